@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,9 +21,15 @@ import (
 	"github.com/pixsaas/backend/internal/domain"
 	"github.com/pixsaas/backend/internal/providers"
 	"github.com/pixsaas/backend/internal/providers/bb"
+	"github.com/pixsaas/backend/internal/providers/bradesco"
 	"github.com/pixsaas/backend/internal/providers/inter"
+	"github.com/pixsaas/backend/internal/providers/itau"
 	"github.com/pixsaas/backend/internal/providers/santander"
+	"github.com/pixsaas/backend/internal/qrimage"
+	"github.com/pixsaas/backend/internal/repository"
 	"github.com/pixsaas/backend/internal/security"
+	"github.com/pixsaas/backend/internal/webhook"
+	"github.com/pixsaas/backend/internal/worker"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
@@ -49,6 +56,11 @@ func main() {
 			&domain.Provider{},
 			&domain.MerchantProvider{},
 			&domain.Transaction{},
+			&domain.TransactionAttempt{},
+			&domain.Refund{},
+			&domain.ScheduledTransfer{},
+			&domain.IdempotencyKey{},
+			&domain.ProviderHealthCheck{},
 			&domain.AuditLog{},
 			&domain.Webhook{},
 			&domain.WebhookDelivery{},
@@ -78,14 +90,118 @@ func main() {
 
 	auditService := audit.NewAuditService(db)
 
-	// Registrar providers
+	// Registrar providers (cada merchant-provider recebe sua própria instância)
 	providerRegistry := providers.NewProviderRegistry()
-	// TODO: Atualizar Bradesco e Itaú para nova interface
-	// providerRegistry.Register(bradesco.NewBradescoProvider())
-	// providerRegistry.Register(itau.NewItauProvider())
-	providerRegistry.Register(bb.NewBBProvider())
-	providerRegistry.Register(santander.NewProvider())
-	providerRegistry.Register(inter.NewInterProvider())
+	providerRegistry.Register(func() providers.PixProvider { return bradesco.NewBradescoProvider() })
+	providerRegistry.Register(func() providers.PixProvider { return itau.NewItauProvider() })
+	providerRegistry.Register(func() providers.PixProvider { return bb.NewBBProvider() })
+	providerRegistry.Register(func() providers.PixProvider { return santander.NewProvider() })
+	providerRegistry.Register(func() providers.PixProvider { return inter.NewInterProvider() })
+
+	providerRepo := repository.NewProviderRepository(db)
+
+	// Circuit breakers alimentam o status de saúde dos providers
+	circuitBreakers := providers.NewCircuitBreakers(providers.CircuitBreakerConfig{
+		FailureThreshold:    cfg.CircuitBreaker.FailureThreshold,
+		OpenTimeout:         cfg.CircuitBreaker.OpenTimeout,
+		HalfOpenMaxCalls:    cfg.CircuitBreaker.HalfOpenMaxCalls,
+		SuccessThreshold:    cfg.CircuitBreaker.SuccessThreshold,
+		PerMerchantProvider: cfg.CircuitBreaker.PerMerchantProvider,
+	}, providerRepo)
+
+	merchantProviderRepo := repository.NewMerchantProviderRepository(db)
+	providerManager := providers.NewProviderManager(providerRegistry, merchantProviderRepo, encryptionService, circuitBreakers)
+	tokenCache := providers.NewTokenCache(merchantProviderRepo, providers.DefaultTokenRefreshMargin)
+
+	// Tokens emitidos com credenciais antigas não devem ser reutilizados
+	providerRegistry.OnInvalidate(tokenCache.Invalidate)
+
+	// Workers em background são encerrados junto com o servidor
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	if cfg.HealthCheck.Enabled {
+		healthChecker := providers.NewHealthChecker(providerManager, providerRepo, merchantProviderRepo, providers.HealthCheckerConfig{
+			Interval:        cfg.HealthCheck.Interval,
+			Timeout:         cfg.HealthCheck.Timeout,
+			DegradedLatency: cfg.HealthCheck.DegradedLatency,
+			Retention:       cfg.HealthCheck.Retention,
+		})
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			healthChecker.Run(workersCtx)
+		}()
+	}
+
+	// Entregas de webhook ficam no banco e são retomadas após reinício
+	webhookDispatcher := webhook.NewDispatcher(repository.NewWebhookRepository(db), encryptionService, auditService, webhook.Config{
+		PollInterval:   cfg.Webhook.PollInterval,
+		BatchSize:      cfg.Webhook.BatchSize,
+		Workers:        cfg.Webhook.Workers,
+		RetryBaseDelay: cfg.Webhook.RetryBaseDelay,
+		RetryMaxDelay:  cfg.Webhook.RetryMaxDelay,
+	})
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		webhookDispatcher.Run(workersCtx)
+	}()
+
+	if cfg.StatusPoller.Enabled {
+		statusPoller := worker.NewStatusPoller(
+			repository.NewTransactionRepository(db),
+			merchantProviderRepo,
+			providerManager,
+			tokenCache,
+			auditService,
+			webhookDispatcher,
+			worker.StatusPollerConfig{
+				Interval:       cfg.StatusPoller.Interval,
+				BatchSize:      cfg.StatusPoller.BatchSize,
+				StaleAfter:     cfg.StatusPoller.StaleAfter,
+				Timeout:        cfg.StatusPoller.Timeout,
+				InitialBackoff: cfg.StatusPoller.InitialBackoff,
+				MaxBackoff:     cfg.StatusPoller.MaxBackoff,
+				ReviewDeadline: cfg.StatusPoller.ReviewDeadline,
+			},
+		)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			statusPoller.Run(workersCtx)
+		}()
+	}
+
+	// Transferências agendadas são executadas pelo mesmo fluxo de fallback das transferências avulsas
+	if cfg.Scheduler.Enabled {
+		transferScheduler := worker.NewTransferScheduler(
+			repository.NewScheduledTransferRepository(db),
+			repository.NewTransactionRepository(db),
+			providerManager,
+			tokenCache,
+			auditService,
+			webhookDispatcher,
+			worker.TransferSchedulerConfig{
+				Interval:  cfg.Scheduler.Interval,
+				BatchSize: cfg.Scheduler.BatchSize,
+				Timeout:   cfg.Scheduler.Timeout,
+			},
+		)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			transferScheduler.Run(workersCtx)
+		}()
+	}
+
+	// Idempotency-Keys expiradas são removidas periodicamente
+	idempotency := middleware.NewIdempotency(repository.NewIdempotencyRepository(db), cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		idempotency.Run(workersCtx)
+	}()
 
 	// Criar aplicação Fiber
 	app := fiber.New(fiber.Config{
@@ -121,27 +237,97 @@ func main() {
 	v1.Post("/auth/login", authHandler.Login)
 	v1.Post("/auth/refresh", authHandler.RefreshToken)
 
+	// Callbacks dos bancos (autenticados por mTLS/HMAC conforme o provider).
+	// A API PIX do BACEN acrescenta "/pix" à URL de webhook cadastrada no banco.
+	callbackHandler := handlers.NewCallbackHandler(db, auditService, encryptionService, providerRegistry, webhookDispatcher, cfg.Callback.ClientCertHeader)
+	v1.Post("/callbacks/:provider", callbackHandler.ReceiveCallback)
+	v1.Post("/callbacks/:provider/pix", callbackHandler.ReceiveCallback)
+
 	// Rotas autenticadas (JWT)
 	authenticated := v1.Group("")
 	authenticated.Use(middleware.AuthMiddleware(jwtService))
 	authenticated.Use(middleware.AuditMiddleware(auditService))
 
+	// Idempotency-Key nas rotas que alteram estado
+	authenticated.Use(idempotency.Middleware())
+
 	authenticated.Get("/auth/me", authHandler.Me)
 	authenticated.Post("/auth/logout", authHandler.Logout)
 
 	// Rotas de transações (requer merchant)
-	txHandler := handlers.NewTransactionHandler(db, auditService, encryptionService, providerRegistry)
+	qrOptions := qrimage.Options{
+		Size:       cfg.QRCode.Size,
+		Level:      qrimage.Level(cfg.QRCode.Level),
+		LogoMargin: cfg.QRCode.LogoMargin,
+	}
+	if err := qrOptions.Validate(); err != nil {
+		log.Fatalf("Configuração de QR Code inválida: %v", err)
+	}
+	txHandler := handlers.NewTransactionHandler(db, auditService, encryptionService, providerManager, tokenCache, webhookDispatcher, qrOptions)
 	transactions := authenticated.Group("/transactions")
 	transactions.Use(middleware.RequireMerchant())
 
 	transactions.Post("/transfer", txHandler.CreateTransfer)
 	transactions.Get("/:id", txHandler.GetTransaction)
+	transactions.Post("/:id/cancel", txHandler.CancelTransfer)
+	transactions.Post("/:id/refunds", txHandler.CreateRefund)
+	transactions.Get("/:id/refunds", txHandler.ListRefunds)
+	transactions.Get("/:id/refunds/:refund_id", txHandler.GetRefund)
 	transactions.Get("", txHandler.ListTransactions)
 
+	// Rotas de cobranças (QR Code estático, dinâmico e com vencimento)
+	charges := authenticated.Group("/charges")
+	charges.Use(middleware.RequireMerchant())
+
+	charges.Post("", txHandler.CreateCharge)
+	charges.Get("/:id", txHandler.GetCharge)
+	charges.Get("/:id/qrcode", txHandler.GetChargeQRCode)
+	charges.Get("/:id/amount", txHandler.GetChargeAmount)
+	charges.Get("", txHandler.ListCharges)
+
+	// Rotas de transferências agendadas e recorrentes (requer merchant)
+	scheduledTransferHandler := handlers.NewScheduledTransferHandler(db, auditService)
+	scheduledTransfers := authenticated.Group("/scheduled-transfers")
+	scheduledTransfers.Use(middleware.RequireMerchant())
+
+	scheduledTransfers.Post("", scheduledTransferHandler.CreateScheduledTransfer)
+	scheduledTransfers.Get("/:id", scheduledTransferHandler.GetScheduledTransfer)
+	scheduledTransfers.Post("/:id/cancel", scheduledTransferHandler.CancelScheduledTransfer)
+	scheduledTransfers.Get("", scheduledTransferHandler.ListScheduledTransfers)
+
+	// Interpretação de códigos PIX copia e cola
+	brcodeHandler := handlers.NewBRCodeHandler()
+	authenticated.Post("/brcode/parse", brcodeHandler.ParseBRCode)
+
+	// Consulta de chaves PIX no DICT, limitada por merchant (regras antivarredura)
+	pixKeyHandler := handlers.NewPixKeyHandler(providerManager, tokenCache, auditService, cfg.Dict.CacheTTL)
+	dictLimiter := middleware.NewWindowRateLimiter(cfg.Dict.RateLimit, cfg.Dict.RateWindow)
+	pixKeys := authenticated.Group("/pix-keys")
+	pixKeys.Use(middleware.RequireMerchant())
+	pixKeys.Get("/:key", dictLimiter.MerchantMiddleware(), pixKeyHandler.LookupPixKey)
+
+	// Rotas de webhooks (requer merchant)
+	webhookHandler := handlers.NewWebhookHandler(db, auditService, encryptionService, webhookDispatcher)
+	webhooks := authenticated.Group("/webhooks")
+	webhooks.Use(middleware.RequireMerchant())
+
+	webhooks.Post("", webhookHandler.CreateWebhook)
+	webhooks.Get("", webhookHandler.ListWebhooks)
+	webhooks.Get("/:id", webhookHandler.GetWebhook)
+	webhooks.Patch("/:id", webhookHandler.UpdateWebhook)
+	webhooks.Delete("/:id", webhookHandler.DeleteWebhook)
+	webhooks.Post("/:id/rotate-secret", webhookHandler.RotateSecret)
+	webhooks.Post("/:id/test", webhookHandler.TestWebhook)
+	webhooks.Get("/:id/deliveries", webhookHandler.ListDeliveries)
+	webhooks.Post("/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverDelivery)
+
 	// Rotas administrativas
 	admin := authenticated.Group("/admin")
 	admin.Use(middleware.RequireRole("admin"))
-	// TODO: Adicionar rotas administrativas
+
+	providerHandler := handlers.NewProviderHandler(db)
+	admin.Get("/providers", providerHandler.ListProviders)
+	admin.Get("/providers/:code/health", providerHandler.GetHealthHistory)
 
 	// Iniciar servidor
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -172,6 +358,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	stopWorkers()
+	workers.Wait()
+
 	log.Println("✅ Servidor desligado com sucesso")
 }
 
@@ -183,6 +372,8 @@ func connectDatabase(cfg *configs.Config) (*gorm.DB, error) {
 
 	db, err := gorm.Open(postgres.Open(cfg.Database.GetDSN()), &gorm.Config{
 		Logger: gormlogger.Default.LogMode(logLevel),
+		// Violações de índice único viram gorm.ErrDuplicatedKey (ex: external_id concorrente)
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, err
@@ -9,12 +9,21 @@ import (
 
 // Config representa a configuração da aplicação
 type Config struct {
-	Server     ServerConfig
-	Database   DatabaseConfig
-	JWT        JWTConfig
-	Encryption EncryptionConfig
-	Audit      AuditConfig
-	Providers  map[string]ProviderConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	JWT            JWTConfig
+	Encryption     EncryptionConfig
+	Audit          AuditConfig
+	CircuitBreaker CircuitBreakerConfig
+	HealthCheck    HealthCheckConfig
+	StatusPoller   StatusPollerConfig
+	Scheduler      SchedulerConfig
+	Webhook        WebhookConfig
+	Callback       CallbackConfig
+	QRCode         QRCodeConfig
+	Idempotency    IdempotencyConfig
+	Dict           DictConfig
+	Providers      map[string]ProviderConfig
 }
 
 // ServerConfig configurações do servidor
@@ -60,6 +69,80 @@ type AuditConfig struct {
 	AsyncLogging   bool
 }
 
+// CircuitBreakerConfig configurações do circuit breaker dos providers
+type CircuitBreakerConfig struct {
+	FailureThreshold    int
+	OpenTimeout         time.Duration
+	HalfOpenMaxCalls    int
+	SuccessThreshold    int
+	PerMerchantProvider bool
+}
+
+// HealthCheckConfig configurações da verificação periódica de saúde dos providers
+type HealthCheckConfig struct {
+	Enabled         bool
+	Interval        time.Duration
+	Timeout         time.Duration
+	DegradedLatency time.Duration
+	Retention       time.Duration
+}
+
+// StatusPollerConfig configurações da consulta de status de transações pendentes
+type StatusPollerConfig struct {
+	Enabled        bool
+	Interval       time.Duration
+	BatchSize      int
+	StaleAfter     time.Duration
+	Timeout        time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	ReviewDeadline time.Duration
+}
+
+// SchedulerConfig configurações da execução de transferências agendadas
+type SchedulerConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+	Timeout   time.Duration // Tempo máximo de cada execução, incluindo o fallback entre providers
+}
+
+// WebhookConfig configurações do envio de webhooks
+type WebhookConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	Workers        int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// CallbackConfig configurações do recebimento de callbacks dos bancos
+type CallbackConfig struct {
+	// Header com o certificado de cliente repassado pelo proxy que termina o TLS (PEM, URL-encoded).
+	// Só deve ser configurado quando o proxy remove esse header das requisições externas.
+	ClientCertHeader string
+}
+
+// QRCodeConfig configurações da imagem de QR Code gerada para as cobranças
+type QRCodeConfig struct {
+	Size       int    // Pixels
+	Level      string // Correção de erros: L, M, Q ou H
+	LogoMargin int    // Percentual central reservado para logo (0 = sem logo)
+}
+
+// IdempotencyConfig configurações do header Idempotency-Key
+type IdempotencyConfig struct {
+	TTL         time.Duration // Tempo em que a resposta armazenada é reproduzida
+	LockTimeout time.Duration // Tempo máximo de uma requisição em andamento antes de a chave ser liberada
+}
+
+// DictConfig configurações da consulta de chaves PIX no DICT
+type DictConfig struct {
+	CacheTTL   time.Duration // Tempo em que o resultado da consulta é reaproveitado
+	RateLimit  int           // Consultas por merchant em cada janela
+	RateWindow time.Duration
+}
+
 // ProviderConfig configurações de providers
 type ProviderConfig struct {
 	BaseURL      string
@@ -136,6 +219,78 @@ func LoadConfig(configPath string) (*Config, error) {
 		AsyncLogging:   viper.GetBool("audit.async_logging"),
 	}
 
+	// Circuit breaker
+	config.CircuitBreaker = CircuitBreakerConfig{
+		FailureThreshold:    viper.GetInt("circuit_breaker.failure_threshold"),
+		OpenTimeout:         viper.GetDuration("circuit_breaker.open_timeout"),
+		HalfOpenMaxCalls:    viper.GetInt("circuit_breaker.half_open_max_calls"),
+		SuccessThreshold:    viper.GetInt("circuit_breaker.success_threshold"),
+		PerMerchantProvider: viper.GetBool("circuit_breaker.per_merchant_provider"),
+	}
+
+	// Health check
+	config.HealthCheck = HealthCheckConfig{
+		Enabled:         viper.GetBool("health_check.enabled"),
+		Interval:        viper.GetDuration("health_check.interval"),
+		Timeout:         viper.GetDuration("health_check.timeout"),
+		DegradedLatency: viper.GetDuration("health_check.degraded_latency"),
+		Retention:       viper.GetDuration("health_check.retention"),
+	}
+
+	// Status poller
+	config.StatusPoller = StatusPollerConfig{
+		Enabled:        viper.GetBool("status_poller.enabled"),
+		Interval:       viper.GetDuration("status_poller.interval"),
+		BatchSize:      viper.GetInt("status_poller.batch_size"),
+		StaleAfter:     viper.GetDuration("status_poller.stale_after"),
+		Timeout:        viper.GetDuration("status_poller.timeout"),
+		InitialBackoff: viper.GetDuration("status_poller.initial_backoff"),
+		MaxBackoff:     viper.GetDuration("status_poller.max_backoff"),
+		ReviewDeadline: viper.GetDuration("status_poller.review_deadline"),
+	}
+
+	// Transferências agendadas
+	config.Scheduler = SchedulerConfig{
+		Enabled:   viper.GetBool("scheduler.enabled"),
+		Interval:  viper.GetDuration("scheduler.interval"),
+		BatchSize: viper.GetInt("scheduler.batch_size"),
+		Timeout:   viper.GetDuration("scheduler.timeout"),
+	}
+
+	// Webhook
+	config.Webhook = WebhookConfig{
+		PollInterval:   viper.GetDuration("webhook.poll_interval"),
+		BatchSize:      viper.GetInt("webhook.batch_size"),
+		Workers:        viper.GetInt("webhook.workers"),
+		RetryBaseDelay: viper.GetDuration("webhook.retry_base_delay"),
+		RetryMaxDelay:  viper.GetDuration("webhook.retry_max_delay"),
+	}
+
+	// Callback
+	config.Callback = CallbackConfig{
+		ClientCertHeader: viper.GetString("callback.client_cert_header"),
+	}
+
+	// QR Code
+	config.QRCode = QRCodeConfig{
+		Size:       viper.GetInt("qrcode.size"),
+		Level:      viper.GetString("qrcode.level"),
+		LogoMargin: viper.GetInt("qrcode.logo_margin"),
+	}
+
+	// Idempotency
+	config.Idempotency = IdempotencyConfig{
+		TTL:         viper.GetDuration("idempotency.ttl"),
+		LockTimeout: viper.GetDuration("idempotency.lock_timeout"),
+	}
+
+	// DICT
+	config.Dict = DictConfig{
+		CacheTTL:   viper.GetDuration("dict.cache_ttl"),
+		RateLimit:  viper.GetInt("dict.rate_limit"),
+		RateWindow: viper.GetDuration("dict.rate_window"),
+	}
+
 	// Providers
 	config.Providers = make(map[string]ProviderConfig)
 	providersMap := viper.GetStringMap("providers")
@@ -182,6 +337,57 @@ func setDefaults() {
 	viper.SetDefault("audit.enabled", true)
 	viper.SetDefault("audit.retention_years", 5)
 	viper.SetDefault("audit.async_logging", true)
+
+	// Circuit breaker defaults
+	viper.SetDefault("circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("circuit_breaker.open_timeout", 30*time.Second)
+	viper.SetDefault("circuit_breaker.half_open_max_calls", 1)
+	viper.SetDefault("circuit_breaker.success_threshold", 2)
+	viper.SetDefault("circuit_breaker.per_merchant_provider", false)
+
+	// Health check defaults
+	viper.SetDefault("health_check.enabled", true)
+	viper.SetDefault("health_check.interval", time.Minute)
+	viper.SetDefault("health_check.timeout", 10*time.Second)
+	viper.SetDefault("health_check.degraded_latency", 3*time.Second)
+	viper.SetDefault("health_check.retention", 30*24*time.Hour)
+
+	// Status poller defaults
+	viper.SetDefault("status_poller.enabled", true)
+	viper.SetDefault("status_poller.interval", 15*time.Second)
+	viper.SetDefault("status_poller.batch_size", 100)
+	viper.SetDefault("status_poller.stale_after", 30*time.Second)
+	viper.SetDefault("status_poller.timeout", 15*time.Second)
+	viper.SetDefault("status_poller.initial_backoff", 30*time.Second)
+	viper.SetDefault("status_poller.max_backoff", 30*time.Minute)
+	viper.SetDefault("status_poller.review_deadline", 24*time.Hour)
+
+	// Scheduler defaults
+	viper.SetDefault("scheduler.enabled", true)
+	viper.SetDefault("scheduler.interval", 30*time.Second)
+	viper.SetDefault("scheduler.batch_size", 50)
+	viper.SetDefault("scheduler.timeout", time.Minute)
+
+	// Webhook defaults
+	viper.SetDefault("webhook.poll_interval", 5*time.Second)
+	viper.SetDefault("webhook.batch_size", 100)
+	viper.SetDefault("webhook.workers", 4)
+	viper.SetDefault("webhook.retry_base_delay", 30*time.Second)
+	viper.SetDefault("webhook.retry_max_delay", time.Hour)
+
+	// QR Code
+	viper.SetDefault("qrcode.size", 256)
+	viper.SetDefault("qrcode.level", "M")
+	viper.SetDefault("qrcode.logo_margin", 0)
+
+	// Idempotency defaults
+	viper.SetDefault("idempotency.ttl", 24*time.Hour)
+	viper.SetDefault("idempotency.lock_timeout", time.Minute)
+
+	// DICT defaults
+	viper.SetDefault("dict.cache_ttl", 5*time.Minute)
+	viper.SetDefault("dict.rate_limit", 60)
+	viper.SetDefault("dict.rate_window", time.Minute)
 }
 
 // GetDSN retorna a string de conexão do banco de dados
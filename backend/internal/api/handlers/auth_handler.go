@@ -9,6 +9,7 @@ import (
 	"github.com/pixsaas/backend/internal/audit"
 	"github.com/pixsaas/backend/internal/repository"
 	"github.com/pixsaas/backend/internal/security"
+	"github.com/pixsaas/backend/internal/validation"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -65,6 +66,10 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := validation.Struct(&req); err != nil {
+		return validationFailed(c, err)
+	}
+
 	// Buscar usuário
 	user, err := h.userRepo.GetByEmail(c.Context(), req.Email)
 	if err != nil {
@@ -142,6 +147,10 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := validation.Struct(&req); err != nil {
+		return validationFailed(c, err)
+	}
+
 	// Validar refresh token
 	userID, err := h.jwtService.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/pixsaas/backend/internal/brcode"
+)
+
+// BRCodeHandler interpreta códigos PIX copia e cola
+type BRCodeHandler struct{}
+
+// NewBRCodeHandler cria um novo handler de BR Code
+func NewBRCodeHandler() *BRCodeHandler {
+	return &BRCodeHandler{}
+}
+
+// ParseBRCodeRequest representa um código copia e cola a ser interpretado
+type ParseBRCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// BRCodeResponse representa os dados de um BR Code
+type BRCodeResponse struct {
+	Type         string `json:"type"` // static ou dynamic
+	PixKey       string `json:"pix_key,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Amount       int64  `json:"amount"` // Centavos (0 para valor livre)
+	MerchantName string `json:"merchant_name"`
+	MerchantCity string `json:"merchant_city"`
+	PostalCode   string `json:"postal_code,omitempty"`
+	TxID         string `json:"txid,omitempty"`
+	SingleUse    bool   `json:"single_use"`
+}
+
+// ParseBRCode decodifica um código copia e cola informado pelo cliente
+func (h *BRCodeHandler) ParseBRCode(c *fiber.Ctx) error {
+	var req ParseBRCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "code is required",
+		})
+	}
+
+	payload, err := brcode.Decode(req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "invalid BR Code",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(toBRCodeResponse(payload))
+}
+
+// toBRCodeResponse converte o payload decodificado para a resposta da API
+func toBRCodeResponse(p *brcode.Payload) BRCodeResponse {
+	resp := BRCodeResponse{
+		Type:         ChargeTypeStatic,
+		PixKey:       p.PixKey,
+		URL:          p.URL,
+		Description:  p.Description,
+		Amount:       p.Amount,
+		MerchantName: p.MerchantName,
+		MerchantCity: p.MerchantCity,
+		PostalCode:   p.PostalCode,
+		TxID:         p.TxID,
+		SingleUse:    p.SingleUse,
+	}
+	if p.IsDynamic() {
+		resp.Type = ChargeTypeDynamic
+	}
+	return resp
+}
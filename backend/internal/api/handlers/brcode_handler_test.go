@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseBRCode(t *testing.T) {
+	app := fiber.New()
+	app.Post("/brcode/parse", NewBRCodeHandler().ParseBRCode)
+
+	const code = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid", `{"code":"` + code + `"}`, http.StatusOK},
+		{"invalid crc", `{"code":"` + code[:len(code)-4] + `0000"}`, http.StatusBadRequest},
+		{"missing code", `{}`, http.StatusBadRequest},
+		{"invalid body", `not json`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/brcode/parse", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to execute request: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var got BRCodeResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if got.Type != ChargeTypeStatic || got.PixKey != "123e4567-e12b-12d1-a456-426655440000" || got.MerchantCity != "BRASILIA" {
+				t.Errorf("response = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pixsaas/backend/internal/audit"
+	"github.com/pixsaas/backend/internal/callback"
+	"github.com/pixsaas/backend/internal/providers"
+	"github.com/pixsaas/backend/internal/repository"
+	"github.com/pixsaas/backend/internal/security"
+	"github.com/pixsaas/backend/internal/webhook"
+	"gorm.io/gorm"
+)
+
+// CallbackHandler recebe os callbacks (webhooks PIX) enviados pelos bancos
+type CallbackHandler struct {
+	providerRepo      *repository.ProviderRepository
+	registry          *providers.ProviderRegistry
+	auditService      *audit.AuditService
+	encryptionService *security.EncryptionService
+	processor         *callback.Processor
+	clientCertHeader  string
+}
+
+// NewCallbackHandler cria um novo handler de callbacks dos bancos
+func NewCallbackHandler(
+	db *gorm.DB,
+	auditService *audit.AuditService,
+	encryptionService *security.EncryptionService,
+	registry *providers.ProviderRegistry,
+	webhooks *webhook.Dispatcher,
+	clientCertHeader string,
+) *CallbackHandler {
+	return &CallbackHandler{
+		providerRepo:      repository.NewProviderRepository(db),
+		registry:          registry,
+		auditService:      auditService,
+		encryptionService: encryptionService,
+		processor:         callback.NewProcessor(repository.NewTransactionRepository(db), auditService, webhooks),
+		clientCertHeader:  clientCertHeader,
+	}
+}
+
+// ReceiveCallback autentica o callback com as regras do provider e conclui as transações pagas
+func (h *CallbackHandler) ReceiveCallback(c *fiber.Ctx) error {
+	code := c.Params("provider")
+
+	provider, err := h.providerRepo.GetByCode(c.Context(), code)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "provider not found",
+		})
+	}
+
+	impl, ok := h.registry.Get(code)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "provider not supported",
+		})
+	}
+
+	var secret string
+	if provider.Config.CallbackSecret != "" {
+		secret, err = h.encryptionService.Decrypt(provider.Config.CallbackSecret)
+		if err != nil {
+			log.Printf("Aviso: falha ao descriptografar segredo de callback do provider %s: %v", code, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "callback authentication misconfigured",
+			})
+		}
+	}
+
+	auth, err := providers.NewCallbackAuth(provider.Config, secret)
+	if err != nil {
+		log.Printf("Aviso: configuração de callback inválida para o provider %s: %v", code, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "callback authentication misconfigured",
+		})
+	}
+
+	headers := make(map[string]string)
+	for name, values := range c.GetReqHeaders() {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	notifications, err := impl.ParseCallback(c.Context(), &providers.CallbackRequest{
+		Body:             c.Body(),
+		Headers:          headers,
+		PeerCertificates: h.peerCertificates(c),
+		Auth:             auth,
+	})
+	if err != nil {
+		var providerErr *providers.ProviderError
+		if errors.As(err, &providerErr) && providerErr.Code == providers.ErrCodeCallbackUnauthorized {
+			_ = h.auditService.LogSecurityEvent(c.Context(), "callback_unauthorized", err.Error(), c.IP(), "high", map[string]interface{}{
+				"provider": code,
+			})
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "unauthorized",
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := h.processor.Process(c.Context(), provider, notifications)
+	if err != nil {
+		// Erro de persistência: o banco reenvia o callback
+		log.Printf("Aviso: falha ao processar callback do provider %s: %v", code, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to process callback",
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// peerCertificates retorna o certificado de cliente do banco, da conexão TLS ou do header do proxy
+func (h *CallbackHandler) peerCertificates(c *fiber.Ctx) []*x509.Certificate {
+	if state := c.Context().TLSConnectionState(); state != nil && len(state.PeerCertificates) > 0 {
+		return state.PeerCertificates
+	}
+
+	if h.clientCertHeader == "" {
+		return nil
+	}
+	value, err := url.QueryUnescape(c.Get(h.clientCertHeader))
+	if err != nil {
+		return nil
+	}
+
+	var certs []*x509.Certificate
+	rest := []byte(value)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
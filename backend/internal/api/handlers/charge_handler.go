@@ -0,0 +1,665 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
+	"github.com/pixsaas/backend/internal/providers"
+	"github.com/pixsaas/backend/internal/qrimage"
+	"github.com/pixsaas/backend/internal/validation"
+)
+
+// Tipos de cobrança aceitos na API
+const (
+	ChargeTypeStatic  = "static"
+	ChargeTypeDynamic = "dynamic"
+	ChargeTypeDueDate = "due_date" // Cobrança com vencimento (cobv)
+)
+
+// Limites de expiração de cobranças dinâmicas
+const (
+	defaultChargeExpiresIn = 3600              // 1 hora
+	maxChargeExpiresIn     = 30 * 24 * 60 * 60 // 30 dias
+)
+
+// chargeTypes são os tipos de transação que representam cobranças
+var chargeTypes = []domain.TransactionType{
+	domain.TransactionTypeQRCodeStatic,
+	domain.TransactionTypeQRCodeDynamic,
+	domain.TransactionTypeQRCodeDueDate,
+}
+
+// CreateChargeRequest representa uma requisição de cobrança (QR Code)
+type CreateChargeRequest struct {
+	ExternalID   string `json:"external_id" validate:"required,max=255"`
+	Type         string `json:"type" validate:"required,oneof=static dynamic due_date"`
+	Amount       int64  `json:"amount" validate:"min=0"` // 0 em cobrança estática: valor livre
+	Description  string `json:"description" validate:"max=140"`
+	ExpiresIn    int    `json:"expires_in,omitempty"` // Segundos (cobrança dinâmica)
+	ProviderCode string `json:"provider_code,omitempty"`
+
+	// Vencimento, multa, juros, abatimento e desconto (cobrança com vencimento)
+	DueDate *domain.DueDateTerms `json:"due_date,omitempty"`
+
+	// Devedor (obrigatório na cobrança com vencimento)
+	PayerName     string `json:"payer_name,omitempty" validate:"max=140"`
+	PayerDocument string `json:"payer_document,omitempty" validate:"document"`
+
+	// Metadata opcional
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ChargeResponse representa a resposta de uma cobrança
+type ChargeResponse struct {
+	ID          uuid.UUID                `json:"id"`
+	ExternalID  string                   `json:"external_id"`
+	Type        string                   `json:"type"`
+	TxID        string                   `json:"txid,omitempty"`
+	E2EID       string                   `json:"e2e_id,omitempty"`
+	Status      domain.TransactionStatus `json:"status"`
+	Amount      int64                    `json:"amount"`
+	Description string                   `json:"description"`
+	Provider    string                   `json:"provider"`
+	QRCode      string                   `json:"qr_code"`                 // Pix copia e cola
+	QRCodeImage string                   `json:"qr_code_image,omitempty"` // Base64
+	ExpiresAt   string                   `json:"expires_at,omitempty"`
+	DueDate     *domain.DueDateTerms     `json:"due_date,omitempty"`
+	PaidAt      string                   `json:"paid_at,omitempty"`
+	PaidAmount  *int64                   `json:"paid_amount,omitempty"`
+	CreatedAt   string                   `json:"created_at"`
+	UpdatedAt   string                   `json:"updated_at"`
+}
+
+// ChargeAmountResponse representa o valor a pagar de uma cobrança em uma data
+type ChargeAmountResponse struct {
+	Date      string `json:"date"`
+	Amount    int64  `json:"amount"`     // Valor original
+	AmountDue int64  `json:"amount_due"` // Com abatimento, desconto, multa e juros
+}
+
+// CreateCharge cria uma cobrança PIX (QR Code estático, dinâmico ou com vencimento)
+func (h *TransactionHandler) CreateCharge(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	var req CreateChargeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return validationFailed(c, err)
+	}
+	if err := reservedExternalID(req.ExternalID); err != nil {
+		return err
+	}
+
+	if err := validateChargeRequest(&req, time.Now()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Verificar se external_id já existe
+	existing, _ := h.txRepo.GetByExternalID(c.Context(), *merchantID, req.ExternalID)
+	if existing != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "external_id already exists",
+		})
+	}
+
+	merchant, err := h.merchantRepo.GetByID(c.Context(), *merchantID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "merchant not found",
+		})
+	}
+
+	txType := chargeTransactionType(req.Type)
+
+	// O merchant é o recebedor da cobrança
+	tx := &domain.Transaction{
+		ID:            uuid.New(),
+		MerchantID:    *merchantID,
+		ExternalID:    req.ExternalID,
+		Type:          txType,
+		Status:        domain.TransactionStatusPending,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		PayerName:     req.PayerName,
+		PayerDocument: req.PayerDocument,
+		PayeeName:     merchant.Name,
+		PayeeDocument: merchant.Document,
+		DueDate:       req.DueDate,
+		Metadata:      req.Metadata,
+	}
+
+	var selectedProvider *domain.Provider
+	var qrResp *providers.QRCodeResponse
+	txCreated := false
+
+	attempts, chargeErr := h.providerManager.ExecuteWithFallback(c.Context(), *merchantID, req.ProviderCode, func(providerImpl providers.PixProvider, merchantProvider *domain.MerchantProvider) error {
+		selectedProvider = &merchantProvider.Provider
+		tx.ProviderID = merchantProvider.ProviderID
+		tx.PayeePixKey = merchantProvider.PixKey
+		tx.PayeePixKeyType = merchantProvider.PixKeyType
+
+		if !txCreated {
+			if err := h.createTransaction(c, tx); err != nil {
+				return err
+			}
+			txCreated = true
+		} else if err := h.txRepo.Update(c.Context(), tx); err != nil {
+			return errTransactionPersistence
+		}
+
+		// Chave mal configurada no provider: nada é enviado ao banco e o próximo provider pode ser tentado
+		payeeKey, err := pixkey.Normalize(merchantProvider.PixKeyType, merchantProvider.PixKey)
+		if err != nil {
+			return &providers.ProviderError{
+				Code:      providers.ErrCodeInvalidPixKey,
+				Message:   "Chave PIX do merchant inválida: " + err.Error(),
+				Retryable: true,
+			}
+		}
+		tx.PayeePixKey = payeeKey.Value
+		tx.PayeePixKeyType = payeeKey.Type
+
+		token, credentials, err := h.providerToken(c, providerImpl, merchantProvider)
+		if err != nil {
+			return err
+		}
+
+		qrReq := &providers.QRCodeRequest{
+			ExternalID:      req.ExternalID,
+			Amount:          req.Amount,
+			Description:     req.Description,
+			PayeeName:       merchant.Name,
+			PayeeDocument:   merchant.Document,
+			PayeeCity:       merchant.City,
+			PixKey:          payeeKey.Value,
+			PayeePixKey:     payeeKey.Value,
+			PayeePixKeyType: payeeKey.Type,
+			TxID:            chargeTxID(tx.ID),
+			ExpiresIn:       req.ExpiresIn,
+			AllowChange:     req.Amount == 0,
+			DueDate:         req.DueDate,
+			PayerName:       req.PayerName,
+			PayerDocument:   req.PayerDocument,
+			Metadata:        req.Metadata,
+			AuthToken:       token.AccessToken,
+			ClientID:        credentials.ClientID,
+		}
+
+		var resp *providers.QRCodeResponse
+		if txType != domain.TransactionTypeQRCodeStatic {
+			resp, err = providerImpl.CreateQRCodeDynamic(c.Context(), qrReq)
+		} else {
+			resp, err = providerImpl.CreateQRCodeStatic(c.Context(), qrReq)
+		}
+		if err != nil {
+			h.tokenCache.InvalidateRevoked(err, merchantProvider)
+			return err
+		}
+		qrResp = resp
+		return nil
+	})
+
+	switch {
+	case errors.Is(chargeErr, providers.ErrProviderNotConfigured):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant not configured for this provider",
+		})
+	case errors.Is(chargeErr, providers.ErrNoHealthyProvider):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "no active providers configured",
+		})
+	case errors.Is(chargeErr, errDuplicateExternalID):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "external_id already exists",
+		})
+	case !txCreated:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create transaction",
+		})
+	}
+
+	providers.RecordAttempts(c.Context(), h.txRepo, h.auditService, tx, attempts, "create_charge")
+
+	// O QR Code estático não depende do banco: com os providers indisponíveis, o BR Code é gerado localmente
+	localQRCode := false
+	if chargeErr != nil && txType == domain.TransactionTypeQRCodeStatic && isRetryableError(chargeErr) {
+		resp, err := providers.NewLocalStaticQRCode(&providers.QRCodeRequest{
+			Amount:      req.Amount,
+			Description: req.Description,
+			PayeeName:   merchant.Name,
+			PayeeCity:   merchant.City,
+			PixKey:      tx.PayeePixKey,
+			TxID:        chargeTxID(tx.ID),
+		})
+		if err != nil {
+			log.Printf("Aviso: falha ao gerar BR Code local da cobrança %s: %v", tx.ID, err)
+		} else {
+			qrResp = resp
+			chargeErr = nil
+			localQRCode = true
+		}
+	}
+
+	if chargeErr != nil {
+		applyProviderError(tx, chargeErr)
+		if err := h.txRepo.Update(c.Context(), tx); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to update transaction",
+			})
+		}
+
+		h.notifyStatus(c, tx, selectedProvider)
+
+		return c.Status(providerErrorStatus(tx.ErrorCode)).JSON(fiber.Map{
+			"error":   "charge failed",
+			"code":    tx.ErrorCode,
+			"details": tx.ErrorMessage,
+		})
+	}
+
+	// Atualizar transação com o QR Code emitido pelo provider
+	tx.ProviderTxID = qrResp.QRCodeID
+	tx.QRCode = qrResp.QRCode
+	tx.QRCodeImage = qrResp.QRCodeImage
+	h.ensureQRCodeImage(tx)
+	tx.QRCodeExpiresAt = qrResp.ExpiresAt
+	if tx.QRCodeExpiresAt == nil && txType == domain.TransactionTypeQRCodeDynamic {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		tx.QRCodeExpiresAt = &expiresAt
+	}
+	if tx.QRCodeExpiresAt == nil && tx.DueDate != nil {
+		if deadline, err := tx.DueDate.Deadline(); err == nil {
+			tx.QRCodeExpiresAt = &deadline
+		}
+	}
+
+	if err := h.txRepo.Update(c.Context(), tx); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update transaction",
+		})
+	}
+
+	// Log de auditoria
+	_ = h.auditService.LogTransaction(c.Context(), *merchantID, uuid.Nil, tx.ID, "create_charge", map[string]interface{}{
+		"provider":     selectedProvider.Code,
+		"type":         req.Type,
+		"amount":       req.Amount,
+		"local_brcode": localQRCode,
+	})
+
+	h.notifyStatus(c, tx, selectedProvider)
+
+	tx.Provider = *selectedProvider
+	return c.Status(fiber.StatusCreated).JSON(toChargeResponse(tx))
+}
+
+// GetCharge busca uma cobrança por ID. Cobranças dinâmicas pendentes são atualizadas
+// com o QR Code e a remoção informados pelo provider; o pagamento chega pelo callback.
+func (h *TransactionHandler) GetCharge(c *fiber.Ctx) error {
+	tx, err := h.findCharge(c)
+	if err != nil {
+		return err
+	}
+
+	if tx.Type == domain.TransactionTypeQRCodeDynamic && tx.Status == domain.TransactionStatusPending && tx.ProviderTxID != "" {
+		h.refreshCharge(c, tx)
+	}
+	h.ensureQRCodeImage(tx)
+
+	return c.JSON(toChargeResponse(tx))
+}
+
+// GetChargeQRCode retorna a imagem do QR Code da cobrança (PNG ou SVG).
+// Formato, tamanho, correção de erros e margem para logo podem ser informados na query.
+func (h *TransactionHandler) GetChargeQRCode(c *fiber.Ctx) error {
+	tx, err := h.findCharge(c)
+	if err != nil {
+		return err
+	}
+
+	if tx.QRCode == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "charge has no QR code",
+		})
+	}
+
+	opts := qrimage.Options{
+		Format:     qrimage.Format(c.Query("format", string(qrimage.FormatPNG))),
+		Size:       c.QueryInt("size", h.qrOptions.Size),
+		Level:      qrimage.Level(c.Query("level", string(h.qrOptions.Level))),
+		LogoMargin: c.QueryInt("logo_margin", h.qrOptions.LogoMargin),
+	}
+
+	image, err := qrimage.Render(tx.QRCode, opts)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, opts.Format.ContentType())
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.Send(image)
+}
+
+// GetChargeAmount calcula o valor a pagar de uma cobrança com vencimento na data
+// informada (AAAA-MM-DD, padrão hoje). Datas após a validade retornam 422.
+func (h *TransactionHandler) GetChargeAmount(c *fiber.Ctx) error {
+	tx, err := h.findCharge(c)
+	if err != nil {
+		return err
+	}
+
+	if tx.DueDate == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "charge has no due date",
+		})
+	}
+
+	at := time.Now()
+	if date := c.Query("date"); date != "" {
+		at, err = time.ParseInLocation("2006-01-02", date, domain.ChargeLocation)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "date must be in YYYY-MM-DD format",
+			})
+		}
+	}
+
+	amountDue, err := tx.DueDate.AmountDue(tx.Amount, at)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, domain.ErrChargeOverdue) {
+			status = fiber.StatusUnprocessableEntity
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(ChargeAmountResponse{
+		Date:      at.In(domain.ChargeLocation).Format("2006-01-02"),
+		Amount:    tx.Amount,
+		AmountDue: amountDue,
+	})
+}
+
+// ListCharges lista as cobranças do merchant
+func (h *TransactionHandler) ListCharges(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	// Parâmetros de paginação
+	limit := c.QueryInt("limit", 50)
+	offset := c.QueryInt("offset", 0)
+
+	// Filtros
+	filters := map[string]interface{}{
+		"types": chargeTypes,
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = domain.TransactionStatus(status)
+	}
+	switch chargeType := c.Query("type"); chargeType {
+	case ChargeTypeStatic, ChargeTypeDynamic, ChargeTypeDueDate:
+		filters["type"] = chargeTransactionType(chargeType)
+	}
+
+	transactions, total, err := h.txRepo.ListByMerchant(c.Context(), *merchantID, filters, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list charges",
+		})
+	}
+
+	response := make([]ChargeResponse, 0, len(transactions))
+	for i := range transactions {
+		response = append(response, toChargeResponse(&transactions[i]))
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   response,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// findCharge busca a cobrança do path e verifica se pertence ao merchant
+func (h *TransactionHandler) findCharge(c *fiber.Ctx) (*domain.Transaction, error) {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "merchant not found in context")
+	}
+
+	txID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid charge id")
+	}
+
+	tx, err := h.txRepo.GetByID(c.Context(), txID)
+	if err != nil || !isChargeType(tx.Type) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "charge not found")
+	}
+
+	// Verificar se pertence ao merchant
+	if tx.MerchantID != *merchantID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "access denied")
+	}
+
+	return tx, nil
+}
+
+// ensureQRCodeImage renderiza a imagem do QR Code quando o banco devolve apenas o copia e cola
+func (h *TransactionHandler) ensureQRCodeImage(tx *domain.Transaction) {
+	if tx.QRCode == "" || tx.QRCodeImage != "" {
+		return
+	}
+
+	opts := h.qrOptions
+	opts.Format = qrimage.FormatPNG
+	image, err := qrimage.RenderBase64(tx.QRCode, opts)
+	if err != nil {
+		log.Printf("Aviso: falha ao renderizar QR Code da cobrança %s: %v", tx.ID, err)
+		return
+	}
+	tx.QRCodeImage = image
+}
+
+// refreshCharge consulta a cobrança no provider, completa os dados do QR Code e registra a remoção.
+// A consulta não traz os dados do PIX recebido (E2EID, valor, data), então a conclusão fica a
+// cargo do callback, que confere valor e vencimento. Falhas na consulta não impedem a resposta.
+func (h *TransactionHandler) refreshCharge(c *fiber.Ctx, tx *domain.Transaction) {
+	merchantProvider, err := h.merchantProviderRepo.GetByMerchantAndProvider(c.Context(), tx.MerchantID, tx.ProviderID)
+	if err != nil {
+		return
+	}
+
+	providerImpl, err := h.providerManager.Instance(merchantProvider)
+	if err != nil {
+		return
+	}
+
+	token, credentials, err := h.providerToken(c, providerImpl, merchantProvider)
+	if err != nil {
+		log.Printf("Aviso: falha ao autenticar consulta da cobrança %s: %v", tx.ID, err)
+		return
+	}
+
+	resp, err := providerImpl.GetQRCode(c.Context(), &providers.GetQRCodeRequest{
+		QRCodeID:  tx.ProviderTxID,
+		AuthToken: token.AccessToken,
+		ClientID:  credentials.ClientID,
+	})
+	if err != nil {
+		h.tokenCache.InvalidateRevoked(err, merchantProvider)
+		log.Printf("Aviso: falha ao consultar cobrança %s no provider: %v", tx.ID, err)
+		return
+	}
+
+	if tx.QRCode == "" {
+		tx.QRCode = resp.QRCode
+	}
+	if tx.QRCodeImage == "" {
+		tx.QRCodeImage = resp.QRCodeImage
+	}
+
+	if resp.Status != "REMOVIDA_PELO_USUARIO_RECEBEDOR" && resp.Status != "REMOVIDA_PELO_PSP" {
+		return
+	}
+
+	previous := tx.Status
+	now := time.Now()
+	tx.Status = domain.TransactionStatusCancelled
+	tx.CancelledAt = &now
+
+	updated, err := h.txRepo.UpdateIfStatus(c.Context(), tx, previous)
+	if err != nil || !updated {
+		return
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "status_transition", map[string]interface{}{
+		"from":     previous,
+		"to":       tx.Status,
+		"provider": tx.Provider.Code,
+		"source":   "charge_query",
+	})
+
+	h.notifyStatus(c, tx, nil)
+}
+
+// validateChargeRequest valida os campos específicos de cada tipo de cobrança criada em now
+func validateChargeRequest(req *CreateChargeRequest, now time.Time) error {
+	if req.ExternalID == "" {
+		return errors.New("external_id is required")
+	}
+	if req.Amount < 0 {
+		return errors.New("amount must not be negative")
+	}
+
+	if req.DueDate != nil && req.Type != ChargeTypeDueDate {
+		return errors.New("due_date is only supported for due_date charges")
+	}
+
+	switch req.Type {
+	case ChargeTypeStatic:
+		if req.ExpiresIn != 0 {
+			return errors.New("expires_in is only supported for dynamic charges")
+		}
+	case ChargeTypeDynamic:
+		if req.Amount == 0 {
+			return errors.New("amount is required for dynamic charges")
+		}
+		if req.ExpiresIn == 0 {
+			req.ExpiresIn = defaultChargeExpiresIn
+		}
+		if req.ExpiresIn < 0 || req.ExpiresIn > maxChargeExpiresIn {
+			return errors.New("expires_in must be between 1 and 2592000 seconds")
+		}
+	case ChargeTypeDueDate:
+		if req.DueDate == nil {
+			return errors.New("due_date is required for due_date charges")
+		}
+		if req.ExpiresIn != 0 {
+			return errors.New("expires_in is only supported for dynamic charges")
+		}
+		if req.PayerName == "" || req.PayerDocument == "" {
+			return errors.New("payer_name and payer_document are required for due_date charges")
+		}
+		if err := req.DueDate.Validate(req.Amount, now); err != nil {
+			return err
+		}
+	default:
+		return errors.New("type must be static, dynamic or due_date")
+	}
+
+	return nil
+}
+
+// chargeTransactionType converte o tipo de cobrança da API no tipo de transação
+func chargeTransactionType(chargeType string) domain.TransactionType {
+	switch chargeType {
+	case ChargeTypeDynamic:
+		return domain.TransactionTypeQRCodeDynamic
+	case ChargeTypeDueDate:
+		return domain.TransactionTypeQRCodeDueDate
+	}
+	return domain.TransactionTypeQRCodeStatic
+}
+
+// chargeTxID deriva da transação o txid da cobrança (até 25 caracteres alfanuméricos)
+func chargeTxID(id uuid.UUID) string {
+	return strings.ReplaceAll(id.String(), "-", "")[:25]
+}
+
+// isRetryableError indica se a falha do provider é temporária
+func isRetryableError(err error) bool {
+	var providerErr *providers.ProviderError
+	return errors.As(err, &providerErr) && providerErr.Retryable
+}
+
+// isChargeType indica se a transação é uma cobrança
+func isChargeType(txType domain.TransactionType) bool {
+	for _, chargeType := range chargeTypes {
+		if txType == chargeType {
+			return true
+		}
+	}
+	return false
+}
+
+// toChargeResponse converte uma transação de cobrança para a resposta da API
+func toChargeResponse(tx *domain.Transaction) ChargeResponse {
+	resp := ChargeResponse{
+		ID:          tx.ID,
+		ExternalID:  tx.ExternalID,
+		Type:        ChargeTypeStatic,
+		TxID:        tx.ProviderTxID,
+		E2EID:       tx.E2EID,
+		Status:      tx.Status,
+		Amount:      tx.Amount,
+		Description: tx.Description,
+		Provider:    tx.Provider.Code,
+		QRCode:      tx.QRCode,
+		QRCodeImage: tx.QRCodeImage,
+		CreatedAt:   tx.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   tx.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	switch tx.Type {
+	case domain.TransactionTypeQRCodeDynamic:
+		resp.Type = ChargeTypeDynamic
+	case domain.TransactionTypeQRCodeDueDate:
+		resp.Type = ChargeTypeDueDate
+		resp.DueDate = tx.DueDate
+	}
+	if tx.QRCodeExpiresAt != nil {
+		resp.ExpiresAt = tx.QRCodeExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	if tx.CompletedAt != nil {
+		resp.PaidAt = tx.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	resp.PaidAmount = tx.PaidAmount
+	return resp
+}
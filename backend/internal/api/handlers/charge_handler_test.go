@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/qrimage"
+)
+
+func TestValidateChargeRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           CreateChargeRequest
+		wantErr       bool
+		wantExpiresIn int
+	}{
+		{"static with amount", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeStatic, Amount: 1000}, false, 0},
+		{"static open amount", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeStatic}, false, 0},
+		{"static with expiration", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeStatic, ExpiresIn: 60}, true, 0},
+		{"dynamic default expiration", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDynamic, Amount: 1000}, false, defaultChargeExpiresIn},
+		{"dynamic custom expiration", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDynamic, Amount: 1000, ExpiresIn: 600}, false, 600},
+		{"dynamic without amount", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDynamic}, true, 0},
+		{"dynamic expiration too long", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDynamic, Amount: 1000, ExpiresIn: maxChargeExpiresIn + 1}, true, 0},
+		{"negative amount", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeStatic, Amount: -1}, true, 0},
+		{"missing external_id", CreateChargeRequest{Type: ChargeTypeStatic}, true, 0},
+		{"unknown type", CreateChargeRequest{ExternalID: "c1", Type: "cobv"}, true, 0},
+		{"due date", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDueDate, Amount: 1000, PayerName: "Fulano", PayerDocument: "12345678909", DueDate: &domain.DueDateTerms{DueDate: "2024-10-31"}}, false, 0},
+		{"due date without terms", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDueDate, Amount: 1000, PayerName: "Fulano", PayerDocument: "12345678909"}, true, 0},
+		{"due date without payer", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDueDate, Amount: 1000, DueDate: &domain.DueDateTerms{DueDate: "2024-10-31"}}, true, 0},
+		{"due date invalid terms", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDueDate, Amount: 1000, PayerName: "Fulano", PayerDocument: "12345678909", DueDate: &domain.DueDateTerms{DueDate: "31/10/2024"}}, true, 0},
+		{"terms on dynamic charge", CreateChargeRequest{ExternalID: "c1", Type: ChargeTypeDynamic, Amount: 1000, DueDate: &domain.DueDateTerms{DueDate: "2024-10-31"}}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := validateChargeRequest(&req, time.Date(2024, 10, 1, 12, 0, 0, 0, domain.ChargeLocation))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateChargeRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && req.ExpiresIn != tt.wantExpiresIn {
+				t.Errorf("ExpiresIn = %d, want %d", req.ExpiresIn, tt.wantExpiresIn)
+			}
+		})
+	}
+}
+
+func TestToChargeResponse(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)
+	tx := &domain.Transaction{
+		ExternalID:      "c1",
+		Type:            domain.TransactionTypeQRCodeDynamic,
+		Status:          domain.TransactionStatusPending,
+		Amount:          1000,
+		ProviderTxID:    "cob123",
+		QRCode:          "00020101021226...",
+		QRCodeExpiresAt: &expiresAt,
+		Provider:        domain.Provider{Code: "bb"},
+	}
+
+	resp := toChargeResponse(tx)
+	if resp.Type != ChargeTypeDynamic || resp.TxID != "cob123" || resp.Provider != "bb" {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.ExpiresAt != "2024-01-01T13:00:00Z" || resp.PaidAt != "" {
+		t.Errorf("ExpiresAt = %q, PaidAt = %q", resp.ExpiresAt, resp.PaidAt)
+	}
+
+	tx.Type = domain.TransactionTypeQRCodeStatic
+	if got := toChargeResponse(tx).Type; got != ChargeTypeStatic {
+		t.Errorf("Type = %q, want %q", got, ChargeTypeStatic)
+	}
+
+	tx.Type = domain.TransactionTypeQRCodeDueDate
+	tx.DueDate = &domain.DueDateTerms{DueDate: "2024-10-31"}
+	if got := toChargeResponse(tx); got.Type != ChargeTypeDueDate || got.DueDate != tx.DueDate {
+		t.Errorf("Type = %q, DueDate = %v", got.Type, got.DueDate)
+	}
+}
+
+func TestEnsureQRCodeImage(t *testing.T) {
+	h := &TransactionHandler{qrOptions: qrimage.Options{Size: 200, Level: qrimage.LevelHigh, LogoMargin: 20}}
+	tx := &domain.Transaction{QRCode: "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"}
+
+	h.ensureQRCodeImage(tx)
+
+	data, err := base64.StdEncoding.DecodeString(tx.QRCodeImage)
+	if err != nil {
+		t.Fatalf("QRCodeImage is not base64: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("QRCodeImage is not a PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 200 {
+		t.Errorf("image width = %d, want 200", img.Bounds().Dx())
+	}
+
+	// Imagem enviada pelo banco é mantida
+	tx.QRCodeImage = "imagem-do-banco"
+	h.ensureQRCodeImage(tx)
+	if tx.QRCodeImage != "imagem-do-banco" {
+		t.Errorf("QRCodeImage = %q, want bank image", tx.QRCodeImage)
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/audit"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+// PixKeyHandler consulta chaves PIX no DICT pelos bancos do merchant
+type PixKeyHandler struct {
+	providerManager *providers.ProviderManager
+	tokenCache      *providers.TokenCache
+	auditService    *audit.AuditService
+	cache           *pixKeyCache
+}
+
+// NewPixKeyHandler cria um novo handler de consulta de chaves. Resultados (inclusive chave
+// não encontrada) são reaproveitados por cacheTTL; zero desativa o cache.
+func NewPixKeyHandler(
+	providerManager *providers.ProviderManager,
+	tokenCache *providers.TokenCache,
+	auditService *audit.AuditService,
+	cacheTTL time.Duration,
+) *PixKeyHandler {
+	return &PixKeyHandler{
+		providerManager: providerManager,
+		tokenCache:      tokenCache,
+		auditService:    auditService,
+		cache:           newPixKeyCache(cacheTTL),
+	}
+}
+
+// PixKeyResponse representa o vínculo de uma chave PIX com os dados do titular mascarados
+type PixKeyResponse struct {
+	PixKey      string            `json:"pix_key"`
+	PixKeyType  domain.PixKeyType `json:"pix_key_type"`
+	HolderName  string            `json:"holder_name"`     // Pessoa física: primeiro nome e inicial do sobrenome
+	Document    string            `json:"holder_document"` // CPF mascarado (***.456.789-**) ou CNPJ completo
+	ISPB        string            `json:"ispb"`
+	AccountType string            `json:"account_type,omitempty"`
+}
+
+// LookupPixKey consulta o titular de uma chave PIX para confirmação antes do pagamento.
+// O tipo é detectado pela chave ou informado em ?type=; ?provider= escolhe o banco preferido.
+func (h *PixKeyHandler) LookupPixKey(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	rawKey, err := url.PathUnescape(c.Params("key"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid pix key encoding",
+		})
+	}
+
+	key, err := pixkey.Normalize(domain.PixKeyType(c.Query("type")), rawKey)
+	if err == nil && key.Type == domain.PixKeyTypeAccount {
+		err = pixkey.ErrInvalidKey
+	}
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "invalid pix key",
+			"details": err.Error(),
+		})
+	}
+
+	if entry, found := h.cache.get(*merchantID, key.Value); found {
+		return pixKeyResult(c, key, entry)
+	}
+
+	var entry *providers.ValidatePixKeyResponse
+	var selectedProvider string
+	_, lookupErr := h.providerManager.ExecuteWithFallback(c.Context(), *merchantID, c.Query("provider"), func(providerImpl providers.PixProvider, merchantProvider *domain.MerchantProvider) error {
+		selectedProvider = merchantProvider.Provider.Code
+
+		// Bancos sem consulta ao DICT são ignorados em favor do próximo provider
+		if !providers.SupportsMethod(providerImpl, "validate_pix_key") {
+			return &providers.ProviderError{Code: "NOT_SUPPORTED", Message: "Consulta de chave não suportada", Retryable: true}
+		}
+
+		credentials, err := h.providerManager.Credentials(merchantProvider)
+		if err != nil {
+			return err
+		}
+		token, err := h.tokenCache.GetToken(c.Context(), providerImpl, merchantProvider, credentials)
+		if err != nil {
+			return err
+		}
+
+		resp, err := providerImpl.ValidatePixKey(c.Context(), &providers.ValidatePixKeyRequest{
+			PixKey:     key.Value,
+			PixKeyType: key.Type,
+			AuthToken:  token.AccessToken,
+			ClientID:   credentials.ClientID,
+		})
+		if err != nil {
+			var providerErr *providers.ProviderError
+			if errors.As(err, &providerErr) && providerErr.StatusCode == fiber.StatusUnauthorized {
+				h.tokenCache.Invalidate(merchantProvider.MerchantID, merchantProvider.ProviderID)
+			}
+			return err
+		}
+		entry = resp
+		return nil
+	})
+
+	var providerErr *providers.ProviderError
+	switch {
+	case errors.Is(lookupErr, providers.ErrProviderNotConfigured):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant not configured for this provider",
+		})
+	case errors.Is(lookupErr, providers.ErrNoHealthyProvider):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "no active providers configured",
+		})
+	case errors.As(lookupErr, &providerErr) && providerErr.Code == providers.ErrCodeNotFound:
+		// Chave inexistente também entra no cache: consultas repetidas contam no limite do DICT
+		entry = nil
+	case errors.As(lookupErr, &providerErr):
+		_ = h.auditService.LogProviderOperation(c.Context(), *merchantID, uuid.Nil, selectedProvider, "validate_pix_key", false, providerErr.Message, 0)
+		return c.Status(providerErrorStatus(providerErr.Code)).JSON(fiber.Map{
+			"error":   "pix key lookup failed",
+			"code":    providerErr.Code,
+			"details": providerErr.Message,
+		})
+	case lookupErr != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "pix key lookup failed",
+		})
+	}
+
+	_ = h.auditService.Log(c.Context(), &audit.LogEntry{
+		MerchantID: merchantID,
+		Action:     "pix_key_lookup",
+		Resource:   "pix_key",
+		Metadata: map[string]interface{}{
+			"provider":     selectedProvider,
+			"pix_key_type": key.Type,
+			"found":        entry != nil,
+		},
+	})
+
+	h.cache.set(*merchantID, key.Value, entry)
+	return pixKeyResult(c, key, entry)
+}
+
+// pixKeyResult responde com o vínculo mascarado ou 404 para chave não encontrada
+func pixKeyResult(c *fiber.Ctx, key pixkey.Key, entry *providers.ValidatePixKeyResponse) error {
+	if entry == nil || !entry.Valid {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "pix key not found",
+		})
+	}
+	return c.JSON(toPixKeyResponse(key, entry))
+}
+
+func toPixKeyResponse(key pixkey.Key, entry *providers.ValidatePixKeyResponse) PixKeyResponse {
+	keyType := entry.PixKeyType
+	if keyType == "" {
+		keyType = key.Type
+	}
+
+	resp := PixKeyResponse{
+		PixKey:      key.Value,
+		PixKeyType:  keyType,
+		HolderName:  entry.Name,
+		Document:    maskDocument(entry.Document),
+		ISPB:        entry.ISPB,
+		AccountType: entry.AccountType,
+	}
+	// Razão social de pessoa jurídica é pública; de pessoa física só exibimos o suficiente para confirmação
+	if !pixkey.ValidCNPJ(entry.Document) {
+		resp.HolderName = maskName(entry.Name)
+	}
+	return resp
+}
+
+// nameParticles são preposições ignoradas ao abreviar o sobrenome
+var nameParticles = map[string]bool{"da": true, "das": true, "de": true, "do": true, "dos": true, "e": true}
+
+// maskName abrevia o nome para primeiro nome e inicial do último sobrenome (ex: João S.)
+func maskName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return ""
+	}
+
+	masked := parts[0]
+	for i := len(parts) - 1; i > 0; i-- {
+		if nameParticles[strings.ToLower(parts[i])] {
+			continue
+		}
+		initial, _ := utf8.DecodeRuneInString(parts[i])
+		masked += " " + strings.ToUpper(string(initial)) + "."
+		break
+	}
+	return masked
+}
+
+// maskDocument mascara o CPF no padrão do BACEN (***.456.789-**); CNPJ é exibido formatado
+func maskDocument(document string) string {
+	digits := strings.NewReplacer(".", "", "-", "", "/", "").Replace(document)
+	switch {
+	case len(digits) == 11:
+		return "***." + digits[3:6] + "." + digits[6:9] + "-**"
+	case len(digits) == 14:
+		return digits[0:2] + "." + digits[2:5] + "." + digits[5:8] + "/" + digits[8:12] + "-" + digits[12:14]
+	case document == "":
+		return ""
+	}
+	return "***"
+}
+
+// pixKeyCache guarda por pouco tempo o resultado das consultas de cada merchant.
+// Entradas com resultado nil representam chave não encontrada.
+type pixKeyCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[pixKeyCacheKey]pixKeyCacheEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type pixKeyCacheKey struct {
+	merchantID uuid.UUID
+	key        string
+}
+
+type pixKeyCacheEntry struct {
+	result    *providers.ValidatePixKeyResponse
+	expiresAt time.Time
+}
+
+func newPixKeyCache(ttl time.Duration) *pixKeyCache {
+	return &pixKeyCache{
+		ttl:     ttl,
+		entries: make(map[pixKeyCacheKey]pixKeyCacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *pixKeyCache) get(merchantID uuid.UUID, key string) (*providers.ValidatePixKeyResponse, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[pixKeyCacheKey{merchantID: merchantID, key: key}]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *pixKeyCache) set(merchantID uuid.UUID, key string, result *providers.ValidatePixKeyResponse) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// Entradas vencidas são removidas no máximo uma vez por TTL para o mapa não crescer indefinidamente
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for cacheKey, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, cacheKey)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[pixKeyCacheKey{merchantID: merchantID, key: key}] = pixKeyCacheEntry{
+		result:    result,
+		expiresAt: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+func TestMaskName(t *testing.T) {
+	tests := map[string]string{
+		"João da Silva":       "João S.",
+		"maria de souza dos":  "maria S.",
+		"Ana":                 "Ana",
+		"  José   Ávila  ":    "José Á.",
+		"":                    "",
+		"Pedro Henrique Dias": "Pedro D.",
+	}
+	for name, want := range tests {
+		if got := maskName(name); got != want {
+			t.Errorf("maskName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMaskDocument(t *testing.T) {
+	tests := map[string]string{
+		"52998224725":        "***.982.247-**",
+		"529.982.247-25":     "***.982.247-**",
+		"11222333000181":     "11.222.333/0001-81",
+		"11.222.333/0001-81": "11.222.333/0001-81",
+		"123":                "***",
+		"":                   "",
+	}
+	for document, want := range tests {
+		if got := maskDocument(document); got != want {
+			t.Errorf("maskDocument(%q) = %q, want %q", document, got, want)
+		}
+	}
+}
+
+func TestToPixKeyResponseKeepsCompanyName(t *testing.T) {
+	key := pixkey.Key{Value: "11222333000181", Type: domain.PixKeyTypeCNPJ}
+	resp := toPixKeyResponse(key, &providers.ValidatePixKeyResponse{Valid: true, Name: "Loja Exemplo Ltda", Document: "11222333000181", ISPB: "00000000"})
+	if resp.HolderName != "Loja Exemplo Ltda" || resp.PixKeyType != domain.PixKeyTypeCNPJ {
+		t.Errorf("toPixKeyResponse() = %+v", resp)
+	}
+}
+
+func TestPixKeyCacheExpires(t *testing.T) {
+	now := time.Now()
+	cache := newPixKeyCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	merchantID := uuid.New()
+	cache.set(merchantID, "joao@example.com", &providers.ValidatePixKeyResponse{Valid: true})
+	cache.set(merchantID, "missing@example.com", nil)
+
+	if entry, found := cache.get(merchantID, "joao@example.com"); !found || entry == nil {
+		t.Errorf("get() = %v, %v, want cached entry", entry, found)
+	}
+	if entry, found := cache.get(merchantID, "missing@example.com"); !found || entry != nil {
+		t.Errorf("get(missing) = %v, %v, want cached not found", entry, found)
+	}
+	if _, found := cache.get(uuid.New(), "joao@example.com"); found {
+		t.Error("entry shared between merchants")
+	}
+
+	now = now.Add(time.Minute)
+	if _, found := cache.get(merchantID, "joao@example.com"); found {
+		t.Error("entry returned after ttl")
+	}
+	cache.set(merchantID, "other@example.com", nil)
+	if len(cache.entries) != 1 {
+		t.Errorf("expired entries kept: %d", len(cache.entries))
+	}
+}
+
+func newPixKeyTestApp(h *PixKeyHandler, merchantID uuid.UUID) *fiber.App {
+	app := fiber.New()
+	app.Get("/pix-keys/:key", func(c *fiber.Ctx) error {
+		c.Locals("merchant_id", &merchantID)
+		return c.Next()
+	}, h.LookupPixKey)
+	return app
+}
+
+func TestLookupPixKeyRejectsInvalidKey(t *testing.T) {
+	app := newPixKeyTestApp(&PixKeyHandler{}, uuid.New())
+
+	for _, path := range []string{"/pix-keys/joao@", "/pix-keys/12345678900?type=cpf", "/pix-keys/0001-123?type=account"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		if resp.StatusCode != fiber.StatusUnprocessableEntity {
+			t.Errorf("GET %s = %d, want 422", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestLookupPixKeyUsesCache(t *testing.T) {
+	merchantID := uuid.New()
+	h := &PixKeyHandler{cache: newPixKeyCache(time.Minute)}
+	h.cache.set(merchantID, "+5511987654321", &providers.ValidatePixKeyResponse{
+		Valid:       true,
+		Name:        "João da Silva",
+		Document:    "52998224725",
+		ISPB:        "00000000",
+		AccountType: "checking",
+	})
+	h.cache.set(merchantID, "maria@example.com", nil)
+	app := newPixKeyTestApp(h, merchantID)
+
+	// Celular com máscara é normalizado para a mesma chave do cache
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/pix-keys/(11)98765-4321", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var got PixKeyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	want := PixKeyResponse{PixKey: "+5511987654321", PixKeyType: domain.PixKeyTypePhone, HolderName: "João S.", Document: "***.982.247-**", ISPB: "00000000", AccountType: "checking"}
+	if got != want {
+		t.Errorf("response = %+v, want %+v", got, want)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/pix-keys/Maria@Example.com", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("cached not found status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pixsaas/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Limites do histórico de health check
+const (
+	defaultHealthHistoryWindow = 24 * time.Hour
+	maxHealthHistoryLimit      = 1000
+)
+
+// ProviderHandler gerencia consultas administrativas de providers
+type ProviderHandler struct {
+	providerRepo *repository.ProviderRepository
+}
+
+// NewProviderHandler cria um novo handler de providers
+func NewProviderHandler(db *gorm.DB) *ProviderHandler {
+	return &ProviderHandler{
+		providerRepo: repository.NewProviderRepository(db),
+	}
+}
+
+// ListProviders lista os providers com o status de saúde atual
+func (h *ProviderHandler) ListProviders(c *fiber.Ctx) error {
+	providers, err := h.providerRepo.List(c.Context(), false)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list providers",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": providers,
+	})
+}
+
+// GetHealthHistory retorna o histórico de verificações de saúde de um provider.
+// Parâmetros: since (RFC3339, padrão últimas 24h) e limit (padrão 100).
+func (h *ProviderHandler) GetHealthHistory(c *fiber.Ctx) error {
+	provider, err := h.providerRepo.GetByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "provider not found",
+		})
+	}
+
+	since := time.Now().Add(-defaultHealthHistoryWindow)
+	if value := c.Query("since"); value != "" {
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid since, expected RFC3339",
+			})
+		}
+	}
+
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 || limit > maxHealthHistoryLimit {
+		limit = maxHealthHistoryLimit
+	}
+
+	checks, err := h.providerRepo.ListHealthChecks(c.Context(), provider.ID, since, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list health checks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"provider":       provider.Code,
+		"health_status":  provider.HealthStatus,
+		"last_health_at": provider.LastHealthAt,
+		"data":           checks,
+	})
+}
@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+// maxRefundDescription é o limite da descrição da devolução na API PIX
+const maxRefundDescription = 140
+
+// CreateRefundRequest representa uma solicitação de devolução de PIX recebido
+type CreateRefundRequest struct {
+	Amount      int64               `json:"amount,omitempty"` // Centavos; 0 devolve todo o saldo restante
+	Reason      domain.RefundReason `json:"reason,omitempty"` // MD06 (padrão), SL02, BE08 ou FR01
+	Description string              `json:"description,omitempty"`
+}
+
+// RefundResponse representa a resposta de uma devolução
+type RefundResponse struct {
+	ID            uuid.UUID           `json:"id"`
+	TransactionID uuid.UUID           `json:"transaction_id"`
+	RefundID      string              `json:"refund_id"`
+	RtrID         string              `json:"rtr_id,omitempty"`
+	Status        domain.RefundStatus `json:"status"`
+	Amount        int64               `json:"amount"`
+	Reason        domain.RefundReason `json:"reason"`
+	MED           bool                `json:"med"`
+	Description   string              `json:"description,omitempty"`
+	ErrorCode     string              `json:"error_code,omitempty"`
+	ErrorMessage  string              `json:"error_message,omitempty"`
+	CompletedAt   string              `json:"completed_at,omitempty"`
+	CreatedAt     string              `json:"created_at"`
+	UpdatedAt     string              `json:"updated_at"`
+}
+
+// CreateRefund solicita a devolução (total ou parcial) de um PIX recebido. As devoluções
+// somadas não podem ultrapassar o valor pago; com todo o valor devolvido, a transação
+// passa para refunded.
+func (h *TransactionHandler) CreateRefund(c *fiber.Ctx) error {
+	tx, err := h.findMerchantTransaction(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateRefundRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := validateRefundRequest(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := refundable(tx); err != nil {
+		return err
+	}
+
+	merchantProvider, err := h.merchantProviderRepo.GetByMerchantAndProvider(c.Context(), tx.MerchantID, tx.ProviderID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant not configured for this provider",
+		})
+	}
+
+	refundUUID := uuid.New()
+	refund := &domain.Refund{
+		ID:            refundUUID,
+		TransactionID: tx.ID,
+		MerchantID:    tx.MerchantID,
+		ProviderID:    tx.ProviderID,
+		RefundID:      refundID(refundUUID),
+		Amount:        req.Amount,
+		Reason:        req.Reason,
+		Description:   req.Description,
+		Status:        domain.RefundStatusPending,
+	}
+
+	// A devolução é reservada antes de ir ao banco: pedidos simultâneos não excedem o valor pago
+	if err := h.refundRepo.Reserve(c.Context(), refund, settledAmount(tx)); err != nil {
+		if errors.Is(err, domain.ErrRefundExceedsAmount) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "refund amount exceeds the amount available for refund",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create refund",
+		})
+	}
+
+	// A devolução só pode ser feita pelo banco que recebeu o PIX, então não há fallback
+	resp, refundErr := h.requestRefund(c, merchantProvider, tx, refund)
+	if refundErr != nil {
+		applyRefundError(refund, refundErr)
+	} else {
+		applyRefundResponse(refund, resp)
+	}
+
+	if err := h.refundRepo.Update(c.Context(), refund); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update refund",
+		})
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "refund_requested", map[string]interface{}{
+		"refund_id": refund.RefundID,
+		"amount":    refund.Amount,
+		"reason":    refund.Reason,
+		"med":       refund.Reason.IsMED(),
+		"status":    refund.Status,
+		"provider":  merchantProvider.Provider.Code,
+	})
+
+	if refundErr != nil {
+		status := providerErrorStatus(refund.ErrorCode)
+		if refund.Status == domain.RefundStatusPending {
+			// Sem confirmação do banco: a devolução é consultada novamente no GET
+			status = fiber.StatusAccepted
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":   "refund failed",
+			"code":    refund.ErrorCode,
+			"details": refund.ErrorMessage,
+			"refund":  toRefundResponse(refund),
+		})
+	}
+
+	h.completeRefund(c, tx, refund)
+
+	return c.Status(fiber.StatusCreated).JSON(toRefundResponse(refund))
+}
+
+// GetRefund consulta uma devolução. Devoluções ainda não liquidadas são atualizadas no banco.
+func (h *TransactionHandler) GetRefund(c *fiber.Ctx) error {
+	tx, err := h.findMerchantTransaction(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(c.Params("refund_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid refund id",
+		})
+	}
+
+	refund, err := h.refundRepo.GetByID(c.Context(), tx.ID, id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "refund not found",
+		})
+	}
+
+	if refund.Status == domain.RefundStatusPending || refund.Status == domain.RefundStatusProcessing {
+		h.refreshRefund(c, tx, refund)
+	}
+
+	return c.JSON(toRefundResponse(refund))
+}
+
+// ListRefunds lista as devoluções de uma transação
+func (h *TransactionHandler) ListRefunds(c *fiber.Ctx) error {
+	tx, err := h.findMerchantTransaction(c)
+	if err != nil {
+		return err
+	}
+
+	refunds, err := h.refundRepo.ListByTransaction(c.Context(), tx.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list refunds",
+		})
+	}
+
+	response := make([]RefundResponse, 0, len(refunds))
+	var refunded int64
+	for i := range refunds {
+		response = append(response, toRefundResponse(&refunds[i]))
+		if refunds[i].Status == domain.RefundStatusCompleted {
+			refunded += refunds[i].Amount
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data":            response,
+		"amount":          settledAmount(tx),
+		"refunded_amount": refunded,
+	})
+}
+
+// requestRefund autentica no provider da transação original e solicita a devolução
+func (h *TransactionHandler) requestRefund(c *fiber.Ctx, merchantProvider *domain.MerchantProvider, tx *domain.Transaction, refund *domain.Refund) (*providers.RefundResponse, error) {
+	providerImpl, err := h.providerManager.Instance(merchantProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	token, credentials, err := h.providerToken(c, providerImpl, merchantProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := providerImpl.RequestRefund(c.Context(), &providers.RefundRequest{
+		E2EID:       tx.E2EID,
+		RefundID:    refund.RefundID,
+		Amount:      refund.Amount,
+		Reason:      refund.Reason,
+		Description: refund.Description,
+		AuthToken:   token.AccessToken,
+		ClientID:    credentials.ClientID,
+	})
+	if err != nil {
+		h.tokenCache.InvalidateRevoked(err, merchantProvider)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// refreshRefund consulta a devolução no banco. Falhas na consulta não impedem a resposta.
+func (h *TransactionHandler) refreshRefund(c *fiber.Ctx, tx *domain.Transaction, refund *domain.Refund) {
+	merchantProvider, err := h.merchantProviderRepo.GetByMerchantAndProvider(c.Context(), tx.MerchantID, tx.ProviderID)
+	if err != nil {
+		return
+	}
+
+	providerImpl, err := h.providerManager.Instance(merchantProvider)
+	if err != nil {
+		return
+	}
+
+	token, credentials, err := h.providerToken(c, providerImpl, merchantProvider)
+	if err != nil {
+		log.Printf("Aviso: falha ao autenticar consulta da devolução %s: %v", refund.ID, err)
+		return
+	}
+
+	resp, err := providerImpl.GetRefund(c.Context(), &providers.GetRefundRequest{
+		E2EID:     tx.E2EID,
+		RefundID:  refund.RefundID,
+		AuthToken: token.AccessToken,
+		ClientID:  credentials.ClientID,
+	})
+	if err != nil {
+		h.tokenCache.InvalidateRevoked(err, merchantProvider)
+		var providerErr *providers.ProviderError
+		if refund.Status == domain.RefundStatusPending && errors.As(err, &providerErr) && providerErr.Code == providers.ErrCodeNotFound {
+			// O banco nunca recebeu a devolução: libera o valor reservado
+			applyRefundError(refund, err)
+			if err := h.refundRepo.Update(c.Context(), refund); err != nil {
+				log.Printf("Aviso: falha ao atualizar devolução %s: %v", refund.ID, err)
+			}
+			return
+		}
+		log.Printf("Aviso: falha ao consultar devolução %s no provider: %v", refund.ID, err)
+		return
+	}
+
+	previous := refund.Status
+	applyRefundResponse(refund, resp)
+	if refund.Status == previous {
+		return
+	}
+
+	if err := h.refundRepo.Update(c.Context(), refund); err != nil {
+		log.Printf("Aviso: falha ao atualizar devolução %s: %v", refund.ID, err)
+		return
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "refund_status", map[string]interface{}{
+		"refund_id": refund.RefundID,
+		"from":      previous,
+		"to":        refund.Status,
+		"source":    "refund_query",
+	})
+
+	h.completeRefund(c, tx, refund)
+}
+
+// completeRefund marca a transação como devolvida quando as devoluções liquidadas somam o valor pago
+func (h *TransactionHandler) completeRefund(c *fiber.Ctx, tx *domain.Transaction, refund *domain.Refund) {
+	if refund.Status != domain.RefundStatusCompleted || tx.Status != domain.TransactionStatusCompleted {
+		return
+	}
+
+	refunded, err := h.refundRepo.CompletedAmount(c.Context(), tx.ID)
+	if err != nil || refunded < settledAmount(tx) {
+		return
+	}
+
+	previous := tx.Status
+	tx.Status = domain.TransactionStatusRefunded
+	tx.UpdatedAt = time.Now()
+	updated, err := h.txRepo.UpdateIfStatus(c.Context(), tx, previous)
+	if err != nil || !updated {
+		return
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "status_transition", map[string]interface{}{
+		"from":      previous,
+		"to":        tx.Status,
+		"provider":  tx.Provider.Code,
+		"source":    "refund",
+		"refund_id": refund.RefundID,
+	})
+
+	h.notifyStatus(c, tx, nil)
+}
+
+// findMerchantTransaction busca a transação do path e verifica se pertence ao merchant
+func (h *TransactionHandler) findMerchantTransaction(c *fiber.Ctx) (*domain.Transaction, error) {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "merchant not found in context")
+	}
+
+	txID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid transaction id")
+	}
+
+	tx, err := h.txRepo.GetByID(c.Context(), txID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "transaction not found")
+	}
+
+	// Verificar se pertence ao merchant
+	if tx.MerchantID != *merchantID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "access denied")
+	}
+
+	return tx, nil
+}
+
+// refundable verifica se a transação é um PIX recebido e liquidado, que pode ser devolvido
+func refundable(tx *domain.Transaction) error {
+	if !isChargeType(tx.Type) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "only received payments can be refunded")
+	}
+	if tx.Status != domain.TransactionStatusCompleted {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "only completed payments can be refunded")
+	}
+	if tx.E2EID == "" || settledAmount(tx) <= 0 {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "payment has no e2e_id or amount to refund")
+	}
+	return nil
+}
+
+// settledAmount retorna o valor recebido na cobrança. Sem o valor do callback, só a cobrança
+// de valor fixo tem o valor pago conhecido; a com vencimento depende da data do pagamento.
+func settledAmount(tx *domain.Transaction) int64 {
+	if tx.PaidAmount != nil {
+		return *tx.PaidAmount
+	}
+	if tx.DueDate != nil {
+		return 0
+	}
+	return tx.Amount
+}
+
+// validateRefundRequest valida a solicitação e aplica o motivo padrão
+func validateRefundRequest(req *CreateRefundRequest) error {
+	if req.Amount < 0 {
+		return errors.New("amount must not be negative")
+	}
+
+	req.Reason = domain.RefundReason(strings.ToUpper(string(req.Reason)))
+	if req.Reason == "" {
+		req.Reason = domain.RefundReasonRequested
+	}
+	if !req.Reason.Valid() {
+		return errors.New("reason must be one of MD06, SL02, BE08 or FR01")
+	}
+
+	if len([]rune(req.Description)) > maxRefundDescription {
+		return errors.New("description must have at most 140 characters")
+	}
+	return nil
+}
+
+// refundID deriva o ID da devolução enviado ao banco (até 35 caracteres alfanuméricos)
+func refundID(id uuid.UUID) string {
+	return "D" + strings.ReplaceAll(id.String(), "-", "")
+}
+
+// applyRefundError registra a falha. Erros temporários mantêm a devolução pendente, pois o
+// banco pode tê-la recebido; as demais falhas liberam o valor reservado.
+func applyRefundError(refund *domain.Refund, err error) {
+	refund.Status = domain.RefundStatusFailed
+	if isRetryableError(err) {
+		refund.Status = domain.RefundStatusPending
+	}
+
+	var providerErr *providers.ProviderError
+	if errors.As(err, &providerErr) {
+		refund.ErrorCode = providerErr.Code
+		refund.ErrorMessage = providerErr.Message
+	} else {
+		refund.ErrorMessage = err.Error()
+	}
+}
+
+// applyRefundResponse atualiza a devolução com o retorno do banco
+func applyRefundResponse(refund *domain.Refund, resp *providers.RefundResponse) {
+	refund.Status = resp.Status
+	refund.RtrID = resp.RtrID
+	refund.ErrorCode = ""
+	refund.ErrorMessage = resp.ErrorMessage
+
+	if resp.Status == domain.RefundStatusCompleted {
+		completedAt := time.Now()
+		if resp.CompletedAt != nil {
+			completedAt = *resp.CompletedAt
+		}
+		refund.CompletedAt = &completedAt
+	}
+}
+
+// toRefundResponse converte uma devolução para a resposta da API
+func toRefundResponse(refund *domain.Refund) RefundResponse {
+	resp := RefundResponse{
+		ID:            refund.ID,
+		TransactionID: refund.TransactionID,
+		RefundID:      refund.RefundID,
+		RtrID:         refund.RtrID,
+		Status:        refund.Status,
+		Amount:        refund.Amount,
+		Reason:        refund.Reason,
+		MED:           refund.Reason.IsMED(),
+		Description:   refund.Description,
+		ErrorCode:     refund.ErrorCode,
+		ErrorMessage:  refund.ErrorMessage,
+		CreatedAt:     refund.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:     refund.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if refund.CompletedAt != nil {
+		resp.CompletedAt = refund.CompletedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+func TestValidateRefundRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        CreateRefundRequest
+		wantErr    bool
+		wantReason domain.RefundReason
+	}{
+		{"default reason", CreateRefundRequest{Amount: 100}, false, domain.RefundReasonRequested},
+		{"full refund", CreateRefundRequest{}, false, domain.RefundReasonRequested},
+		{"fraud lowercase", CreateRefundRequest{Reason: "fr01"}, false, domain.RefundReasonFraud},
+		{"unknown reason", CreateRefundRequest{Reason: "AM09"}, true, ""},
+		{"negative amount", CreateRefundRequest{Amount: -1}, true, ""},
+		{"long description", CreateRefundRequest{Description: strings.Repeat("a", 141)}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := validateRefundRequest(&req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRefundRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && req.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", req.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRefundable(t *testing.T) {
+	paid := domain.Transaction{Type: domain.TransactionTypeQRCodeDynamic, Status: domain.TransactionStatusCompleted, E2EID: "E1", Amount: 1000}
+	if err := refundable(&paid); err != nil {
+		t.Errorf("refundable() error = %v", err)
+	}
+
+	tests := map[string]func(tx *domain.Transaction){
+		"outgoing transfer": func(tx *domain.Transaction) { tx.Type = domain.TransactionTypeTransfer },
+		"pending charge":    func(tx *domain.Transaction) { tx.Status = domain.TransactionStatusPending },
+		"already refunded":  func(tx *domain.Transaction) { tx.Status = domain.TransactionStatusRefunded },
+		"without e2e_id":    func(tx *domain.Transaction) { tx.E2EID = "" },
+		"due date without paid amount": func(tx *domain.Transaction) {
+			tx.Type = domain.TransactionTypeQRCodeDueDate
+			tx.DueDate = &domain.DueDateTerms{DueDate: "2024-03-10"}
+		},
+	}
+	for name, modify := range tests {
+		tx := paid
+		modify(&tx)
+		var fiberErr *fiber.Error
+		if err := refundable(&tx); !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusUnprocessableEntity {
+			t.Errorf("%s: refundable() error = %v, want 422", name, err)
+		}
+	}
+}
+
+func TestSettledAmount(t *testing.T) {
+	paidAmount := int64(900)
+	tests := map[string]struct {
+		tx   domain.Transaction
+		want int64
+	}{
+		"paid amount":           {domain.Transaction{Amount: 1000, PaidAmount: &paidAmount}, 900},
+		"fixed amount":          {domain.Transaction{Amount: 1000}, 1000},
+		"due date without paid": {domain.Transaction{Amount: 1000, DueDate: &domain.DueDateTerms{DueDate: "2024-03-10"}}, 0},
+		"open amount with paid": {domain.Transaction{PaidAmount: &paidAmount}, 900},
+	}
+	for name, tt := range tests {
+		if got := settledAmount(&tt.tx); got != tt.want {
+			t.Errorf("%s: settledAmount() = %d, want %d", name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRefundError(t *testing.T) {
+	refund := &domain.Refund{Status: domain.RefundStatusPending}
+	applyRefundError(refund, &providers.ProviderError{Code: providers.ErrCodeBankUnavailable, Message: "timeout", Retryable: true})
+	if refund.Status != domain.RefundStatusPending || refund.ErrorCode != providers.ErrCodeBankUnavailable {
+		t.Errorf("retryable error: refund = %+v, want pending", refund)
+	}
+
+	applyRefundError(refund, &providers.ProviderError{Code: providers.ErrCodeInvalidRequest, Message: "valor inválido"})
+	if refund.Status != domain.RefundStatusFailed {
+		t.Errorf("permanent error: Status = %s, want failed", refund.Status)
+	}
+}
+
+func TestApplyRefundResponse(t *testing.T) {
+	settledAt := time.Date(2024, 1, 10, 10, 0, 2, 0, time.UTC)
+	refund := &domain.Refund{Status: domain.RefundStatusPending, ErrorCode: "BANK_UNAVAILABLE"}
+
+	applyRefundResponse(refund, &providers.RefundResponse{RtrID: "D1", Status: domain.RefundStatusCompleted, CompletedAt: &settledAt})
+	if refund.Status != domain.RefundStatusCompleted || refund.RtrID != "D1" || refund.ErrorCode != "" {
+		t.Errorf("refund = %+v", refund)
+	}
+	if refund.CompletedAt == nil || !refund.CompletedAt.Equal(settledAt) {
+		t.Errorf("CompletedAt = %v, want %v", refund.CompletedAt, settledAt)
+	}
+}
+
+func TestRefundID(t *testing.T) {
+	id := refundID(uuid.New())
+	if len(id) > 35 || strings.ContainsAny(id, "-") {
+		t.Errorf("refundID() = %q, want up to 35 alphanumeric characters", id)
+	}
+}
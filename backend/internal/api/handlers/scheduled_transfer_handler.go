@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/audit"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
+	"github.com/pixsaas/backend/internal/repository"
+	"github.com/pixsaas/backend/internal/validation"
+	"gorm.io/gorm"
+)
+
+// ScheduledTransferHandler gerencia as transferências agendadas e recorrentes
+type ScheduledTransferHandler struct {
+	scheduleRepo *repository.ScheduledTransferRepository
+	auditService *audit.AuditService
+	now          func() time.Time
+}
+
+// NewScheduledTransferHandler cria um novo handler de transferências agendadas
+func NewScheduledTransferHandler(db *gorm.DB, auditService *audit.AuditService) *ScheduledTransferHandler {
+	return &ScheduledTransferHandler{
+		scheduleRepo: repository.NewScheduledTransferRepository(db),
+		auditService: auditService,
+		now:          time.Now,
+	}
+}
+
+// CreateScheduledTransferRequest representa uma requisição de transferência agendada
+type CreateScheduledTransferRequest struct {
+	ExternalID   string `json:"external_id" validate:"required,max=200"`
+	Amount       int64  `json:"amount" validate:"required,min=1"` // Centavos por execução
+	Description  string `json:"description" validate:"max=140"`
+	ProviderCode string `json:"provider_code,omitempty"`
+
+	// Recebedor
+	PayeeName       string            `json:"payee_name" validate:"required,max=140"`
+	PayeeDocument   string            `json:"payee_document" validate:"required,document"`
+	PayeePixKey     string            `json:"payee_pix_key,omitempty" validate:"required_without=PayeeAccount,pixkey=PayeePixKeyType"`
+	PayeePixKeyType domain.PixKeyType `json:"payee_pix_key_type,omitempty" validate:"oneof=cpf cnpj email phone random account"`
+	PayeeAccount    *AccountInfo      `json:"payee_account,omitempty"`
+
+	// Agenda: primeira execução e, para recorrências, o último dia (YYYY-MM-DD, horário de Brasília)
+	Frequency domain.ScheduleFrequency `json:"frequency" validate:"required,oneof=once daily weekly monthly"`
+	StartAt   time.Time                `json:"start_at" validate:"required"`
+	EndDate   string                   `json:"end_date,omitempty"`
+
+	// Metadata opcional, repassada a cada transação
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ScheduledTransferResponse representa a resposta de uma transferência agendada
+type ScheduledTransferResponse struct {
+	ID                uuid.UUID                      `json:"id"`
+	ExternalID        string                         `json:"external_id"`
+	Status            domain.ScheduledTransferStatus `json:"status"`
+	Amount            int64                          `json:"amount"`
+	Description       string                         `json:"description"`
+	ProviderCode      string                         `json:"provider_code,omitempty"`
+	PayeeName         string                         `json:"payee_name"`
+	PayeePixKey       string                         `json:"payee_pix_key,omitempty"`
+	Frequency         domain.ScheduleFrequency       `json:"frequency"`
+	StartAt           string                         `json:"start_at"`
+	EndDate           string                         `json:"end_date,omitempty"`
+	NextRunAt         string                         `json:"next_run_at,omitempty"`
+	Occurrences       int                            `json:"occurrences"`
+	LastTransactionID *uuid.UUID                     `json:"last_transaction_id,omitempty"`
+	LastRunAt         string                         `json:"last_run_at,omitempty"`
+	CancelledAt       string                         `json:"cancelled_at,omitempty"`
+	CreatedAt         string                         `json:"created_at"`
+	UpdatedAt         string                         `json:"updated_at"`
+}
+
+// CreateScheduledTransfer agenda uma transferência única ou recorrente
+func (h *ScheduledTransferHandler) CreateScheduledTransfer(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	var req CreateScheduledTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return validationFailed(c, err)
+	}
+
+	schedule, err := newScheduledTransfer(*merchantID, &req, h.now())
+	if err != nil {
+		return err
+	}
+
+	if err := h.scheduleRepo.Create(c.Context(), schedule); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "external_id already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create scheduled transfer",
+		})
+	}
+
+	h.logAction(c, schedule, "scheduled_transfer_created", map[string]interface{}{
+		"external_id": schedule.ExternalID,
+		"amount":      schedule.Amount,
+		"frequency":   schedule.Frequency,
+		"start_at":    schedule.StartAt,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(toScheduledTransferResponse(schedule))
+}
+
+// ListScheduledTransfers lista as transferências agendadas do merchant
+func (h *ScheduledTransferHandler) ListScheduledTransfers(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	// Parâmetros de paginação
+	limit := c.QueryInt("limit", 50)
+	offset := c.QueryInt("offset", 0)
+	status := domain.ScheduledTransferStatus(c.Query("status"))
+
+	schedules, total, err := h.scheduleRepo.ListByMerchant(c.Context(), *merchantID, status, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list scheduled transfers",
+		})
+	}
+
+	response := make([]ScheduledTransferResponse, 0, len(schedules))
+	for i := range schedules {
+		response = append(response, toScheduledTransferResponse(&schedules[i]))
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   response,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetScheduledTransfer busca uma transferência agendada por ID
+func (h *ScheduledTransferHandler) GetScheduledTransfer(c *fiber.Ctx) error {
+	schedule, err := h.findMerchantSchedule(c)
+	if err != nil {
+		return err
+	}
+	return c.JSON(toScheduledTransferResponse(schedule))
+}
+
+// CancelScheduledTransfer cancela as próximas execuções. Transferências já iniciadas seguem
+// o fluxo normal e podem ser canceladas individualmente em /transactions/:id/cancel.
+func (h *ScheduledTransferHandler) CancelScheduledTransfer(c *fiber.Ctx) error {
+	schedule, err := h.findMerchantSchedule(c)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Status != domain.ScheduledTransferActive {
+		return fiber.NewError(fiber.StatusConflict, "only active scheduled transfers can be cancelled")
+	}
+
+	cancelled, err := h.scheduleRepo.Cancel(c.Context(), schedule, h.now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to cancel scheduled transfer",
+		})
+	}
+	if !cancelled {
+		return fiber.NewError(fiber.StatusConflict, "scheduled transfer is no longer active")
+	}
+
+	h.logAction(c, schedule, "scheduled_transfer_cancelled", map[string]interface{}{
+		"occurrences": schedule.Occurrences,
+	})
+
+	return c.JSON(toScheduledTransferResponse(schedule))
+}
+
+// findMerchantSchedule busca o agendamento do path garantindo que pertence ao merchant
+func (h *ScheduledTransferHandler) findMerchantSchedule(c *fiber.Ctx) (*domain.ScheduledTransfer, error) {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "merchant not found in context")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid scheduled transfer id")
+	}
+
+	schedule, err := h.scheduleRepo.GetByID(c.Context(), *merchantID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "scheduled transfer not found")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to load scheduled transfer")
+	}
+	return schedule, nil
+}
+
+// logAction registra uma ação de gerenciamento de agendamento na auditoria
+func (h *ScheduledTransferHandler) logAction(c *fiber.Ctx, schedule *domain.ScheduledTransfer, action string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["scheduled_transfer_id"] = schedule.ID
+
+	entry := &audit.LogEntry{
+		MerchantID: &schedule.MerchantID,
+		Action:     action,
+		Resource:   "scheduled_transfer",
+		Method:     c.Method(),
+		Path:       c.Path(),
+		IPAddress:  c.IP(),
+		Metadata:   metadata,
+	}
+	if userID, ok := c.Locals("user_id").(uuid.UUID); ok {
+		entry.UserID = &userID
+	}
+
+	_ = h.auditService.Log(c.Context(), entry)
+}
+
+// newScheduledTransfer monta o agendamento a partir de uma requisição já validada
+func newScheduledTransfer(merchantID uuid.UUID, req *CreateScheduledTransferRequest, now time.Time) (*domain.ScheduledTransfer, error) {
+	schedule := &domain.ScheduledTransfer{
+		ID:            uuid.New(),
+		MerchantID:    merchantID,
+		ExternalID:    req.ExternalID,
+		ProviderCode:  req.ProviderCode,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		PayeeName:     req.PayeeName,
+		PayeeDocument: req.PayeeDocument,
+		Metadata:      req.Metadata,
+		Frequency:     req.Frequency,
+		StartAt:       req.StartAt,
+	}
+
+	// Chave do recebedor no formato do DICT; o tipo é detectado quando omitido
+	if req.PayeePixKey != "" {
+		key, err := pixkey.Normalize(req.PayeePixKeyType, req.PayeePixKey)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid payee_pix_key: "+err.Error())
+		}
+		schedule.PayeePixKey = key.Value
+		schedule.PayeePixKeyType = key.Type
+	}
+
+	if req.PayeeAccount != nil {
+		schedule.PayeeBank = req.PayeeAccount.Bank
+		schedule.PayeeISPB = req.PayeeAccount.ISPB
+		schedule.PayeeAccountAgency = req.PayeeAccount.Agency
+		schedule.PayeeAccountNumber = req.PayeeAccount.Number
+		schedule.PayeeAccountType = req.PayeeAccount.Type
+	}
+
+	if req.EndDate != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, domain.ScheduleLocation)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "end_date must be in YYYY-MM-DD format")
+		}
+		schedule.EndDate = &endDate
+	}
+
+	if err := schedule.Schedule(now); err != nil {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	}
+	return schedule, nil
+}
+
+// toScheduledTransferResponse converte o agendamento na resposta da API
+func toScheduledTransferResponse(schedule *domain.ScheduledTransfer) ScheduledTransferResponse {
+	const layout = "2006-01-02T15:04:05Z07:00"
+
+	resp := ScheduledTransferResponse{
+		ID:                schedule.ID,
+		ExternalID:        schedule.ExternalID,
+		Status:            schedule.Status,
+		Amount:            schedule.Amount,
+		Description:       schedule.Description,
+		ProviderCode:      schedule.ProviderCode,
+		PayeeName:         schedule.PayeeName,
+		PayeePixKey:       schedule.PayeePixKey,
+		Frequency:         schedule.Frequency,
+		StartAt:           schedule.StartAt.Format(layout),
+		Occurrences:       schedule.Occurrences,
+		LastTransactionID: schedule.LastTransactionID,
+		CreatedAt:         schedule.CreatedAt.Format(layout),
+		UpdatedAt:         schedule.UpdatedAt.Format(layout),
+	}
+	if schedule.EndDate != nil {
+		resp.EndDate = schedule.EndDate.In(domain.ScheduleLocation).Format("2006-01-02")
+	}
+	if schedule.NextRunAt != nil {
+		resp.NextRunAt = schedule.NextRunAt.Format(layout)
+	}
+	if schedule.LastRunAt != nil {
+		resp.LastRunAt = schedule.LastRunAt.Format(layout)
+	}
+	if schedule.CancelledAt != nil {
+		resp.CancelledAt = schedule.CancelledAt.Format(layout)
+	}
+	return resp
+}
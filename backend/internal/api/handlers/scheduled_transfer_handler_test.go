@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/validation"
+)
+
+func TestNewScheduledTransfer(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, domain.ScheduleLocation)
+	merchantID := uuid.New()
+	req := CreateScheduledTransferRequest{
+		ExternalID:    "aluguel",
+		Amount:        250000,
+		PayeeName:     "Imobiliária Exemplo",
+		PayeeDocument: "11222333000181",
+		PayeePixKey:   "+55 (11) 98765-4321",
+		Frequency:     domain.ScheduleMonthly,
+		StartAt:       time.Date(2026, 1, 31, 9, 0, 0, 0, domain.ScheduleLocation),
+		EndDate:       "2026-12-31",
+	}
+
+	schedule, err := newScheduledTransfer(merchantID, &req, now)
+	if err != nil {
+		t.Fatalf("newScheduledTransfer() error = %v", err)
+	}
+	if schedule.MerchantID != merchantID || schedule.Status != domain.ScheduledTransferActive || !schedule.NextRunAt.Equal(req.StartAt) {
+		t.Errorf("schedule = %+v", schedule)
+	}
+	if schedule.PayeePixKey != "+5511987654321" || schedule.PayeePixKeyType != domain.PixKeyTypePhone {
+		t.Errorf("payee key = %q (%s), want normalized phone", schedule.PayeePixKey, schedule.PayeePixKeyType)
+	}
+	wantEnd := time.Date(2026, 12, 31, 0, 0, 0, 0, domain.ScheduleLocation)
+	if schedule.EndDate == nil || !schedule.EndDate.Equal(wantEnd) {
+		t.Errorf("EndDate = %v, want %v", schedule.EndDate, wantEnd)
+	}
+
+	resp := toScheduledTransferResponse(schedule)
+	if resp.EndDate != "2026-12-31" || resp.NextRunAt != "2026-01-31T09:00:00-03:00" {
+		t.Errorf("response end_date = %q, next_run_at = %q", resp.EndDate, resp.NextRunAt)
+	}
+
+	account := req
+	account.PayeePixKey = ""
+	account.PayeeAccount = &AccountInfo{Bank: "Banco Exemplo", ISPB: "12345678", Agency: "0001", Number: "12345-6", Type: "checking"}
+	schedule, err = newScheduledTransfer(merchantID, &account, now)
+	if err != nil {
+		t.Fatalf("newScheduledTransfer() with account error = %v", err)
+	}
+	if schedule.PayeeISPB != "12345678" || schedule.PayeeAccountNumber != "12345-6" || schedule.PayeeAccountType != "checking" {
+		t.Errorf("payee account = %+v", schedule)
+	}
+}
+
+func TestNewScheduledTransferRejects(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, domain.ScheduleLocation)
+	valid := CreateScheduledTransferRequest{
+		ExternalID:    "aluguel",
+		Amount:        250000,
+		PayeeName:     "Fulano",
+		PayeeDocument: "52998224725",
+		PayeePixKey:   "fulano@example.com",
+		Frequency:     domain.ScheduleDaily,
+		StartAt:       now.Add(time.Hour),
+	}
+
+	tests := map[string]func(r *CreateScheduledTransferRequest){
+		"past start":       func(r *CreateScheduledTransferRequest) { r.StartAt = now.Add(-time.Minute) },
+		"end date format":  func(r *CreateScheduledTransferRequest) { r.EndDate = "31/12/2026" },
+		"end before start": func(r *CreateScheduledTransferRequest) { r.EndDate = "2026-01-09" },
+		"end date on once": func(r *CreateScheduledTransferRequest) { r.Frequency = domain.ScheduleOnce; r.EndDate = "2026-12-31" },
+		"invalid pix key": func(r *CreateScheduledTransferRequest) {
+			r.PayeePixKeyType = domain.PixKeyTypeCPF
+			r.PayeePixKey = "123"
+		},
+	}
+	for name, modify := range tests {
+		req := valid
+		modify(&req)
+		var fiberErr *fiber.Error
+		if _, err := newScheduledTransfer(uuid.New(), &req, now); !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusUnprocessableEntity {
+			t.Errorf("%s: newScheduledTransfer() error = %v, want 422", name, err)
+		}
+	}
+}
+
+func TestCreateScheduledTransferValidation(t *testing.T) {
+	merchantID := uuid.New()
+	app := fiber.New()
+	app.Post("/scheduled-transfers", func(c *fiber.Ctx) error {
+		c.Locals("merchant_id", &merchantID)
+		return c.Next()
+	}, (&ScheduledTransferHandler{}).CreateScheduledTransfer)
+
+	body := `{"external_id":"s1","amount":1000,"payee_name":"Fulano","payee_document":"52998224725","payee_pix_key":"fulano@example.com","frequency":"yearly"}`
+	req := httptest.NewRequest(fiber.MethodPost, "/scheduled-transfers", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", resp.StatusCode)
+	}
+
+	var got struct {
+		Fields []validation.FieldError `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	fields := map[string]bool{}
+	for _, fieldErr := range got.Fields {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"frequency", "start_at"} {
+		if !fields[field] {
+			t.Errorf("%s missing from %+v", field, got.Fields)
+		}
+	}
+}
@@ -1,13 +1,24 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/pixsaas/backend/internal/audit"
+	"github.com/pixsaas/backend/internal/brcode"
 	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
 	"github.com/pixsaas/backend/internal/providers"
+	"github.com/pixsaas/backend/internal/qrimage"
 	"github.com/pixsaas/backend/internal/repository"
 	"github.com/pixsaas/backend/internal/security"
+	"github.com/pixsaas/backend/internal/validation"
+	"github.com/pixsaas/backend/internal/webhook"
 	"gorm.io/gorm"
 )
 
@@ -18,17 +29,34 @@ type TransactionHandler struct {
 	merchantRepo         *repository.MerchantRepository
 	providerRepo         *repository.ProviderRepository
 	merchantProviderRepo *repository.MerchantProviderRepository
+	refundRepo           *repository.RefundRepository
 	auditService         *audit.AuditService
 	encryptionService    *security.EncryptionService
-	providerRegistry     *providers.ProviderRegistry
+	providerManager      *providers.ProviderManager
+	tokenCache           *providers.TokenCache
+	webhooks             *webhook.Dispatcher
+	qrOptions            qrimage.Options
+	brcodeFetcher        *brcode.PayloadFetcher
 }
 
+// errTransactionPersistence indica falha ao persistir a transação durante uma tentativa
+var errTransactionPersistence = errors.New("failed to persist transaction")
+
+// errTransferCancelled indica que a transação foi cancelada enquanto era enviada ao banco
+var errTransferCancelled = errors.New("transaction cancelled during submission")
+
+// errDuplicateExternalID indica que outra requisição criou a transação com o mesmo external_id
+var errDuplicateExternalID = errors.New("external_id already exists")
+
 // NewTransactionHandler cria um novo handler de transações
 func NewTransactionHandler(
 	db *gorm.DB,
 	auditService *audit.AuditService,
 	encryptionService *security.EncryptionService,
-	providerRegistry *providers.ProviderRegistry,
+	providerManager *providers.ProviderManager,
+	tokenCache *providers.TokenCache,
+	webhooks *webhook.Dispatcher,
+	qrOptions qrimage.Options,
 ) *TransactionHandler {
 	return &TransactionHandler{
 		db:                   db,
@@ -36,24 +64,32 @@ func NewTransactionHandler(
 		merchantRepo:         repository.NewMerchantRepository(db),
 		providerRepo:         repository.NewProviderRepository(db),
 		merchantProviderRepo: repository.NewMerchantProviderRepository(db),
+		refundRepo:           repository.NewRefundRepository(db),
 		auditService:         auditService,
 		encryptionService:    encryptionService,
-		providerRegistry:     providerRegistry,
+		providerManager:      providerManager,
+		tokenCache:           tokenCache,
+		webhooks:             webhooks,
+		qrOptions:            qrOptions,
+		brcodeFetcher:        brcode.NewPayloadFetcher(nil),
 	}
 }
 
 // CreateTransferRequest representa uma requisição de transferência
 type CreateTransferRequest struct {
-	ExternalID   string `json:"external_id" validate:"required"`
-	Amount       int64  `json:"amount" validate:"required,min=1"`
-	Description  string `json:"description"`
+	ExternalID   string `json:"external_id" validate:"required,max=255"`
+	Amount       int64  `json:"amount" validate:"required_without=BRCode,min=1"`
+	Description  string `json:"description" validate:"max=140"`
 	ProviderCode string `json:"provider_code,omitempty"`
 
-	// Recebedor (obrigatório)
-	PayeeName       string            `json:"payee_name" validate:"required"`
-	PayeeDocument   string            `json:"payee_document" validate:"required"`
-	PayeePixKey     string            `json:"payee_pix_key,omitempty"`
-	PayeePixKeyType domain.PixKeyType `json:"payee_pix_key_type,omitempty"`
+	// Pix copia e cola: valor, txid e recebedor vêm do código
+	BRCode string `json:"br_code,omitempty"`
+
+	// Recebedor (obrigatório sem br_code)
+	PayeeName       string            `json:"payee_name" validate:"required_without=BRCode,max=140"`
+	PayeeDocument   string            `json:"payee_document" validate:"required_without=BRCode,document"`
+	PayeePixKey     string            `json:"payee_pix_key,omitempty" validate:"required_without_all=BRCode PayeeAccount,pixkey=PayeePixKeyType"`
+	PayeePixKeyType domain.PixKeyType `json:"payee_pix_key_type,omitempty" validate:"oneof=cpf cnpj email phone random account"`
 	PayeeAccount    *AccountInfo      `json:"payee_account,omitempty"`
 
 	// Metadata opcional
@@ -63,10 +99,10 @@ type CreateTransferRequest struct {
 // AccountInfo representa informações de conta bancária
 type AccountInfo struct {
 	Bank   string `json:"bank"`
-	ISPB   string `json:"ispb"`
-	Agency string `json:"agency"`
-	Number string `json:"number"`
-	Type   string `json:"type"` // checking, savings
+	ISPB   string `json:"ispb" validate:"required,ispb"`
+	Agency string `json:"agency" validate:"required,max=10"`
+	Number string `json:"number" validate:"required,max=20"`
+	Type   string `json:"type" validate:"oneof=checking savings"` // checking, savings
 }
 
 // TransactionResponse representa a resposta de uma transação
@@ -98,6 +134,23 @@ func (h *TransactionHandler) CreateTransfer(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := validation.Struct(&req); err != nil {
+		return validationFailed(c, err)
+	}
+	if err := reservedExternalID(req.ExternalID); err != nil {
+		return err
+	}
+
+	// Chave do recebedor no formato do DICT; o tipo é detectado quando omitido
+	if req.PayeePixKey != "" && req.BRCode == "" {
+		key, err := pixkey.Normalize(req.PayeePixKeyType, req.PayeePixKey)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid payee_pix_key: "+err.Error())
+		}
+		req.PayeePixKey = key.Value
+		req.PayeePixKeyType = key.Type
+	}
+
 	// Verificar se external_id já existe
 	existing, _ := h.txRepo.GetByExternalID(c.Context(), *merchantID, req.ExternalID)
 	if existing != nil {
@@ -106,125 +159,24 @@ func (h *TransactionHandler) CreateTransfer(c *fiber.Ctx) error {
 		})
 	}
 
-	// Selecionar provider
-	var selectedProvider *domain.Provider
-	var merchantProvider *domain.MerchantProvider
-
-	if req.ProviderCode != "" {
-		// Provider específico solicitado
-		provider, err := h.providerRepo.GetByCode(c.Context(), req.ProviderCode)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "provider not found",
-			})
-		}
-		selectedProvider = provider
-
-		// Buscar configuração do merchant para este provider
-		mp, err := h.merchantProviderRepo.GetByMerchantAndProvider(c.Context(), *merchantID, provider.ID)
+	// Pagamento de BR Code: o código é validado antes de qualquer chamada ao provider
+	txType := domain.TransactionTypeTransfer
+	var qrCodeTxID string
+	if req.BRCode != "" {
+		txid, err := h.resolveBRCode(c.Context(), &req)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "merchant not configured for this provider",
-			})
+			return err
 		}
-		merchantProvider = mp
-	} else {
-		// Selecionar provider automaticamente (primeiro ativo)
-		mps, err := h.merchantProviderRepo.ListByMerchant(c.Context(), *merchantID, true)
-		if err != nil || len(mps) == 0 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "no active providers configured",
-			})
-		}
-		merchantProvider = &mps[0]
-		selectedProvider = &merchantProvider.Provider
-	}
-
-	// Obter implementação do provider
-	providerImpl, exists := h.providerRegistry.Get(selectedProvider.Code)
-	if !exists {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "provider implementation not found",
-		})
-	}
-
-	// Inicializar provider com configuração convertida
-	providerConfig := providers.ProviderConfig{
-		BaseURL:      selectedProvider.Config.BaseURL,
-		AuthURL:      selectedProvider.Config.AuthURL,
-		SandboxURL:   selectedProvider.Config.SandboxURL,
-		Timeout:      selectedProvider.Config.Timeout,
-		MaxRetries:   selectedProvider.Config.MaxRetries,
-		RequiresMTLS: selectedProvider.Config.RequiresMTLS,
-	}
-
-	if err := providerImpl.Initialize(providerConfig); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to initialize provider",
-		})
+		txType = domain.TransactionTypePixCopyPaste
+		qrCodeTxID = txid
 	}
 
-	// Descriptografar credenciais
-	clientID, _ := h.encryptionService.Decrypt(merchantProvider.ClientID)
-	clientSecret, _ := h.encryptionService.Decrypt(merchantProvider.ClientSecret)
-
-	// Autenticar com provider
-	credentials := providers.ProviderCredentials{
-		ClientID:      clientID,
-		ClientSecret:  clientSecret,
-		AccountAgency: merchantProvider.AccountAgency,
-		AccountNumber: merchantProvider.AccountNumber,
-		AccountType:   merchantProvider.AccountType,
-		PixKey:        merchantProvider.PixKey,
-		PixKeyType:    merchantProvider.PixKeyType,
-	}
-
-	// TODO: Implementar cache de tokens
-	_, authErr := providerImpl.Authenticate(c.Context(), credentials)
-	if authErr != nil {
-		_ = h.auditService.LogProviderOperation(c.Context(), *merchantID, uuid.Nil, selectedProvider.Code, "authenticate", false, authErr.Error(), 0)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to authenticate with provider",
-		})
-	}
-
-	// Criar requisição de transferência
-	transferReq := &providers.TransferRequest{
-		ExternalID:  req.ExternalID,
-		Amount:      req.Amount,
-		Description: req.Description,
-
-		// Pagador (merchant)
-		PayerAccountAgency: merchantProvider.AccountAgency,
-		PayerAccountNumber: merchantProvider.AccountNumber,
-		PayerAccountType:   merchantProvider.AccountType,
-		PayerPixKey:        merchantProvider.PixKey,
-		PayerPixKeyType:    merchantProvider.PixKeyType,
-
-		// Recebedor
-		PayeeName:       req.PayeeName,
-		PayeeDocument:   req.PayeeDocument,
-		PayeePixKey:     req.PayeePixKey,
-		PayeePixKeyType: req.PayeePixKeyType,
-
-		Metadata: req.Metadata,
-	}
-
-	if req.PayeeAccount != nil {
-		transferReq.PayeeBank = req.PayeeAccount.Bank
-		transferReq.PayeeISPB = req.PayeeAccount.ISPB
-		transferReq.PayeeAccountAgency = req.PayeeAccount.Agency
-		transferReq.PayeeAccountNumber = req.PayeeAccount.Number
-		transferReq.PayeeAccountType = req.PayeeAccount.Type
-	}
-
-	// Criar transação no banco
+	// Criar transação (o provider é definido na primeira tentativa)
 	tx := &domain.Transaction{
 		ID:              uuid.New(),
 		MerchantID:      *merchantID,
-		ProviderID:      selectedProvider.ID,
 		ExternalID:      req.ExternalID,
-		Type:            domain.TransactionTypeTransfer,
+		Type:            txType,
 		Status:          domain.TransactionStatusPending,
 		Amount:          req.Amount,
 		Description:     req.Description,
@@ -232,6 +184,7 @@ func (h *TransactionHandler) CreateTransfer(c *fiber.Ctx) error {
 		PayeeDocument:   req.PayeeDocument,
 		PayeePixKey:     req.PayeePixKey,
 		PayeePixKeyType: req.PayeePixKeyType,
+		QRCode:          req.BRCode,
 		Metadata:        req.Metadata,
 	}
 
@@ -241,33 +194,111 @@ func (h *TransactionHandler) CreateTransfer(c *fiber.Ctx) error {
 		tx.PayeeAccountNumber = req.PayeeAccount.Number
 	}
 
-	if err := h.txRepo.Create(c.Context(), tx); err != nil {
+	var selectedProvider *domain.Provider
+	var transferResp *providers.TransferResponse
+	txCreated := false
+	submitted := false
+
+	// Executar transferência com fallback entre os providers do merchant
+	attempts, transferErr := h.providerManager.ExecuteWithFallback(c.Context(), *merchantID, req.ProviderCode, func(providerImpl providers.PixProvider, merchantProvider *domain.MerchantProvider) error {
+		selectedProvider = &merchantProvider.Provider
+		tx.ProviderID = merchantProvider.ProviderID
+		submitted = false
+
+		// Bancos sem pagamento de copia-e-cola são ignorados em favor do próximo provider
+		if txType == domain.TransactionTypePixCopyPaste && !providers.SupportsMethod(providerImpl, "pix_copy_paste") {
+			return &providers.ProviderError{Code: "NOT_SUPPORTED", Message: "Pagamento de copia-e-cola não suportado pelo provider", Retryable: true}
+		}
+
+		if !txCreated {
+			if err := h.createTransaction(c, tx); err != nil {
+				return err
+			}
+			txCreated = true
+		} else if updated, err := h.txRepo.UpdateIfStatus(c.Context(), tx, domain.TransactionStatusPending); err != nil {
+			return errTransactionPersistence
+		} else if !updated {
+			return errTransferCancelled
+		}
+
+		token, credentials, err := h.providerToken(c, providerImpl, merchantProvider)
+		if err != nil {
+			return err
+		}
+
+		// Criar requisição de transferência
+		transferReq := providers.NewTransferRequest(tx, merchantProvider, token.AccessToken, credentials.ClientID)
+		transferReq.QRCodeTxID = qrCodeTxID
+		if req.PayeeAccount != nil {
+			transferReq.PayeeISPB = req.PayeeAccount.ISPB
+			transferReq.PayeeAccountType = req.PayeeAccount.Type
+		}
+
+		submitted = true
+		resp, err := providerImpl.CreateTransfer(c.Context(), transferReq)
+		if err != nil {
+			h.tokenCache.InvalidateRevoked(err, merchantProvider)
+			return err
+		}
+		transferResp = resp
+		return nil
+	})
+
+	switch {
+	case errors.Is(transferErr, providers.ErrProviderNotConfigured):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant not configured for this provider",
+		})
+	case errors.Is(transferErr, providers.ErrNoHealthyProvider):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "no active providers configured",
+		})
+	case errors.Is(transferErr, errDuplicateExternalID):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "external_id already exists",
+		})
+	case !txCreated && txType == domain.TransactionTypePixCopyPaste && isNotSupported(transferErr):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "no configured provider supports BR Code payments",
+		})
+	case !txCreated:
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to create transaction",
 		})
 	}
 
-	// Executar transferência com provider
-	transferResp, transferErr := providerImpl.CreateTransfer(c.Context(), transferReq)
+	// Registrar todas as tentativas na transação
+	providers.RecordAttempts(c.Context(), h.txRepo, h.auditService, tx, attempts, "create_transfer")
+
+	if errors.Is(transferErr, errTransferCancelled) {
+		if current, err := h.txRepo.GetByID(c.Context(), tx.ID); err == nil {
+			tx = current
+		}
+		return h.transferCancelled(c, tx)
+	}
+
+	if transferErr != nil && submitted && providers.OutcomeUnknown(transferErr) {
+		return h.transferOutcomeUnknown(c, tx, selectedProvider, transferErr)
+	}
+
 	if transferErr != nil {
 		// Atualizar transação como falha
-		tx.Status = domain.TransactionStatusFailed
-		if providerErr, ok := transferErr.(*providers.ProviderError); ok {
-			tx.ErrorCode = providerErr.Code
-			tx.ErrorMessage = providerErr.Message
-		} else {
-			tx.ErrorMessage = transferErr.Error()
-		}
-		if err := h.txRepo.Update(c.Context(), tx); err != nil {
+		applyProviderError(tx, transferErr)
+		saved, err := h.saveSubmission(c, tx, false)
+		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "failed to update transaction",
 			})
 		}
+		if !saved {
+			return h.transferCancelled(c, tx)
+		}
 
-		_ = h.auditService.LogProviderOperation(c.Context(), *merchantID, tx.ID, selectedProvider.Code, "create_transfer", false, transferErr.Error(), 0)
+		h.notifyStatus(c, tx, selectedProvider)
 
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		return c.Status(providerErrorStatus(tx.ErrorCode)).JSON(fiber.Map{
 			"error":   "transfer failed",
+			"code":    tx.ErrorCode,
 			"details": tx.ErrorMessage,
 		})
 	}
@@ -279,20 +310,28 @@ func (h *TransactionHandler) CreateTransfer(c *fiber.Ctx) error {
 	tx.ProcessedAt = transferResp.ProcessedAt
 	tx.CompletedAt = transferResp.CompletedAt
 
-	if err := h.txRepo.Update(c.Context(), tx); err != nil {
+	saved, err := h.saveSubmission(c, tx, tx.Status != domain.TransactionStatusFailed)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to update transaction",
 		})
 	}
+	if !saved {
+		return h.transferCancelled(c, tx)
+	}
 
 	// Log de auditoria
-	_ = h.auditService.LogTransaction(c.Context(), *merchantID, uuid.Nil, tx.ID, "create_transfer", map[string]interface{}{
+	auditMetadata := map[string]interface{}{
 		"provider": selectedProvider.Code,
 		"amount":   req.Amount,
 		"status":   tx.Status,
-	})
+	}
+	if txType == domain.TransactionTypePixCopyPaste {
+		auditMetadata["brcode_txid"] = qrCodeTxID
+	}
+	_ = h.auditService.LogTransaction(c.Context(), *merchantID, uuid.Nil, tx.ID, "create_transfer", auditMetadata)
 
-	// TODO: Enviar webhook se configurado
+	h.notifyStatus(c, tx, selectedProvider)
 
 	return c.Status(fiber.StatusCreated).JSON(TransactionResponse{
 		ID:          tx.ID,
@@ -307,6 +346,252 @@ func (h *TransactionHandler) CreateTransfer(c *fiber.Ctx) error {
 	})
 }
 
+// transferOutcomeUnknown trata falhas em que não se sabe se o banco processou a transferência
+// (ex: 502, timeout). Sem o ID do banco não há como consultar o resultado, então a transação
+// vai direto para revisão manual, sem fallback para outro banco.
+func (h *TransactionHandler) transferOutcomeUnknown(c *fiber.Ctx, tx *domain.Transaction, provider *domain.Provider, transferErr error) error {
+	applyProviderError(tx, transferErr)
+	tx.Status = domain.TransactionStatusManualReview
+	saved, err := h.saveSubmission(c, tx, true)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update transaction",
+		})
+	}
+	if !saved {
+		return h.transferCancelled(c, tx)
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "create_transfer", map[string]interface{}{
+		"provider":        provider.Code,
+		"amount":          tx.Amount,
+		"status":          tx.Status,
+		"outcome_unknown": true,
+		"error_code":      tx.ErrorCode,
+	})
+
+	h.notifyStatus(c, tx, provider)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"error":   "transfer outcome unknown",
+		"code":    tx.ErrorCode,
+		"details": tx.ErrorMessage,
+		"transaction": TransactionResponse{
+			ID:          tx.ID,
+			ExternalID:  tx.ExternalID,
+			Status:      tx.Status,
+			Amount:      tx.Amount,
+			Description: tx.Description,
+			Provider:    provider.Code,
+			CreatedAt:   tx.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:   tx.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		},
+	})
+}
+
+// saveSubmission grava o resultado do envio se a transação continua pendente. Se ela foi
+// cancelada durante o envio e o banco pode ter aceito a transferência (accepted), o cancelamento
+// local não vale e a transação vai para revisão manual. Em tx fica a versão persistida.
+func (h *TransactionHandler) saveSubmission(c *fiber.Ctx, tx *domain.Transaction, accepted bool) (bool, error) {
+	updated, err := h.txRepo.UpdateIfStatus(c.Context(), tx, domain.TransactionStatusPending)
+	if err != nil || updated {
+		return updated, err
+	}
+
+	current, err := h.txRepo.GetByID(c.Context(), tx.ID)
+	if err != nil {
+		return false, err
+	}
+	if accepted && current.Status == domain.TransactionStatusCancelled {
+		previous := current.Status
+		current.MarkCancelConflict(tx.ProviderTxID, tx.E2EID, time.Now())
+		moved, err := h.txRepo.UpdateIfStatus(c.Context(), current, previous)
+		if err != nil {
+			return false, err
+		}
+		if moved {
+			_ = h.auditService.LogTransaction(c.Context(), current.MerchantID, uuid.Nil, current.ID, "status_transition", map[string]interface{}{
+				"from":     previous,
+				"to":       current.Status,
+				"provider": current.Provider.Code,
+				"source":   "create_transfer",
+				"reason":   current.ErrorMessage,
+			})
+			h.notifyStatus(c, current, nil)
+		}
+	}
+
+	*tx = *current
+	return false, nil
+}
+
+// transferCancelled responde à criação de uma transferência cancelada durante o envio
+func (h *TransactionHandler) transferCancelled(c *fiber.Ctx, tx *domain.Transaction) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"error":       "transaction was cancelled during submission",
+		"transaction": transactionResponse(tx),
+	})
+}
+
+// createTransaction persiste a nova transação. A verificação prévia do external_id não cobre
+// requisições simultâneas: o índice único decide qual delas cria a transação.
+func (h *TransactionHandler) createTransaction(c *fiber.Ctx, tx *domain.Transaction) error {
+	if err := h.txRepo.Create(c.Context(), tx); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errDuplicateExternalID
+		}
+		return errTransactionPersistence
+	}
+	return nil
+}
+
+// resolveBRCode decodifica o Pix copia e cola e preenche valor e recebedor da requisição.
+// QR Codes dinâmicos têm o payload JWS verificado no PSP do recebedor. Retorna o txid da cobrança.
+func (h *TransactionHandler) resolveBRCode(ctx context.Context, req *CreateTransferRequest) (string, error) {
+	if req.PayeePixKey != "" || req.PayeeAccount != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "payee_pix_key and payee_account must not be sent with br_code")
+	}
+
+	code, err := brcode.Decode(req.BRCode)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid br_code: "+err.Error())
+	}
+
+	amount := code.Amount
+	allowChange := false
+	txid := code.TxID
+	pixKey := code.PixKey
+	payeeName := code.MerchantName
+	payeeDocument := ""
+
+	if code.IsDynamic() {
+		payload, err := h.brcodeFetcher.Fetch(ctx, code.URL)
+		if err != nil {
+			return "", brCodePayloadError(err)
+		}
+		if amount, err = payload.Amount(); err != nil {
+			return "", fiber.NewError(fiber.StatusBadRequest, "invalid br_code: "+err.Error())
+		}
+		allowChange = payload.AllowChange()
+		txid = payload.TxID
+		pixKey = payload.PixKey
+		payeeDocument = payload.PayeeDocument()
+		if payload.Payee.Name != "" {
+			payeeName = payload.Payee.Name
+		}
+	}
+
+	if pixKey == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid br_code: missing pix key")
+	}
+	key, err := pixkey.Parse(pixKey)
+	if err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid br_code: "+err.Error())
+	}
+
+	// Valor em aberto no código (ou alterável pelo pagador) vem da requisição; caso contrário deve conferir
+	switch {
+	case amount == 0 || allowChange:
+		if req.Amount <= 0 {
+			return "", fiber.NewError(fiber.StatusBadRequest, "amount is required for br_code without fixed amount")
+		}
+	case req.Amount != 0 && req.Amount != amount:
+		return "", fiber.NewError(fiber.StatusBadRequest, "amount does not match br_code")
+	default:
+		req.Amount = amount
+	}
+
+	req.PayeePixKey = key.Value
+	req.PayeePixKeyType = key.Type
+	req.PayeeName = payeeName
+	req.PayeeDocument = payeeDocument
+	if req.Description == "" {
+		req.Description = code.Description
+	}
+
+	return txid, nil
+}
+
+// brCodePayloadError converte a falha de verificação do payload dinâmico em resposta HTTP
+func brCodePayloadError(err error) error {
+	switch {
+	case errors.Is(err, brcode.ErrPayloadExpired):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "br_code expired")
+	case errors.Is(err, brcode.ErrPayloadInactive):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "br_code charge is not active")
+	case errors.Is(err, brcode.ErrPayloadUnavailable):
+		log.Printf("Aviso: falha ao buscar payload do BR Code: %v", err)
+		return fiber.NewError(fiber.StatusBadGateway, "br_code payload unavailable")
+	}
+	return fiber.NewError(fiber.StatusBadRequest, "invalid br_code: "+err.Error())
+}
+
+// providerToken descriptografa as credenciais do merchant-provider e obtém o token de acesso (com cache)
+func (h *TransactionHandler) providerToken(c *fiber.Ctx, providerImpl providers.PixProvider, merchantProvider *domain.MerchantProvider) (*providers.AuthToken, providers.ProviderCredentials, error) {
+	credentials, err := h.providerManager.Credentials(merchantProvider)
+	if err != nil {
+		return nil, credentials, err
+	}
+
+	token, err := h.tokenCache.GetToken(c.Context(), providerImpl, merchantProvider, credentials)
+	if err != nil {
+		return nil, credentials, err
+	}
+	return token, credentials, nil
+}
+
+// applyProviderError marca a transação como falha com o erro retornado pelo provider
+func applyProviderError(tx *domain.Transaction, err error) {
+	tx.Status = domain.TransactionStatusFailed
+	var providerErr *providers.ProviderError
+	if errors.As(err, &providerErr) {
+		tx.ErrorCode = providerErr.Code
+		tx.ErrorMessage = providerErr.Message
+	} else {
+		tx.ErrorMessage = err.Error()
+	}
+}
+
+// isNotSupported indica que o provider não implementa a operação solicitada
+func isNotSupported(err error) bool {
+	var providerErr *providers.ProviderError
+	return errors.As(err, &providerErr) && providerErr.Code == "NOT_SUPPORTED"
+}
+
+// providerErrorStatus retorna o status HTTP de uma falha do provider.
+// Indisponibilidade do banco é falha de gateway, não da requisição do cliente.
+func providerErrorStatus(code string) int {
+	switch code {
+	case providers.ErrCodeBankUnavailable, providers.ErrCodeRateLimited:
+		return fiber.StatusBadGateway
+	}
+	return fiber.StatusBadRequest
+}
+
+// validationFailed responde 422 com os campos que violaram as regras de validação
+func validationFailed(c *fiber.Ctx, err error) error {
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+		"error":  "validation failed",
+		"fields": fieldErrs,
+	})
+}
+
+// notifyStatus enfileira os webhooks do merchant para o status atual da transação
+func (h *TransactionHandler) notifyStatus(c *fiber.Ctx, tx *domain.Transaction, provider *domain.Provider) {
+	if provider != nil {
+		tx.Provider = *provider
+	}
+	if err := h.webhooks.Enqueue(c.Context(), tx); err != nil {
+		log.Printf("Aviso: falha ao enfileirar webhooks da transação %s: %v", tx.ID, err)
+	}
+}
+
 // GetTransaction busca uma transação por ID
 func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
 	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
@@ -350,6 +635,222 @@ func (h *TransactionHandler) GetTransaction(c *fiber.Ctx) error {
 	})
 }
 
+// CancelTransferRequest representa uma requisição de cancelamento de transferência
+type CancelTransferRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=140"`
+}
+
+// CancelTransfer cancela uma transferência agendada ou pendente. Transferências ainda sem ID do
+// banco são canceladas localmente (um envio em andamento detecta o cancelamento ao gravar o
+// resultado); as demais dependem do suporte do provider.
+func (h *TransactionHandler) CancelTransfer(c *fiber.Ctx) error {
+	tx, err := h.findMerchantTransaction(c)
+	if err != nil {
+		return err
+	}
+
+	// O corpo é opcional: apenas o motivo do cancelamento
+	var req CancelTransferRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+	if err := validation.Struct(&req); err != nil {
+		return validationFailed(c, err)
+	}
+
+	if err := cancellable(tx); err != nil {
+		return err
+	}
+
+	submitted := tx.ProviderTxID != ""
+	if submitted {
+		if err := h.cancelAtProvider(c, tx, req.Reason); err != nil {
+			var providerErr *providers.ProviderError
+			if !errors.As(err, &providerErr) {
+				return err
+			}
+			return c.Status(providerErrorStatus(providerErr.Code)).JSON(fiber.Map{
+				"error":   "cancel failed",
+				"code":    providerErr.Code,
+				"details": providerErr.Message,
+			})
+		}
+	}
+
+	// O status só muda se ninguém (callback, poller) o alterou desde a leitura
+	previous := tx.Status
+	now := time.Now()
+	tx.Status = domain.TransactionStatusCancelled
+	tx.CancelledAt = &now
+	tx.UpdatedAt = now
+	updated, err := h.txRepo.UpdateIfStatus(c.Context(), tx, previous)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update transaction",
+		})
+	}
+	if !updated {
+		if submitted {
+			return h.reconcileCancel(c, tx.ID, req.Reason)
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "transaction status changed and can no longer be cancelled",
+		})
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "status_transition", map[string]interface{}{
+		"from":      previous,
+		"to":        tx.Status,
+		"provider":  tx.Provider.Code,
+		"source":    "cancel",
+		"submitted": submitted,
+		"reason":    req.Reason,
+	})
+
+	h.notifyStatus(c, tx, nil)
+
+	return c.JSON(transactionResponse(tx))
+}
+
+// reconcileCancel aplica o cancelamento já aceito pelo banco a uma transação cujo status mudou
+// desde a leitura. Se ela consta como concluída, o conflito vai para revisão manual.
+func (h *TransactionHandler) reconcileCancel(c *fiber.Ctx, txID uuid.UUID, reason string) error {
+	tx, err := h.txRepo.GetByID(c.Context(), txID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to load transaction",
+		})
+	}
+
+	previous := tx.Status
+	now := time.Now()
+	switch previous {
+	case domain.TransactionStatusCancelled, domain.TransactionStatusFailed:
+		// Nenhum valor foi transferido: o status atual já é compatível com o cancelamento
+		return c.JSON(transactionResponse(tx))
+	case domain.TransactionStatusPending, domain.TransactionStatusProcessing:
+		tx.Status = domain.TransactionStatusCancelled
+		tx.CancelledAt = &now
+	default:
+		tx.Status = domain.TransactionStatusManualReview
+		tx.ErrorCode = "CANCEL_CONFLICT"
+		tx.ErrorMessage = "banco aceitou o cancelamento de transferência com status " + string(previous)
+	}
+	tx.UpdatedAt = now
+
+	updated, err := h.txRepo.UpdateIfStatus(c.Context(), tx, previous)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update transaction",
+		})
+	}
+	if !updated {
+		_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "cancel_conflict", map[string]interface{}{
+			"status":   previous,
+			"provider": tx.Provider.Code,
+			"reason":   reason,
+		})
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "transfer cancelled at the provider but its status keeps changing; check it before retrying",
+		})
+	}
+
+	_ = h.auditService.LogTransaction(c.Context(), tx.MerchantID, uuid.Nil, tx.ID, "status_transition", map[string]interface{}{
+		"from":      previous,
+		"to":        tx.Status,
+		"provider":  tx.Provider.Code,
+		"source":    "cancel",
+		"submitted": true,
+		"reason":    reason,
+	})
+
+	h.notifyStatus(c, tx, nil)
+
+	if tx.Status == domain.TransactionStatusManualReview {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":       "transfer cancelled at the provider after being settled; sent to manual review",
+			"transaction": transactionResponse(tx),
+		})
+	}
+	return c.JSON(transactionResponse(tx))
+}
+
+// cancelAtProvider solicita o cancelamento ao banco que recebeu a transferência
+func (h *TransactionHandler) cancelAtProvider(c *fiber.Ctx, tx *domain.Transaction, reason string) error {
+	merchantProvider, err := h.merchantProviderRepo.GetByMerchantAndProvider(c.Context(), tx.MerchantID, tx.ProviderID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "merchant not configured for this provider")
+	}
+
+	providerImpl, err := h.providerManager.Instance(merchantProvider)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "failed to load provider")
+	}
+
+	if !providers.SupportsMethod(providerImpl, "cancel_transfer") {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "provider does not support cancelling submitted transfers")
+	}
+
+	start := time.Now()
+	token, credentials, err := h.providerToken(c, providerImpl, merchantProvider)
+	if err == nil {
+		err = providerImpl.CancelTransfer(c.Context(), &providers.CancelTransferRequest{
+			ProviderTxID: tx.ProviderTxID,
+			AuthToken:    token.AccessToken,
+			ClientID:     credentials.ClientID,
+			Reason:       reason,
+		})
+	}
+
+	errorMessage := ""
+	if err != nil {
+		h.tokenCache.InvalidateRevoked(err, merchantProvider)
+		errorMessage = err.Error()
+		err = providers.NewProviderError("CANCEL_FAILED", "falha ao cancelar transferência", err)
+	}
+	_ = h.auditService.LogProviderOperation(c.Context(), tx.MerchantID, tx.ID, merchantProvider.Provider.Code, "cancel_transfer", err == nil, errorMessage, time.Since(start).Milliseconds())
+
+	return err
+}
+
+// cancellable verifica se a transação é uma transferência que ainda pode ser cancelada
+func cancellable(tx *domain.Transaction) error {
+	if tx.Type != domain.TransactionTypeTransfer && tx.Type != domain.TransactionTypePixCopyPaste {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "only transfers can be cancelled")
+	}
+	if tx.Status != domain.TransactionStatusPending {
+		return fiber.NewError(fiber.StatusConflict, "only pending transfers can be cancelled")
+	}
+	return nil
+}
+
+// reservedExternalID recusa external_ids no espaço reservado às execuções agendadas
+func reservedExternalID(externalID string) error {
+	if strings.HasPrefix(externalID, domain.ScheduledExternalIDPrefix) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "external_id must not start with "+domain.ScheduledExternalIDPrefix)
+	}
+	return nil
+}
+
+// transactionResponse converte a transação para a resposta da API
+func transactionResponse(tx *domain.Transaction) TransactionResponse {
+	return TransactionResponse{
+		ID:          tx.ID,
+		ExternalID:  tx.ExternalID,
+		E2EID:       tx.E2EID,
+		Status:      tx.Status,
+		Amount:      tx.Amount,
+		Description: tx.Description,
+		Provider:    tx.Provider.Code,
+		CreatedAt:   tx.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   tx.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
 // ListTransactions lista transações do merchant
 func (h *TransactionHandler) ListTransactions(c *fiber.Ctx) error {
 	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
@@ -368,6 +869,15 @@ func (h *TransactionHandler) ListTransactions(c *fiber.Ctx) error {
 	if status := c.Query("status"); status != "" {
 		filters["status"] = domain.TransactionStatus(status)
 	}
+	if scheduledTransferID := c.Query("scheduled_transfer_id"); scheduledTransferID != "" {
+		id, err := uuid.Parse(scheduledTransferID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid scheduled_transfer_id",
+			})
+		}
+		filters["scheduled_transfer_id"] = id
+	}
 
 	transactions, total, err := h.txRepo.ListByMerchant(c.Context(), *merchantID, filters, limit, offset)
 	if err != nil {
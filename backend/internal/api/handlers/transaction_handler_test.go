@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/brcode"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/validation"
+)
+
+func TestResolveBRCodeStatic(t *testing.T) {
+	h := &TransactionHandler{}
+	code := mustEncodeBRCode(t, 2500)
+
+	req := &CreateTransferRequest{ExternalID: "p1", BRCode: code}
+	txid, err := h.resolveBRCode(context.Background(), req)
+	if err != nil {
+		t.Fatalf("resolveBRCode() error = %v", err)
+	}
+
+	if txid != "PEDIDO42" || req.Amount != 2500 || req.PayeePixKey != "loja@example.com" || req.PayeeName != "Loja Exemplo" {
+		t.Errorf("txid = %q, req = %+v", txid, req)
+	}
+	if req.Description != "Pedido 42" {
+		t.Errorf("Description = %q, want description from code", req.Description)
+	}
+	if req.PayeePixKeyType != domain.PixKeyTypeEmail {
+		t.Errorf("PayeePixKeyType = %q, want type detected from key", req.PayeePixKeyType)
+	}
+}
+
+func TestResolveBRCodeOpenAmount(t *testing.T) {
+	h := &TransactionHandler{}
+	code := mustEncodeBRCode(t, 0)
+
+	req := &CreateTransferRequest{ExternalID: "p1", BRCode: code, Amount: 990}
+	if _, err := h.resolveBRCode(context.Background(), req); err != nil {
+		t.Fatalf("resolveBRCode() error = %v", err)
+	}
+	if req.Amount != 990 {
+		t.Errorf("Amount = %d, want amount from request", req.Amount)
+	}
+}
+
+func TestResolveBRCodeRejects(t *testing.T) {
+	fixed := mustEncodeBRCode(t, 2500)
+	open := mustEncodeBRCode(t, 0)
+
+	tests := []struct {
+		name string
+		req  CreateTransferRequest
+	}{
+		{"amount mismatch", CreateTransferRequest{BRCode: fixed, Amount: 100}},
+		{"open amount without amount", CreateTransferRequest{BRCode: open}},
+		{"payee key with br_code", CreateTransferRequest{BRCode: fixed, PayeePixKey: "outra@example.com"}},
+		{"payee account with br_code", CreateTransferRequest{BRCode: fixed, PayeeAccount: &AccountInfo{Number: "1"}}},
+		{"tampered code", CreateTransferRequest{BRCode: fixed[:len(fixed)-4] + "0000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &TransactionHandler{}
+			req := tt.req
+			_, err := h.resolveBRCode(context.Background(), &req)
+
+			var fiberErr *fiber.Error
+			if !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusBadRequest {
+				t.Errorf("resolveBRCode() error = %v, want 400", err)
+			}
+		})
+	}
+}
+
+func TestBRCodePayloadError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{brcode.ErrPayloadExpired, fiber.StatusUnprocessableEntity},
+		{brcode.ErrPayloadInactive, fiber.StatusUnprocessableEntity},
+		{brcode.ErrPayloadUnavailable, fiber.StatusBadGateway},
+		{brcode.ErrInvalidSignature, fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		var fiberErr *fiber.Error
+		if err := brCodePayloadError(tt.err); !errors.As(err, &fiberErr) || fiberErr.Code != tt.status {
+			t.Errorf("brCodePayloadError(%v) = %v, want status %d", tt.err, err, tt.status)
+		}
+	}
+}
+
+func mustEncodeBRCode(t *testing.T, amount int64) string {
+	t.Helper()
+
+	code, err := brcode.Encode(&brcode.Payload{
+		PixKey:       "loja@example.com",
+		Description:  "Pedido 42",
+		Amount:       amount,
+		MerchantName: "Loja Exemplo",
+		MerchantCity: "Recife",
+		TxID:         "PEDIDO42",
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	return code
+}
+
+func TestCancellable(t *testing.T) {
+	pending := domain.Transaction{Type: domain.TransactionTypeTransfer, Status: domain.TransactionStatusPending}
+	if err := cancellable(&pending); err != nil {
+		t.Errorf("cancellable() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		modify func(tx *domain.Transaction)
+		status int
+	}{
+		"charge":        {func(tx *domain.Transaction) { tx.Type = domain.TransactionTypeQRCodeDynamic }, fiber.StatusUnprocessableEntity},
+		"completed":     {func(tx *domain.Transaction) { tx.Status = domain.TransactionStatusCompleted }, fiber.StatusConflict},
+		"cancelled":     {func(tx *domain.Transaction) { tx.Status = domain.TransactionStatusCancelled }, fiber.StatusConflict},
+		"manual review": {func(tx *domain.Transaction) { tx.Status = domain.TransactionStatusManualReview }, fiber.StatusConflict},
+	}
+	for name, tt := range tests {
+		tx := pending
+		tt.modify(&tx)
+		var fiberErr *fiber.Error
+		if err := cancellable(&tx); !errors.As(err, &fiberErr) || fiberErr.Code != tt.status {
+			t.Errorf("%s: cancellable() error = %v, want %d", name, err, tt.status)
+		}
+	}
+}
+
+func TestReservedExternalID(t *testing.T) {
+	if err := reservedExternalID("ORDER-1"); err != nil {
+		t.Errorf("reservedExternalID() error = %v", err)
+	}
+	var fiberErr *fiber.Error
+	if err := reservedExternalID("scheduled:abc:1"); !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusUnprocessableEntity {
+		t.Errorf("reservedExternalID() error = %v, want 422", err)
+	}
+}
+
+func TestCreateTransferValidation(t *testing.T) {
+	merchantID := uuid.New()
+	app := fiber.New()
+	app.Post("/transfer", func(c *fiber.Ctx) error {
+		c.Locals("merchant_id", &merchantID)
+		return c.Next()
+	}, (&TransactionHandler{}).CreateTransfer)
+
+	body := `{"external_id":"p1","amount":0,"payee_name":"Fulano","payee_document":"12345678900","payee_pix_key":"fulano@","payee_pix_key_type":"email"}`
+	req := httptest.NewRequest(fiber.MethodPost, "/transfer", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", resp.StatusCode)
+	}
+
+	var got struct {
+		Fields []validation.FieldError `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	fields := map[string]bool{}
+	for _, fieldErr := range got.Fields {
+		fields[fieldErr.Field] = true
+	}
+	for _, field := range []string{"amount", "payee_document", "payee_pix_key"} {
+		if !fields[field] {
+			t.Errorf("%s missing from %+v", field, got.Fields)
+		}
+	}
+}
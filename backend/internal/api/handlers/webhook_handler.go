@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/audit"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/netguard"
+	"github.com/pixsaas/backend/internal/repository"
+	"github.com/pixsaas/backend/internal/security"
+	"github.com/pixsaas/backend/internal/webhook"
+	"gorm.io/gorm"
+)
+
+// Limites de configuração de webhooks
+const (
+	webhookSecretPrefix     = "whsec_"
+	defaultWebhookRetries   = 3
+	maxWebhookRetries       = 10
+	defaultWebhookTimeout   = 30 // segundos
+	maxWebhookTimeout       = 60 // segundos
+	defaultSecretOverlap    = 24 * time.Hour
+	maxSecretOverlap        = 7 * 24 * time.Hour
+	maxWebhookDeliveryLimit = 100
+)
+
+// WebhookHandler gerencia os webhooks do merchant
+type WebhookHandler struct {
+	webhookRepo       *repository.WebhookRepository
+	auditService      *audit.AuditService
+	encryptionService *security.EncryptionService
+	webhooks          *webhook.Dispatcher
+	resolver          netguard.Resolver
+}
+
+// NewWebhookHandler cria um novo handler de webhooks
+func NewWebhookHandler(
+	db *gorm.DB,
+	auditService *audit.AuditService,
+	encryptionService *security.EncryptionService,
+	webhooks *webhook.Dispatcher,
+) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo:       repository.NewWebhookRepository(db),
+		auditService:      auditService,
+		encryptionService: encryptionService,
+		webhooks:          webhooks,
+		resolver:          net.DefaultResolver,
+	}
+}
+
+// CreateWebhookRequest representa uma requisição de cadastro de webhook
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	MaxRetries *int     `json:"max_retries,omitempty"`
+	Timeout    *int     `json:"timeout,omitempty"` // segundos
+}
+
+// UpdateWebhookRequest representa uma atualização parcial de webhook
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url,omitempty"`
+	Events     []string `json:"events,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+	MaxRetries *int     `json:"max_retries,omitempty"`
+	Timeout    *int     `json:"timeout,omitempty"`
+}
+
+// RotateSecretRequest representa uma requisição de rotação de segredo
+type RotateSecretRequest struct {
+	// Tempo, em segundos, em que o segredo anterior continua assinando (padrão 24h, 0 revoga imediatamente)
+	OverlapSeconds *int64 `json:"overlap_seconds,omitempty"`
+}
+
+// WebhookResponse representa a resposta de um webhook
+type WebhookResponse struct {
+	ID                      uuid.UUID  `json:"id"`
+	URL                     string     `json:"url"`
+	Events                  []string   `json:"events"`
+	Active                  bool       `json:"active"`
+	MaxRetries              int        `json:"max_retries"`
+	Timeout                 int        `json:"timeout"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+
+	// Retornado apenas na criação e na rotação
+	Secret string `json:"secret,omitempty"`
+}
+
+// CreateWebhook cadastra um webhook do merchant
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	var req CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	hook := &domain.Webhook{
+		ID:         uuid.New(),
+		MerchantID: *merchantID,
+		URL:        req.URL,
+		Events:     req.Events,
+		Active:     true,
+		MaxRetries: defaultWebhookRetries,
+		Timeout:    defaultWebhookTimeout,
+	}
+	if req.MaxRetries != nil {
+		hook.MaxRetries = *req.MaxRetries
+	}
+	if req.Timeout != nil {
+		hook.Timeout = *req.Timeout
+	}
+
+	if err := validateWebhook(c.Context(), hook, h.resolver); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	secret, err := h.newSecret(hook)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to generate webhook secret",
+		})
+	}
+
+	if err := h.webhookRepo.Create(c.Context(), hook); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to create webhook",
+		})
+	}
+
+	h.logAction(c, hook, "webhook_created", map[string]interface{}{
+		"url":    hook.URL,
+		"events": hook.Events,
+	})
+
+	resp := toWebhookResponse(hook)
+	resp.Secret = secret
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// ListWebhooks lista os webhooks do merchant
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "merchant not found in context",
+		})
+	}
+
+	hooks, err := h.webhookRepo.ListByMerchant(c.Context(), *merchantID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list webhooks",
+		})
+	}
+
+	response := make([]WebhookResponse, 0, len(hooks))
+	for i := range hooks {
+		response = append(response, toWebhookResponse(&hooks[i]))
+	}
+
+	return c.JSON(fiber.Map{
+		"data": response,
+	})
+}
+
+// GetWebhook busca um webhook do merchant
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(toWebhookResponse(hook))
+}
+
+// UpdateWebhook atualiza URL, eventos, status e limites de um webhook
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		hook.Events = req.Events
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if req.MaxRetries != nil {
+		hook.MaxRetries = *req.MaxRetries
+	}
+	if req.Timeout != nil {
+		hook.Timeout = *req.Timeout
+	}
+
+	if err := validateWebhook(c.Context(), hook, h.resolver); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.webhookRepo.Update(c.Context(), hook); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update webhook",
+		})
+	}
+
+	h.logAction(c, hook, "webhook_updated", map[string]interface{}{
+		"url":    hook.URL,
+		"events": hook.Events,
+		"active": hook.Active,
+	})
+
+	return c.JSON(toWebhookResponse(hook))
+}
+
+// DeleteWebhook remove um webhook do merchant
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhookRepo.Delete(c.Context(), hook); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to delete webhook",
+		})
+	}
+
+	h.logAction(c, hook, "webhook_deleted", nil)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RotateSecret gera um novo segredo de assinatura. Durante a janela de transição os
+// envios são assinados com os dois segredos, para o merchant atualizar sua verificação.
+func (h *WebhookHandler) RotateSecret(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	var req RotateSecretRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+	}
+
+	overlap := defaultSecretOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+		if overlap < 0 || overlap > maxSecretOverlap {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("overlap_seconds must be between 0 and %d", int64(maxSecretOverlap.Seconds())),
+			})
+		}
+	}
+
+	previous := hook.Secret
+	secret, err := h.newSecret(hook)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to generate webhook secret",
+		})
+	}
+
+	if overlap > 0 {
+		expiresAt := time.Now().Add(overlap)
+		hook.PreviousSecret = previous
+		hook.PreviousSecretExpiresAt = &expiresAt
+	} else {
+		hook.PreviousSecret = ""
+		hook.PreviousSecretExpiresAt = nil
+	}
+
+	if err := h.webhookRepo.Update(c.Context(), hook); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to rotate webhook secret",
+		})
+	}
+
+	h.logAction(c, hook, "webhook_secret_rotated", map[string]interface{}{
+		"overlap_seconds": int64(overlap.Seconds()),
+	})
+
+	resp := toWebhookResponse(hook)
+	resp.Secret = secret
+	return c.JSON(resp)
+}
+
+// TestWebhook envia um evento de teste e retorna a resposta do endpoint do merchant
+func (h *WebhookHandler) TestWebhook(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	result := h.webhooks.SendTest(c.Context(), hook)
+
+	h.logAction(c, hook, "webhook_test", map[string]interface{}{
+		"success":       result.Success,
+		"response_code": result.ResponseCode,
+	})
+
+	return c.JSON(result)
+}
+
+// ListDeliveries lista o histórico de entregas de um webhook.
+// Parâmetros: status (pending, success, failed), limit e offset.
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", domain.WebhookDeliveryPending, domain.WebhookDeliverySuccess, domain.WebhookDeliveryFailed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid status",
+		})
+	}
+
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > maxWebhookDeliveryLimit {
+		limit = maxWebhookDeliveryLimit
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, total, err := h.webhookRepo.ListDeliveries(c.Context(), hook.ID, status, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to list webhook deliveries",
+		})
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		response = append(response, toWebhookDeliveryResponse(&deliveries[i]))
+	}
+
+	return c.JSON(fiber.Map{
+		"data":   response,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// RedeliverDelivery reenvia uma entrega que falhou
+func (h *WebhookHandler) RedeliverDelivery(c *fiber.Ctx) error {
+	hook, err := h.findWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	deliveryID, err := uuid.Parse(c.Params("deliveryId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid delivery id",
+		})
+	}
+
+	delivery, err := h.webhookRepo.GetDelivery(c.Context(), hook.ID, deliveryID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "delivery not found",
+		})
+	}
+
+	if delivery.Status != domain.WebhookDeliveryFailed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "only failed deliveries can be redelivered",
+		})
+	}
+	if !hook.Active {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "webhook is inactive",
+		})
+	}
+
+	if err := h.webhooks.Redeliver(c.Context(), delivery.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to schedule redelivery",
+		})
+	}
+
+	h.logAction(c, hook, "webhook_redelivery", map[string]interface{}{
+		"delivery_id":    delivery.ID,
+		"transaction_id": delivery.TransactionID,
+		"event":          delivery.Event,
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"id":     delivery.ID,
+		"status": domain.WebhookDeliveryPending,
+	})
+}
+
+// WebhookDeliveryResponse representa uma entrega no histórico
+type WebhookDeliveryResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	TransactionID uuid.UUID  `json:"transaction_id"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"`
+	Attempt       int        `json:"attempt"`
+	ResponseCode  int        `json:"response_code"`
+	ResponseBody  string     `json:"response_body,omitempty"`
+	ErrorMessage  string     `json:"error_message,omitempty"`
+	NextRetryAt   *time.Time `json:"next_retry_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// findWebhook busca o webhook da rota entre os webhooks do merchant autenticado
+func (h *WebhookHandler) findWebhook(c *fiber.Ctx) (*domain.Webhook, error) {
+	merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+	if !ok || merchantID == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "merchant not found in context")
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid webhook id")
+	}
+
+	hook, err := h.webhookRepo.GetByMerchantAndID(c.Context(), *merchantID, id)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "webhook not found")
+	}
+	return hook, nil
+}
+
+// newSecret gera um segredo de assinatura e o armazena criptografado no webhook
+func (h *WebhookHandler) newSecret(hook *domain.Webhook) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	secret := webhookSecretPrefix + hex.EncodeToString(raw)
+
+	encrypted, err := h.encryptionService.Encrypt(secret)
+	if err != nil {
+		return "", err
+	}
+	hook.Secret = encrypted
+	return secret, nil
+}
+
+// logAction registra uma ação de gerenciamento de webhook na auditoria
+func (h *WebhookHandler) logAction(c *fiber.Ctx, hook *domain.Webhook, action string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["webhook_id"] = hook.ID
+
+	entry := &audit.LogEntry{
+		MerchantID: &hook.MerchantID,
+		Action:     action,
+		Resource:   "webhook",
+		Method:     c.Method(),
+		Path:       c.Path(),
+		IPAddress:  c.IP(),
+		Metadata:   metadata,
+	}
+	if userID, ok := c.Locals("user_id").(uuid.UUID); ok {
+		entry.UserID = &userID
+	}
+
+	_ = h.auditService.Log(c.Context(), entry)
+}
+
+// validateWebhook valida URL, eventos e limites de um webhook. A URL deve apontar para um
+// endereço público: loopback, redes privadas, link-local e metadados de nuvem são recusados.
+func validateWebhook(ctx context.Context, hook *domain.Webhook, resolver netguard.Resolver) error {
+	parsed, err := url.Parse(hook.URL)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return errors.New("url must be a valid https URL")
+	}
+	if err := netguard.CheckDestination(ctx, resolver, parsed.Hostname()); err != nil {
+		return fmt.Errorf("url must point to a public address: %w", err)
+	}
+
+	if len(hook.Events) == 0 {
+		return errors.New("events must not be empty")
+	}
+	for _, event := range hook.Events {
+		if !webhook.IsSupportedEvent(event) {
+			return fmt.Errorf("unsupported event: %s", event)
+		}
+	}
+
+	if hook.MaxRetries < 0 || hook.MaxRetries > maxWebhookRetries {
+		return fmt.Errorf("max_retries must be between 0 and %d", maxWebhookRetries)
+	}
+	if hook.Timeout < 1 || hook.Timeout > maxWebhookTimeout {
+		return fmt.Errorf("timeout must be between 1 and %d seconds", maxWebhookTimeout)
+	}
+	return nil
+}
+
+// toWebhookResponse converte um webhook para a resposta da API
+func toWebhookResponse(hook *domain.Webhook) WebhookResponse {
+	resp := WebhookResponse{
+		ID:         hook.ID,
+		URL:        hook.URL,
+		Events:     hook.Events,
+		Active:     hook.Active,
+		MaxRetries: hook.MaxRetries,
+		Timeout:    hook.Timeout,
+		CreatedAt:  hook.CreatedAt,
+		UpdatedAt:  hook.UpdatedAt,
+	}
+	if hook.PreviousSecretExpiresAt != nil && hook.PreviousSecretExpiresAt.After(time.Now()) {
+		resp.PreviousSecretExpiresAt = hook.PreviousSecretExpiresAt
+	}
+	return resp
+}
+
+// toWebhookDeliveryResponse converte uma entrega para a resposta da API
+func toWebhookDeliveryResponse(delivery *domain.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:            delivery.ID,
+		TransactionID: delivery.TransactionID,
+		Event:         delivery.Event,
+		Status:        delivery.Status,
+		Attempt:       delivery.Attempt,
+		ResponseCode:  delivery.ResponseCode,
+		ResponseBody:  delivery.ResponseBody,
+		ErrorMessage:  delivery.ErrorMessage,
+		NextRetryAt:   delivery.NextRetryAt,
+		DeliveredAt:   delivery.DeliveredAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+}
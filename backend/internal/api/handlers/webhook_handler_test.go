@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	valid := func() *domain.Webhook {
+		return &domain.Webhook{
+			URL:        "https://merchant.example.com/pix/webhook",
+			Events:     []string{"transaction.completed", "transaction.failed"},
+			MaxRetries: 3,
+			Timeout:    30,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(w *domain.Webhook)
+		wantErr bool
+	}{
+		{"valid", func(w *domain.Webhook) {}, false},
+		{"wildcard", func(w *domain.Webhook) { w.Events = []string{"*"} }, false},
+		{"http url", func(w *domain.Webhook) { w.URL = "http://merchant.example.com" }, true},
+		{"missing host", func(w *domain.Webhook) { w.URL = "https://" }, true},
+		{"invalid url", func(w *domain.Webhook) { w.URL = "not a url" }, true},
+		{"no events", func(w *domain.Webhook) { w.Events = nil }, true},
+		{"unknown event", func(w *domain.Webhook) { w.Events = []string{"charge.paid"} }, true},
+		{"negative retries", func(w *domain.Webhook) { w.MaxRetries = -1 }, true},
+		{"too many retries", func(w *domain.Webhook) { w.MaxRetries = maxWebhookRetries + 1 }, true},
+		{"zero timeout", func(w *domain.Webhook) { w.Timeout = 0 }, true},
+		{"timeout too long", func(w *domain.Webhook) { w.Timeout = maxWebhookTimeout + 1 }, true},
+		{"public ip", func(w *domain.Webhook) { w.URL = "https://203.0.113.10/hook" }, false},
+		{"loopback ip", func(w *domain.Webhook) { w.URL = "https://127.0.0.1:8443/hook" }, true},
+		{"ipv6 loopback", func(w *domain.Webhook) { w.URL = "https://[::1]/hook" }, true},
+		{"cloud metadata", func(w *domain.Webhook) { w.URL = "https://169.254.169.254/latest/meta-data" }, true},
+		{"host resolving to private network", func(w *domain.Webhook) { w.URL = "https://internal.example.com/hook" }, true},
+		{"unresolvable host", func(w *domain.Webhook) { w.URL = "https://missing.example.com/hook" }, true},
+	}
+
+	resolver := fakeResolver{
+		"merchant.example.com": {"203.0.113.10"},
+		"internal.example.com": {"203.0.113.11", "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := valid()
+			tt.modify(hook)
+			if err := validateWebhook(context.Background(), hook, resolver); (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeResolver resolve hosts a partir de uma tabela fixa
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
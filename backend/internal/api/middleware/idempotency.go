@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// IdempotencyHeader é o header com a chave de idempotência enviada pelo cliente
+const IdempotencyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore persiste as chaves de idempotência e as respostas associadas
+type IdempotencyStore interface {
+	Acquire(ctx context.Context, record *domain.IdempotencyKey, now time.Time) (bool, *domain.IdempotencyKey, error)
+	Complete(ctx context.Context, record *domain.IdempotencyKey) error
+	Release(ctx context.Context, record *domain.IdempotencyKey) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Idempotency reproduz a resposta original para requisições repetidas com o mesmo Idempotency-Key
+type Idempotency struct {
+	store       IdempotencyStore
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+// NewIdempotency cria o middleware de idempotência. As chaves expiradas são removidas por Run.
+func NewIdempotency(store IdempotencyStore, ttl, lockTimeout time.Duration) *Idempotency {
+	return &Idempotency{
+		store:       store,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+		now:         time.Now,
+	}
+}
+
+// Middleware retorna o middleware de idempotência. Requisições sem o header, que não alteram
+// estado ou sem merchant seguem sem controle. A mesma chave com outro corpo é rejeitada e,
+// enquanto a requisição original estiver em andamento, as repetições recebem 409.
+func (i *Idempotency) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyHeader)
+		if key == "" || !isMutatingMethod(c.Method()) {
+			return c.Next()
+		}
+
+		merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+		if !ok || merchantID == nil {
+			return c.Next()
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Idempotency-Key must have at most 255 characters",
+			})
+		}
+
+		now := i.now()
+		record := &domain.IdempotencyKey{
+			ID:          uuid.New(),
+			MerchantID:  *merchantID,
+			Key:         key,
+			Method:      c.Method(),
+			Path:        c.Path(),
+			RequestHash: requestFingerprint(c),
+			LockedUntil: now.Add(i.lockTimeout),
+			ExpiresAt:   now.Add(i.ttl),
+		}
+
+		acquired, existing, err := i.store.Acquire(c.Context(), record, now)
+		if err != nil {
+			log.Printf("Erro ao registrar Idempotency-Key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to process Idempotency-Key",
+			})
+		}
+		if !acquired {
+			return i.replay(c, record, existing)
+		}
+
+		// Erros são renderizados aqui para que a resposta final seja armazenada
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = i.store.Release(context.Background(), record)
+				return handlerErr
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			// Falhas do servidor não são definitivas: a chave é liberada para nova tentativa
+			if err := i.store.Release(context.Background(), record); err != nil {
+				log.Printf("Aviso: falha ao liberar Idempotency-Key %s: %v", key, err)
+			}
+			return nil
+		}
+
+		completedAt := i.now()
+		record.StatusCode = status
+		record.ContentType = string(c.Response().Header.ContentType())
+		record.ResponseBody = append([]byte(nil), c.Response().Body()...)
+		record.CompletedAt = &completedAt
+		if err := i.store.Complete(context.Background(), record); err != nil {
+			log.Printf("Aviso: falha ao armazenar resposta do Idempotency-Key %s: %v", key, err)
+		}
+		return nil
+	}
+}
+
+// replay responde a uma repetição com a resposta armazenada da requisição original
+func (i *Idempotency) replay(c *fiber.Ctx, record, existing *domain.IdempotencyKey) error {
+	if existing.RequestHash != record.RequestHash {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Idempotency-Key already used with a different request",
+		})
+	}
+
+	if existing.CompletedAt == nil {
+		retryAfter := int(existing.LockedUntil.Sub(i.now()).Seconds()) + 1
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "a request with this Idempotency-Key is still being processed",
+		})
+	}
+
+	c.Set("Idempotent-Replayed", "true")
+	if existing.ContentType != "" {
+		c.Set(fiber.HeaderContentType, existing.ContentType)
+	}
+	return c.Status(existing.StatusCode).Send(existing.ResponseBody)
+}
+
+// Run remove periodicamente as chaves expiradas até o contexto ser cancelado
+func (i *Idempotency) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := i.store.DeleteExpired(ctx, i.now()); err != nil && ctx.Err() == nil {
+				log.Printf("Aviso: falha ao remover Idempotency-Keys expiradas: %v", err)
+			}
+		}
+	}
+}
+
+// requestFingerprint identifica a requisição por método, path e corpo
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
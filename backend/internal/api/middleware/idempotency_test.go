@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) Acquire(ctx context.Context, record *domain.IdempotencyKey, now time.Time) (bool, *domain.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := record.MerchantID.String() + "/" + record.Key
+	if existing, ok := s.records[id]; ok && existing.ExpiresAt.After(now) {
+		copied := *existing
+		return false, &copied, nil
+	}
+	stored := *record
+	s.records[id] = &stored
+	return true, nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record *domain.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *record
+	s.records[record.MerchantID.String()+"/"+record.Key] = &stored
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, record *domain.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, record.MerchantID.String()+"/"+record.Key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newIdempotencyTestApp(handler fiber.Handler) (*fiber.App, *memoryIdempotencyStore) {
+	store := &memoryIdempotencyStore{records: make(map[string]*domain.IdempotencyKey)}
+	idempotency := &Idempotency{store: store, ttl: time.Hour, lockTimeout: time.Minute, now: time.Now}
+
+	merchantID := uuid.New()
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("merchant_id", &merchantID)
+		return c.Next()
+	})
+	app.Use(idempotency.Middleware())
+	app.Post("/transfer", handler)
+	return app, store
+}
+
+func sendIdempotent(t *testing.T, app *fiber.App, key, body string) (int, string, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/transfer", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	payload, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(payload), resp.Header.Get("Idempotent-Replayed")
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	app, _ := newIdempotencyTestApp(func(c *fiber.Ctx) error {
+		calls++
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"call": calls})
+	})
+
+	status, body, replayed := sendIdempotent(t, app, "k1", `{"amount":100}`)
+	if status != fiber.StatusCreated || replayed != "" {
+		t.Fatalf("first request = %d (replayed %q)", status, replayed)
+	}
+
+	status2, body2, replayed2 := sendIdempotent(t, app, "k1", `{"amount":100}`)
+	if status2 != fiber.StatusCreated || body2 != body || replayed2 != "true" {
+		t.Errorf("retry = %d %s (replayed %q), want %d %s", status2, body2, replayed2, status, body)
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+
+	// Sem o header, cada requisição é executada
+	sendIdempotent(t, app, "", `{"amount":100}`)
+	if calls != 2 {
+		t.Errorf("handler called %d times without key, want 2", calls)
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	app, _ := newIdempotencyTestApp(func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	sendIdempotent(t, app, "k1", `{"amount":100}`)
+	if status, _, _ := sendIdempotent(t, app, "k1", `{"amount":200}`); status != fiber.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", status)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRequest(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app, _ := newIdempotencyTestApp(func(c *fiber.Ctx) error {
+		close(started)
+		<-release
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	done := make(chan int)
+	go func() {
+		status, _, _ := sendIdempotent(t, app, "k1", `{}`)
+		done <- status
+	}()
+	<-started
+
+	if status, _, _ := sendIdempotent(t, app, "k1", `{}`); status != fiber.StatusConflict {
+		t.Errorf("concurrent status = %d, want 409", status)
+	}
+
+	close(release)
+	if status := <-done; status != fiber.StatusCreated {
+		t.Errorf("original status = %d, want 201", status)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	calls := 0
+	app, store := newIdempotencyTestApp(func(c *fiber.Ctx) error {
+		calls++
+		if calls == 1 {
+			return fiber.NewError(fiber.StatusInternalServerError, "boom")
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	if status, _, _ := sendIdempotent(t, app, "k1", `{}`); status != fiber.StatusInternalServerError {
+		t.Fatalf("first status = %d, want 500", status)
+	}
+	if len(store.records) != 0 {
+		t.Errorf("key kept after server error: %+v", store.records)
+	}
+	if status, _, replayed := sendIdempotent(t, app, "k1", `{}`); status != fiber.StatusCreated || replayed != "" {
+		t.Errorf("retry status = %d (replayed %q), want new 201", status, replayed)
+	}
+}
+
+func TestIdempotencyStoresClientErrors(t *testing.T) {
+	app, _ := newIdempotencyTestApp(func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "invalid")
+	})
+
+	sendIdempotent(t, app, "k1", `{}`)
+	if status, _, replayed := sendIdempotent(t, app, "k1", `{}`); status != fiber.StatusUnprocessableEntity || replayed != "true" {
+		t.Errorf("retry status = %d (replayed %q), want replayed 422", status, replayed)
+	}
+}
+
+func TestIdempotencyRunStopsWithContext(t *testing.T) {
+	idempotency := NewIdempotency(&memoryIdempotencyStore{}, time.Hour, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		idempotency.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after the context was cancelled")
+	}
+}
@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"math"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // RateLimiter implementa rate limiting simples em memória
@@ -17,10 +20,15 @@ type RateLimiter struct {
 
 // NewRateLimiter cria um novo rate limiter
 func NewRateLimiter(requestsPerSecond int) *RateLimiter {
+	return NewWindowRateLimiter(requestsPerSecond, time.Second)
+}
+
+// NewWindowRateLimiter cria um rate limiter com limite de requisições por janela
+func NewWindowRateLimiter(limit int, window time.Duration) *RateLimiter {
 	rl := &RateLimiter{
 		requests: make(map[string][]time.Time),
-		limit:    requestsPerSecond,
-		window:   time.Second,
+		limit:    limit,
+		window:   window,
 	}
 
 	// Limpar entradas antigas periodicamente
@@ -49,6 +57,28 @@ func (rl *RateLimiter) Middleware() fiber.Handler {
 	}
 }
 
+// MerchantMiddleware aplica o limite por merchant autenticado (usar após RequireMerchant)
+func (rl *RateLimiter) MerchantMiddleware() fiber.Handler {
+	retryAfter := int(math.Ceil(rl.window.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		merchantID, ok := c.Locals("merchant_id").(*uuid.UUID)
+		if !ok || merchantID == nil {
+			return c.Next()
+		}
+
+		if !rl.allow(merchantID.String()) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":       "rate limit exceeded",
+				"retry_after": retryAfter,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
 func (rl *RateLimiter) allow(identifier string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestMerchantMiddlewareLimitsPerMerchant(t *testing.T) {
+	limiter := NewWindowRateLimiter(2, time.Minute)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		merchantID := uuid.MustParse(c.Get("X-Merchant"))
+		c.Locals("merchant_id", &merchantID)
+		return c.Next()
+	})
+	app.Use(limiter.MerchantMiddleware())
+	app.Get("/lookup", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(merchantID uuid.UUID) (int, string) {
+		req := httptest.NewRequest(fiber.MethodGet, "/lookup", nil)
+		req.Header.Set("X-Merchant", merchantID.String())
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter)
+	}
+
+	first, second := uuid.New(), uuid.New()
+	for i := 0; i < 2; i++ {
+		if status, _ := send(first); status != fiber.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, status)
+		}
+	}
+	if status, retryAfter := send(first); status != fiber.StatusTooManyRequests || retryAfter != "60" {
+		t.Errorf("over limit = %d (Retry-After %q), want 429 with 60", status, retryAfter)
+	}
+	if status, _ := send(second); status != fiber.StatusOK {
+		t.Errorf("other merchant = %d, want 200", status)
+	}
+}
@@ -0,0 +1,307 @@
+// Package brcode gera e interpreta o BR Code do PIX (payload EMV-MPM do "copia e cola").
+package brcode
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// GUI identifica o arranjo PIX no campo de informações da conta do recebedor
+const GUI = "br.gov.bcb.pix"
+
+// Identificadores dos campos EMV usados no BR Code
+const (
+	idPayloadFormatIndicator     = "00"
+	idPointOfInitiationMethod    = "01"
+	idMerchantAccountInfo        = "26"
+	idMerchantCategoryCode       = "52"
+	idTransactionCurrency        = "53"
+	idTransactionAmount          = "54"
+	idCountryCode                = "58"
+	idMerchantName               = "59"
+	idMerchantCity               = "60"
+	idPostalCode                 = "61"
+	idAdditionalDataField        = "62"
+	idCRC16                      = "63"
+	idMerchantAccountGUI         = "00"
+	idMerchantAccountKey         = "01"
+	idMerchantAccountDescription = "02"
+	idMerchantAccountURL         = "25"
+	idReferenceLabel             = "05"
+)
+
+// Valores fixos do BR Code
+const (
+	payloadFormatIndicator = "01"
+	singleUseInitiation    = "12"
+	currencyBRL            = "986"
+	countryBR              = "BR"
+	defaultMCC             = "0000"
+	noTxID                 = "***"
+	crcPrefix              = idCRC16 + "04"
+)
+
+// Limites de tamanho definidos pelo manual do BR Code
+const (
+	maxFieldLength        = 99
+	maxMerchantNameLength = 25
+	maxMerchantCityLength = 15
+	maxTxIDLength         = 25
+)
+
+var (
+	// ErrInvalidCRC indica que o CRC do BR Code não confere com o conteúdo
+	ErrInvalidCRC = errors.New("CRC do BR Code inválido")
+	// ErrInvalidFormat indica um BR Code que não segue o formato EMV
+	ErrInvalidFormat = errors.New("formato de BR Code inválido")
+
+	txIDPattern = regexp.MustCompile(`^[A-Za-z0-9]{1,25}$`)
+)
+
+// Payload representa os dados de um BR Code PIX
+type Payload struct {
+	PixKey       string // Chave PIX (QR Code estático)
+	URL          string // Location do payload (QR Code dinâmico), sem o esquema https://
+	Description  string // Informação adicional ao pagador
+	Amount       int64  // Centavos (0 para valor livre)
+	MerchantName string
+	MerchantCity string
+	PostalCode   string
+	TxID         string // Identificador da cobrança (vazio = "***")
+	SingleUse    bool   // QR Code de uso único (ponto de iniciação 12)
+	MCC          string // Merchant Category Code (vazio = "0000")
+}
+
+// IsDynamic indica se o BR Code aponta para um payload dinâmico (cob/cobv)
+func (p *Payload) IsDynamic() bool {
+	return p.URL != ""
+}
+
+// Encode gera o BR Code ("copia e cola"). Nome e cidade são normalizados para
+// caracteres sem acento e truncados aos limites do EMV.
+func Encode(p *Payload) (string, error) {
+	if (p.PixKey == "") == (p.URL == "") {
+		return "", errors.New("informe a chave PIX ou a URL do payload dinâmico")
+	}
+	if p.Amount < 0 {
+		return "", errors.New("valor não pode ser negativo")
+	}
+
+	name := normalize(p.MerchantName, maxMerchantNameLength)
+	if name == "" {
+		return "", errors.New("nome do recebedor é obrigatório")
+	}
+	city := normalize(p.MerchantCity, maxMerchantCityLength)
+	if city == "" {
+		return "", errors.New("cidade do recebedor é obrigatória")
+	}
+
+	txID := p.TxID
+	if txID == "" {
+		txID = noTxID
+	}
+	if txID != noTxID && !txIDPattern.MatchString(txID) {
+		return "", fmt.Errorf("txid deve ter de 1 a %d caracteres alfanuméricos", maxTxIDLength)
+	}
+
+	mcc := p.MCC
+	if mcc == "" {
+		mcc = defaultMCC
+	}
+
+	account := []string{field(idMerchantAccountGUI, GUI)}
+	if p.PixKey != "" {
+		account = append(account, field(idMerchantAccountKey, p.PixKey))
+	}
+	if p.Description != "" {
+		account = append(account, field(idMerchantAccountDescription, p.Description))
+	}
+	if p.URL != "" {
+		account = append(account, field(idMerchantAccountURL, strings.TrimPrefix(p.URL, "https://")))
+	}
+	accountInfo := strings.Join(account, "")
+	if len(accountInfo) > maxFieldLength {
+		return "", errors.New("chave PIX, URL e descrição excedem o tamanho do campo de conta")
+	}
+
+	var b strings.Builder
+	b.WriteString(field(idPayloadFormatIndicator, payloadFormatIndicator))
+	if p.SingleUse {
+		b.WriteString(field(idPointOfInitiationMethod, singleUseInitiation))
+	}
+	b.WriteString(field(idMerchantAccountInfo, accountInfo))
+	b.WriteString(field(idMerchantCategoryCode, mcc))
+	b.WriteString(field(idTransactionCurrency, currencyBRL))
+	if p.Amount > 0 {
+		b.WriteString(field(idTransactionAmount, formatAmount(p.Amount)))
+	}
+	b.WriteString(field(idCountryCode, countryBR))
+	b.WriteString(field(idMerchantName, name))
+	b.WriteString(field(idMerchantCity, city))
+	if p.PostalCode != "" {
+		b.WriteString(field(idPostalCode, p.PostalCode))
+	}
+	b.WriteString(field(idAdditionalDataField, field(idReferenceLabel, txID)))
+	b.WriteString(crcPrefix)
+
+	code := b.String()
+	return code + fmt.Sprintf("%04X", CRC16(code)), nil
+}
+
+// Decode interpreta um BR Code, validando o CRC antes de ler os campos
+func Decode(code string) (*Payload, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < len(crcPrefix)+4 {
+		return nil, ErrInvalidFormat
+	}
+
+	body := code[:len(code)-4]
+	if !strings.HasSuffix(body, crcPrefix) {
+		return nil, ErrInvalidFormat
+	}
+	crc, err := strconv.ParseUint(code[len(code)-4:], 16, 16)
+	if err != nil || uint16(crc) != CRC16(body) {
+		return nil, ErrInvalidCRC
+	}
+
+	fields, err := parseFields(body[:len(body)-len(crcPrefix)])
+	if err != nil {
+		return nil, err
+	}
+	if fields[idPayloadFormatIndicator] != payloadFormatIndicator {
+		return nil, ErrInvalidFormat
+	}
+
+	p := &Payload{
+		MerchantName: fields[idMerchantName],
+		MerchantCity: fields[idMerchantCity],
+		PostalCode:   fields[idPostalCode],
+		SingleUse:    fields[idPointOfInitiationMethod] == singleUseInitiation,
+		MCC:          fields[idMerchantCategoryCode],
+	}
+
+	// O PIX pode ocupar qualquer um dos templates de conta (26 a 51)
+	found := false
+	for id := 26; id <= 51 && !found; id++ {
+		value, ok := fields[strconv.Itoa(id)]
+		if !ok {
+			continue
+		}
+		account, err := parseFields(value)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(account[idMerchantAccountGUI], GUI) {
+			continue
+		}
+		found = true
+		p.PixKey = account[idMerchantAccountKey]
+		p.Description = account[idMerchantAccountDescription]
+		p.URL = account[idMerchantAccountURL]
+	}
+	if !found || (p.PixKey == "" && p.URL == "") {
+		return nil, fmt.Errorf("%w: conta PIX não encontrada", ErrInvalidFormat)
+	}
+
+	if value, ok := fields[idTransactionAmount]; ok {
+		p.Amount, err = parseAmount(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := fields[idAdditionalDataField]; ok {
+		additional, err := parseFields(value)
+		if err != nil {
+			return nil, err
+		}
+		if txID := additional[idReferenceLabel]; txID != noTxID {
+			p.TxID = txID
+		}
+	}
+
+	return p, nil
+}
+
+// CRC16 calcula o CRC16-CCITT (polinômio 0x1021, valor inicial 0xFFFF) exigido pelo BR Code
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// field codifica um campo EMV no formato ID + tamanho + valor
+func field(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// parseFields lê uma sequência de campos EMV
+func parseFields(data string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, ErrInvalidFormat
+		}
+		length, err := strconv.Atoi(data[2:4])
+		if err != nil || length > len(data)-4 {
+			return nil, ErrInvalidFormat
+		}
+		fields[data[:2]] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+	return fields, nil
+}
+
+// formatAmount formata centavos como decimal com duas casas ("10.50")
+func formatAmount(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// parseAmount converte o valor decimal do BR Code para centavos
+func parseAmount(value string) (int64, error) {
+	units, decimals, hasDecimals := strings.Cut(value, ".")
+	if units == "" || len(decimals) > 2 || (hasDecimals && decimals == "") {
+		return 0, fmt.Errorf("%w: valor %q", ErrInvalidFormat, value)
+	}
+	for len(decimals) < 2 {
+		decimals += "0"
+	}
+
+	cents, err := strconv.ParseUint(units+decimals, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("%w: valor %q", ErrInvalidFormat, value)
+	}
+	return int64(cents), nil
+}
+
+// normalize remove acentos e caracteres não ASCII e limita o tamanho do texto
+func normalize(value string, maxLength int) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.TrimSpace(value)) {
+		if unicode.Is(unicode.Mn, r) || r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	result := b.String()
+	if len(result) > maxLength {
+		result = strings.TrimSpace(result[:maxLength])
+	}
+	return result
+}
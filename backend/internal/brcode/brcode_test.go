@@ -0,0 +1,185 @@
+package brcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Exemplo de QR Code estático do manual do BR Code (BACEN)
+const manualExample = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestEncodeMatchesManualExample(t *testing.T) {
+	code, err := Encode(&Payload{
+		PixKey:       "123e4567-e12b-12d1-a456-426655440000",
+		MerchantName: "Fulano de Tal",
+		MerchantCity: "BRASILIA",
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if code != manualExample {
+		t.Errorf("Encode() = %s\nwant       %s", code, manualExample)
+	}
+}
+
+func TestDecodeManualExample(t *testing.T) {
+	p, err := Decode(manualExample)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if p.PixKey != "123e4567-e12b-12d1-a456-426655440000" || p.MerchantName != "Fulano de Tal" || p.MerchantCity != "BRASILIA" {
+		t.Errorf("payload = %+v", p)
+	}
+	if p.Amount != 0 || p.TxID != "" || p.IsDynamic() {
+		t.Errorf("payload = %+v, want open amount without txid", p)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload Payload
+	}{
+		{"static with amount", Payload{
+			PixKey:       "pagamentos@loja.com.br",
+			Description:  "Pedido 42",
+			Amount:       12345,
+			MerchantName: "Loja Exemplo",
+			MerchantCity: "SAO PAULO",
+			PostalCode:   "01310100",
+			TxID:         "PEDIDO42",
+		}},
+		{"dynamic", Payload{
+			URL:          "pix.example.com/qr/v2/9d36b84fc70b478fb95c12729b90ca25",
+			Amount:       100,
+			MerchantName: "Loja Exemplo",
+			MerchantCity: "CURITIBA",
+			SingleUse:    true,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(&tt.payload)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			got, err := Decode(code)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			want := tt.payload
+			want.MCC = defaultMCC
+			if *got != want {
+				t.Errorf("Decode() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeNormalizesNameAndCity(t *testing.T) {
+	code, err := Encode(&Payload{
+		PixKey:       "12345678000199",
+		MerchantName: "Padaria São João da Esquina Ltda",
+		MerchantCity: "São José dos Campos",
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	p, err := Decode(code)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if p.MerchantName != "Padaria Sao Joao da Esqui" || p.MerchantCity != "Sao Jose dos Ca" {
+		t.Errorf("name = %q, city = %q", p.MerchantName, p.MerchantCity)
+	}
+}
+
+func TestEncodeRejectsInvalidPayload(t *testing.T) {
+	valid := func() Payload {
+		return Payload{PixKey: "chave", MerchantName: "Loja", MerchantCity: "Recife"}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *Payload)
+	}{
+		{"no key or url", func(p *Payload) { p.PixKey = "" }},
+		{"key and url", func(p *Payload) { p.URL = "pix.example.com/qr/1" }},
+		{"negative amount", func(p *Payload) { p.Amount = -1 }},
+		{"missing name", func(p *Payload) { p.MerchantName = "" }},
+		{"missing city", func(p *Payload) { p.MerchantCity = " " }},
+		{"invalid txid", func(p *Payload) { p.TxID = "pedido-42" }},
+		{"txid too long", func(p *Payload) { p.TxID = strings.Repeat("a", maxTxIDLength+1) }},
+		{"account too long", func(p *Payload) { p.Description = strings.Repeat("x", maxFieldLength) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.modify(&p)
+			if _, err := Encode(&p); err == nil {
+				t.Error("Encode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsInvalidCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{"wrong crc", manualExample[:len(manualExample)-4] + "0000", ErrInvalidCRC},
+		{"tampered", strings.Replace(manualExample, "Fulano", "Ciclano", 1), ErrInvalidCRC},
+		{"too short", "6304", ErrInvalidFormat},
+		{"missing crc field", "000201", ErrInvalidFormat},
+		{"not pix", withCRC("000201260800040abc5802BR"), ErrInvalidFormat},
+		{"truncated field", withCRC("0002012699"), ErrInvalidFormat},
+		{"invalid amount", withCRC("00020126180014br.gov.bcb.pix5404ab.c"), ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.code)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"10.50", 1050, false},
+		{"0.01", 1, false},
+		{"7", 700, false},
+		{"7.5", 750, false},
+		{"7.", 0, true},
+		{".50", 0, true},
+		{"1.001", 0, true},
+		{"-1.00", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAmount(%q) = %d, %v; want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func withCRC(body string) string {
+	body += crcPrefix
+	return fmt.Sprintf("%s%04X", body, CRC16(body))
+}
@@ -0,0 +1,318 @@
+package brcode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pixsaas/backend/internal/netguard"
+)
+
+// Erros da verificação do payload de QR Code dinâmico
+var (
+	// ErrInvalidSignature indica um payload JWS adulterado ou assinado por chave não publicada pelo PSP
+	ErrInvalidSignature = errors.New("assinatura do payload do QR Code inválida")
+	// ErrPayloadExpired indica uma cobrança vencida
+	ErrPayloadExpired = errors.New("cobrança do QR Code expirada")
+	// ErrPayloadInactive indica uma cobrança já paga ou removida
+	ErrPayloadInactive = errors.New("cobrança do QR Code não está ativa")
+	// ErrPayloadUnavailable indica falha ao buscar o payload no PSP do recebedor
+	ErrPayloadUnavailable = errors.New("payload do QR Code indisponível")
+)
+
+// Algoritmos aceitos na assinatura do payload (manual de padrões do PIX)
+var payloadSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+const (
+	defaultFetchTimeout = 10 * time.Second
+	maxPayloadSize      = 64 * 1024
+	payloadStatusActive = "ATIVA"
+)
+
+// DynamicPayload representa o payload JWS de um QR Code dinâmico (cob/cobv)
+type DynamicPayload struct {
+	Revision    int    `json:"revisao"`
+	TxID        string `json:"txid"`
+	Status      string `json:"status"`
+	PixKey      string `json:"chave"`
+	Description string `json:"solicitacaoPagador"`
+
+	Calendar struct {
+		CreatedAt     time.Time `json:"criacao"`
+		PresentedAt   time.Time `json:"apresentacao"`
+		ExpiresIn     int       `json:"expiracao"`              // Segundos (cob)
+		DueDate       string    `json:"dataDeVencimento"`       // AAAA-MM-DD (cobv)
+		ValidAfterDue int       `json:"validadeAposVencimento"` // Dias (cobv)
+	} `json:"calendario"`
+
+	Value struct {
+		Original   string `json:"original"`
+		Final      string `json:"final"`               // Valor com juros, multa e descontos (cobv)
+		ChangeMode int    `json:"modalidadeAlteracao"` // 1 = pagador pode alterar o valor
+	} `json:"valor"`
+
+	Payee struct {
+		Name string `json:"nome"`
+		CNPJ string `json:"cnpj"`
+		CPF  string `json:"cpf"`
+	} `json:"recebedor"`
+}
+
+// Amount retorna o valor a pagar em centavos (valor final na cobv)
+func (p *DynamicPayload) Amount() (int64, error) {
+	if p.Value.Final != "" {
+		return parseAmount(p.Value.Final)
+	}
+	return parseAmount(p.Value.Original)
+}
+
+// AllowChange indica se o pagador pode alterar o valor
+func (p *DynamicPayload) AllowChange() bool {
+	return p.Value.ChangeMode == 1
+}
+
+// PayeeDocument retorna o CNPJ ou CPF do recebedor, quando informado
+func (p *DynamicPayload) PayeeDocument() string {
+	if p.Payee.CNPJ != "" {
+		return p.Payee.CNPJ
+	}
+	return p.Payee.CPF
+}
+
+// expired indica se a cobrança não pode mais ser paga no instante informado
+func (p *DynamicPayload) expired(now time.Time) (bool, error) {
+	if p.Calendar.DueDate != "" {
+		due, err := time.ParseInLocation("2006-01-02", p.Calendar.DueDate, brasilia)
+		if err != nil {
+			return false, fmt.Errorf("%w: data de vencimento %q", ErrInvalidFormat, p.Calendar.DueDate)
+		}
+		// Pode ser paga até o fim do último dia de validade após o vencimento
+		limit := due.AddDate(0, 0, p.Calendar.ValidAfterDue+1)
+		return !now.Before(limit), nil
+	}
+
+	if p.Calendar.ExpiresIn > 0 && !p.Calendar.CreatedAt.IsZero() {
+		return !now.Before(p.Calendar.CreatedAt.Add(time.Duration(p.Calendar.ExpiresIn) * time.Second)), nil
+	}
+	return false, nil
+}
+
+// brasilia é o fuso das datas de vencimento das cobranças
+var brasilia = time.FixedZone("BRT", -3*60*60)
+
+// PayloadFetcher busca e verifica o payload JWS de QR Codes dinâmicos
+type PayloadFetcher struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewPayloadFetcher cria o verificador de payloads. Com client nil, usa um cliente
+// com timeout que não segue redirecionamentos e só conecta a endereços públicos:
+// a URL vem do BR Code colado pelo merchant e pode apontar para a rede interna.
+func NewPayloadFetcher(client *http.Client) *PayloadFetcher {
+	if client == nil {
+		client = &http.Client{
+			Transport: netguard.NewTransport(),
+			Timeout:   defaultFetchTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &PayloadFetcher{client: client, now: time.Now}
+}
+
+// Fetch baixa o payload da URL do BR Code, verifica a assinatura com a chave publicada
+// pelo PSP (jku) e rejeita cobranças expiradas ou que não estejam ativas.
+func (f *PayloadFetcher) Fetch(ctx context.Context, location string) (*DynamicPayload, error) {
+	payloadURL, err := payloadLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := f.get(ctx, payloadURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(strings.TrimSpace(string(body)), func(token *jwt.Token) (interface{}, error) {
+		return f.signingKey(ctx, payloadURL, token)
+	}, jwt.WithValidMethods(payloadSigningMethods), jwt.WithoutClaimsValidation())
+	if err != nil {
+		if errors.Is(err, ErrPayloadUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	claims, err := json.Marshal(token.Claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	var payload DynamicPayload
+	if err := json.Unmarshal(claims, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	if payload.Status != payloadStatusActive {
+		return nil, fmt.Errorf("%w: status %s", ErrPayloadInactive, payload.Status)
+	}
+	expired, err := payload.expired(f.now())
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrPayloadExpired
+	}
+
+	return &payload, nil
+}
+
+// signingKey busca no JWKS indicado pelo header jku a chave pública que assinou o payload.
+// O jku precisa estar no mesmo host do payload, senão qualquer um assinaria cobranças do PSP.
+func (f *PayloadFetcher) signingKey(ctx context.Context, payloadURL *url.URL, token *jwt.Token) (interface{}, error) {
+	jku, _ := token.Header["jku"].(string)
+	if jku == "" {
+		return nil, errors.New("header jku ausente")
+	}
+	if !strings.Contains(jku, "://") {
+		jku = "https://" + jku
+	}
+	jwksURL, err := url.Parse(jku)
+	if err != nil || jwksURL.Scheme != "https" || !strings.EqualFold(jwksURL.Host, payloadURL.Host) {
+		return nil, fmt.Errorf("jku %q fora do domínio do payload", jku)
+	}
+
+	body, err := f.get(ctx, jwksURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, fmt.Errorf("JWKS inválido: %w", err)
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range jwks.Keys {
+		if kid != "" && key.Kid != kid {
+			continue
+		}
+		if kid == "" && len(jwks.Keys) > 1 {
+			return nil, errors.New("header kid ausente com mais de uma chave publicada")
+		}
+		return key.publicKey()
+	}
+	return nil, fmt.Errorf("chave %q não publicada no JWKS", kid)
+}
+
+// get faz a requisição HTTPS com limite de tamanho da resposta
+func (f *PayloadFetcher) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadUnavailable, err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d em %s", ErrPayloadUnavailable, resp.StatusCode, target)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPayloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPayloadUnavailable, err)
+	}
+	if len(body) > maxPayloadSize {
+		return nil, fmt.Errorf("%w: resposta maior que %d bytes", ErrPayloadUnavailable, maxPayloadSize)
+	}
+	return body, nil
+}
+
+// payloadLocation valida a URL do BR Code: somente HTTPS e nunca um IP literal.
+// Hosts que resolvem para a rede interna são barrados na conexão (netguard).
+func payloadLocation(location string) (*url.URL, error) {
+	if !strings.Contains(location, "://") {
+		location = "https://" + location
+	}
+
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return nil, fmt.Errorf("%w: URL do payload %q", ErrInvalidFormat, location)
+	}
+	if net.ParseIP(u.Hostname()) != nil || strings.EqualFold(u.Hostname(), "localhost") {
+		return nil, fmt.Errorf("%w: host do payload %q", ErrInvalidFormat, u.Hostname())
+	}
+	return u, nil
+}
+
+// jsonWebKey representa uma chave pública do JWKS do PSP (RSA ou EC)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converte a JWK em chave pública
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWK RSA inválida: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("JWK RSA inválida: expoente")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curva %q não suportada", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("JWK EC inválida")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("JWK EC inválida: ponto fora da curva")
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("tipo de chave %q não suportado", k.Kty)
+}
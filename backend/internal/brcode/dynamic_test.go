@@ -0,0 +1,249 @@
+package brcode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pixsaas/backend/internal/netguard"
+)
+
+func TestFetchVerifiesSignedPayload(t *testing.T) {
+	psp := newTestPSP(t)
+	psp.payload = psp.sign(t, psp.key, "k1", activeCob(psp.now))
+
+	payload, err := psp.fetcher().Fetch(context.Background(), "example.com/qr/v2/cob1")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	amount, err := payload.Amount()
+	if err != nil || amount != 15000 {
+		t.Errorf("Amount() = %d, %v; want 15000", amount, err)
+	}
+	if payload.TxID != "cob1txid000000000000000000" || payload.PixKey != "recebedor@psp.com.br" || payload.AllowChange() {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestFetchRejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, psp *testPSP)
+		want   error
+	}{
+		{"tampered", func(t *testing.T, psp *testPSP) {
+			parts := strings.Split(psp.sign(t, psp.key, "k1", activeCob(psp.now)), ".")
+			claims := activeCob(psp.now)
+			claims["valor"] = map[string]interface{}{"original": "1.00"}
+			body, _ := json.Marshal(claims)
+			parts[1] = base64.RawURLEncoding.EncodeToString(body)
+			psp.payload = strings.Join(parts, ".")
+		}, ErrInvalidSignature},
+		{"unknown key", func(t *testing.T, psp *testPSP) {
+			other, _ := rsa.GenerateKey(rand.Reader, 2048)
+			psp.payload = psp.sign(t, other, "k1", activeCob(psp.now))
+		}, ErrInvalidSignature},
+		{"foreign jku", func(t *testing.T, psp *testPSP) {
+			psp.jku = "https://attacker.example.org/jwks"
+			psp.payload = psp.sign(t, psp.key, "k1", activeCob(psp.now))
+		}, ErrInvalidSignature},
+		{"expired cob", func(t *testing.T, psp *testPSP) {
+			claims := activeCob(psp.now)
+			claims["calendario"] = map[string]interface{}{"criacao": psp.now.Add(-2 * time.Hour).Format(time.RFC3339), "expiracao": 3600}
+			psp.payload = psp.sign(t, psp.key, "k1", claims)
+		}, ErrPayloadExpired},
+		{"expired cobv", func(t *testing.T, psp *testPSP) {
+			claims := activeCob(psp.now)
+			claims["calendario"] = map[string]interface{}{"dataDeVencimento": psp.now.AddDate(0, 0, -3).Format("2006-01-02"), "validadeAposVencimento": 1}
+			psp.payload = psp.sign(t, psp.key, "k1", claims)
+		}, ErrPayloadExpired},
+		{"already paid", func(t *testing.T, psp *testPSP) {
+			claims := activeCob(psp.now)
+			claims["status"] = "CONCLUIDA"
+			psp.payload = psp.sign(t, psp.key, "k1", claims)
+		}, ErrPayloadInactive},
+		{"payload not found", func(t *testing.T, psp *testPSP) {
+			psp.payload = ""
+		}, ErrPayloadUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			psp := newTestPSP(t)
+			tt.modify(t, psp)
+
+			_, err := psp.fetcher().Fetch(context.Background(), "example.com/qr/v2/cob1")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Fetch() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchAcceptsECKey(t *testing.T) {
+	psp := newTestPSP(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	psp.jwks = map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+		"kty": "EC",
+		"kid": "ec1",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+	}}}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims(activeCob(psp.now)))
+	token.Header["jku"] = psp.jku
+	token.Header["kid"] = "ec1"
+	psp.payload, err = token.SignedString(ecKey)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	if _, err := psp.fetcher().Fetch(context.Background(), "example.com/qr/v2/cob1"); err != nil {
+		t.Errorf("Fetch() error = %v", err)
+	}
+}
+
+func TestPayloadLocation(t *testing.T) {
+	for _, location := range []string{"pix.example.com/qr/v2/1", "https://pix.example.com/qr/v2/1"} {
+		if _, err := payloadLocation(location); err != nil {
+			t.Errorf("payloadLocation(%q) error = %v", location, err)
+		}
+	}
+	for _, location := range []string{"http://pix.example.com/qr", "127.0.0.1/qr", "localhost/qr", "https://user@pix.example.com/qr", "https:///qr"} {
+		if _, err := payloadLocation(location); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("payloadLocation(%q) error = %v, want ErrInvalidFormat", location, err)
+		}
+	}
+}
+
+func TestFetchRefusesHostResolvingToPrivateAddress(t *testing.T) {
+	psp := newTestPSP(t)
+	psp.payload = psp.sign(t, psp.key, "k1", activeCob(psp.now))
+
+	// Simula um DNS que resolve example.com para o servidor de teste em 127.0.0.1,
+	// mantendo o dialer protegido do cliente padrão
+	f := NewPayloadFetcher(nil)
+	transport := f.client.Transport.(*http.Transport)
+	dial := transport.DialContext
+	addr := psp.server.Listener.Addr().String()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dial(ctx, network, addr)
+	}
+
+	_, err := f.Fetch(context.Background(), "example.com/qr/v2/cob1")
+	if !errors.Is(err, ErrPayloadUnavailable) || !strings.Contains(err.Error(), netguard.ErrNonPublicAddress.Error()) {
+		t.Errorf("Fetch() error = %v, want ErrPayloadUnavailable for non-public address", err)
+	}
+}
+
+// testPSP simula o PSP do recebedor, publicando o payload JWS e o JWKS em example.com
+type testPSP struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	jku     string
+	jwks    map[string]interface{}
+	payload string
+	now     time.Time
+}
+
+func newTestPSP(t *testing.T) *testPSP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	psp := &testPSP{
+		key: key,
+		jku: "https://example.com/jwks",
+		jwks: map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}},
+		now: time.Now(),
+	}
+
+	psp.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jwks":
+			_ = json.NewEncoder(w).Encode(psp.jwks)
+		case "/qr/v2/cob1":
+			if psp.payload == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/jose")
+			_, _ = w.Write([]byte(psp.payload))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(psp.server.Close)
+
+	return psp
+}
+
+// fetcher retorna um verificador que resolve qualquer host para o servidor de teste
+func (p *testPSP) fetcher() *PayloadFetcher {
+	transport := p.server.Client().Transport.(*http.Transport).Clone()
+	addr := p.server.Listener.Addr().String()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	transport.DialTLSContext = nil
+	transport.TLSClientConfig = &tls.Config{RootCAs: transport.TLSClientConfig.RootCAs, MinVersion: tls.VersionTLS12}
+
+	f := NewPayloadFetcher(&http.Client{Transport: transport, Timeout: 5 * time.Second})
+	f.now = func() time.Time { return p.now }
+	return f
+}
+
+func (p *testPSP) sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodPS256, jwt.MapClaims(claims))
+	token.Header["jku"] = p.jku
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return signed
+}
+
+func activeCob(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"revisao": 0,
+		"txid":    "cob1txid000000000000000000",
+		"status":  "ATIVA",
+		"chave":   "recebedor@psp.com.br",
+		"calendario": map[string]interface{}{
+			"criacao":      now.Add(-time.Minute).Format(time.RFC3339),
+			"apresentacao": now.Format(time.RFC3339),
+			"expiracao":    3600,
+		},
+		"valor": map[string]interface{}{"original": "150.00"},
+	}
+}
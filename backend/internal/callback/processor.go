@@ -0,0 +1,251 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+	"gorm.io/gorm"
+)
+
+// TransactionStore busca e atualiza as transações notificadas pelos bancos
+type TransactionStore interface {
+	GetByProviderTxID(ctx context.Context, providerID uuid.UUID, providerTxID string) (*domain.Transaction, error)
+	GetByE2EID(ctx context.Context, e2eID string) (*domain.Transaction, error)
+	UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error)
+}
+
+// Auditor registra eventos de auditoria de transações
+type Auditor interface {
+	LogTransaction(ctx context.Context, merchantID, userID, transactionID uuid.UUID, action string, metadata map[string]interface{}) error
+}
+
+// Notifier notifica o merchant sobre mudanças de status (webhooks)
+type Notifier interface {
+	Enqueue(ctx context.Context, tx *domain.Transaction) error
+}
+
+// chargeTypes são os tipos de transação liquidados por PIX recebido
+var chargeTypes = map[domain.TransactionType]bool{
+	domain.TransactionTypeQRCodeStatic:  true,
+	domain.TransactionTypeQRCodeDynamic: true,
+	domain.TransactionTypeQRCodeDueDate: true,
+}
+
+// Result resume o processamento de um callback
+type Result struct {
+	Received  int `json:"received"`
+	Updated   int `json:"updated"`
+	Duplicate int `json:"duplicate"`
+	Ignored   int `json:"ignored"`
+	Unmatched int `json:"unmatched"`
+}
+
+// Processor aplica às transações os PIX recebidos informados pelos bancos
+type Processor struct {
+	transactions TransactionStore
+	auditor      Auditor
+	notifier     Notifier
+	now          func() time.Time
+}
+
+// NewProcessor cria o processador de callbacks
+func NewProcessor(transactions TransactionStore, auditor Auditor, notifier Notifier) *Processor {
+	return &Processor{
+		transactions: transactions,
+		auditor:      auditor,
+		notifier:     notifier,
+		now:          time.Now,
+	}
+}
+
+// Process conclui as transações pagas. Notificações repetidas não alteram a transação,
+// então o banco pode reenviar o mesmo callback com segurança.
+func (p *Processor) Process(ctx context.Context, provider *domain.Provider, notifications []providers.CallbackNotification) (*Result, error) {
+	result := &Result{Received: len(notifications)}
+
+	for i := range notifications {
+		notification := &notifications[i]
+
+		tx, err := p.match(ctx, provider, notification)
+		if err != nil {
+			return result, err
+		}
+		if tx == nil {
+			log.Printf("Aviso: callback %s sem transação correspondente (txid=%s, e2e_id=%s)", provider.Code, notification.TxID, notification.E2EID)
+			result.Unmatched++
+			continue
+		}
+
+		if !chargeTypes[tx.Type] {
+			// Transferências enviadas não são liquidadas por callback de recebimento
+			log.Printf("Aviso: callback %s para transação %s do tipo %s", provider.Code, tx.ID, tx.Type)
+			_ = p.auditor.LogTransaction(ctx, tx.MerchantID, uuid.Nil, tx.ID, "callback_ignored", map[string]interface{}{
+				"type":     tx.Type,
+				"provider": provider.Code,
+				"e2e_id":   notification.E2EID,
+				"amount":   notification.Amount,
+			})
+			result.Ignored++
+			continue
+		}
+
+		switch tx.Status {
+		case domain.TransactionStatusCompleted, domain.TransactionStatusRefunded, domain.TransactionStatusManualReview:
+			// Reenvio do pagamento já aplicado
+			if notification.E2EID == "" || notification.E2EID == tx.E2EID {
+				result.Duplicate++
+				continue
+			}
+			// Outro pagamento (ex.: QR Code estático reutilizado): não altera a transação, mas fica registrado
+			p.ignore(ctx, provider, tx, notification)
+			result.Ignored++
+			continue
+		case domain.TransactionStatusFailed, domain.TransactionStatusCancelled:
+			// Pagamento de uma cobrança já encerrada: não reabre a transação, mas fica registrado
+			p.ignore(ctx, provider, tx, notification)
+			result.Ignored++
+			continue
+		}
+
+		updated, err := p.complete(ctx, provider, tx, notification)
+		if err != nil {
+			return result, err
+		}
+		if updated {
+			result.Updated++
+		} else {
+			result.Duplicate++
+		}
+	}
+
+	return result, nil
+}
+
+// ignore registra um pagamento que não pode ser aplicado à transação no estado atual
+func (p *Processor) ignore(ctx context.Context, provider *domain.Provider, tx *domain.Transaction, notification *providers.CallbackNotification) {
+	log.Printf("Aviso: callback %s para transação %s com status %s (e2e_id=%s)", provider.Code, tx.ID, tx.Status, notification.E2EID)
+	_ = p.auditor.LogTransaction(ctx, tx.MerchantID, uuid.Nil, tx.ID, "callback_ignored", map[string]interface{}{
+		"status":   tx.Status,
+		"provider": provider.Code,
+		"e2e_id":   notification.E2EID,
+		"amount":   notification.Amount,
+	})
+}
+
+// match busca a transação pelo txid e, na falta dele, pelo E2EID
+func (p *Processor) match(ctx context.Context, provider *domain.Provider, notification *providers.CallbackNotification) (*domain.Transaction, error) {
+	if notification.TxID != "" {
+		tx, err := p.transactions.GetByProviderTxID(ctx, provider.ID, notification.TxID)
+		if err == nil {
+			return tx, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if notification.E2EID != "" {
+		tx, err := p.transactions.GetByE2EID(ctx, notification.E2EID)
+		if err == nil {
+			// O E2EID é global: um banco só pode notificar transações processadas por ele
+			if tx.ProviderID != provider.ID {
+				return nil, nil
+			}
+			return tx, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// complete marca a transação como concluída; retorna false se outra atualização chegou antes.
+// Pagamento fora da validade ou com valor diferente do devido vai para revisão manual.
+func (p *Processor) complete(ctx context.Context, provider *domain.Provider, tx *domain.Transaction, notification *providers.CallbackNotification) (bool, error) {
+	previous := tx.Status
+	now := p.now()
+
+	paidAt := now
+	if notification.PaidAt != nil {
+		paidAt = *notification.PaidAt
+	}
+	expectedAmount := tx.Amount
+	var dueDateErr error
+	if tx.DueDate != nil {
+		expectedAmount, dueDateErr = tx.DueDate.AmountDue(tx.Amount, paidAt)
+	}
+
+	// Cobrança estática sem valor aceita qualquer quantia
+	openAmount := tx.Amount == 0 && tx.DueDate == nil
+	amountMismatch := dueDateErr == nil && !openAmount && notification.Amount != expectedAmount
+
+	tx.NextStatusCheckAt = nil
+	switch {
+	case dueDateErr != nil:
+		tx.Status = domain.TransactionStatusManualReview
+		tx.ErrorCode = "INVALID_DUE_DATE_TERMS"
+		if errors.Is(dueDateErr, domain.ErrChargeOverdue) {
+			tx.ErrorCode = "CHARGE_OVERDUE"
+		}
+		tx.ErrorMessage = dueDateErr.Error()
+	case amountMismatch:
+		tx.Status = domain.TransactionStatusManualReview
+		tx.ErrorCode = "AMOUNT_MISMATCH"
+		tx.ErrorMessage = "valor pago diferente do valor devido"
+	default:
+		tx.Status = domain.TransactionStatusCompleted
+		tx.CompletedAt = &paidAt
+	}
+	paidAmount := notification.Amount
+	tx.PaidAmount = &paidAmount
+	if notification.E2EID != "" {
+		tx.E2EID = notification.E2EID
+	}
+	if tx.PayerName == "" {
+		tx.PayerName = notification.PayerName
+	}
+	if tx.PayerDocument == "" {
+		tx.PayerDocument = notification.PayerDocument
+	}
+	tx.UpdatedAt = now
+
+	updated, err := p.transactions.UpdateIfStatus(ctx, tx, previous)
+	if err != nil || !updated {
+		return false, err
+	}
+
+	metadata := map[string]interface{}{
+		"from":     previous,
+		"to":       tx.Status,
+		"provider": provider.Code,
+		"source":   "callback",
+		"e2e_id":   tx.E2EID,
+	}
+	if dueDateErr != nil {
+		metadata["reason"] = dueDateErr.Error()
+		metadata["paid_at"] = paidAt
+	}
+	if amountMismatch {
+		metadata["reason"] = tx.ErrorMessage
+	}
+	if notification.Amount != expectedAmount {
+		metadata["paid_amount"] = notification.Amount
+	}
+	if expectedAmount != tx.Amount {
+		metadata["amount_due"] = expectedAmount
+	}
+	_ = p.auditor.LogTransaction(ctx, tx.MerchantID, uuid.Nil, tx.ID, "status_transition", metadata)
+
+	if err := p.notifier.Enqueue(ctx, tx); err != nil {
+		log.Printf("Aviso: falha ao enfileirar webhooks da transação %s: %v", tx.ID, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,380 @@
+package callback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+	"gorm.io/gorm"
+)
+
+func TestProcessCompletesChargeByTxID(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cob123", "", domain.TransactionStatusPending)
+	paidAt := time.Now().Add(-time.Minute)
+
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{
+		TxID:      "cob123",
+		E2EID:     "E12345678202401011200abcdefghijk",
+		Amount:    1000,
+		PayerName: "Maria",
+		PaidAt:    &paidAt,
+	}})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if result.Updated != 1 {
+		t.Errorf("result = %+v, want 1 updated", result)
+	}
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusCompleted || got.E2EID != "E12345678202401011200abcdefghijk" || got.PayerName != "Maria" {
+		t.Errorf("transaction = %+v", got)
+	}
+	if got.PaidAmount == nil || *got.PaidAmount != 1000 {
+		t.Errorf("PaidAmount = %v, want 1000", got.PaidAmount)
+	}
+	if got.CompletedAt == nil || !got.CompletedAt.Equal(paidAt) {
+		t.Errorf("CompletedAt = %v, want %v", got.CompletedAt, paidAt)
+	}
+	if len(env.auditor.entries) != 1 || env.auditor.entries[0]["source"] != "callback" {
+		t.Errorf("audit entries = %v", env.auditor.entries)
+	}
+	if len(env.notifier.statuses) != 1 || env.notifier.statuses[0] != domain.TransactionStatusCompleted {
+		t.Errorf("notified = %v, want [completed]", env.notifier.statuses)
+	}
+}
+
+func TestProcessMatchesByE2EID(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("", "E1", domain.TransactionStatusProcessing)
+
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{E2EID: "E1", Amount: 1000}})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if result.Updated != 1 || env.store.txs[tx.ID].Status != domain.TransactionStatusCompleted {
+		t.Errorf("result = %+v, status = %s", result, env.store.txs[tx.ID].Status)
+	}
+}
+
+func TestProcessDuplicateNotificationIsIdempotent(t *testing.T) {
+	env := newProcessorTestEnv()
+	env.addTransaction("cob123", "", domain.TransactionStatusPending)
+	notifications := []providers.CallbackNotification{{TxID: "cob123", E2EID: "E1", Amount: 1000}}
+
+	if _, err := env.processor.Process(context.Background(), env.provider, notifications); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	result, err := env.processor.Process(context.Background(), env.provider, notifications)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if result.Duplicate != 1 || result.Updated != 0 {
+		t.Errorf("result = %+v, want 1 duplicate", result)
+	}
+	if len(env.auditor.entries) != 1 || len(env.notifier.statuses) != 1 {
+		t.Errorf("audit entries = %d, notified = %d, want 1 each", len(env.auditor.entries), len(env.notifier.statuses))
+	}
+}
+
+func TestProcessRepeatedCallbackForReviewedCharge(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cob123", "", domain.TransactionStatusPending)
+
+	// Valor divergente: a cobrança vai para revisão manual
+	if _, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cob123", E2EID: "E1", Amount: 1}}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{
+		{TxID: "cob123", E2EID: "E1", Amount: 1},
+		{TxID: "cob123", E2EID: "E2", Amount: 1000},
+	})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if result.Duplicate != 1 || result.Ignored != 1 || result.Updated != 0 {
+		t.Errorf("result = %+v, want 1 duplicate and 1 ignored", result)
+	}
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusManualReview || got.E2EID != "E1" || *got.PaidAmount != 1 {
+		t.Errorf("transaction = %+v, want untouched review", got)
+	}
+	if len(env.auditor.entries) != 1 || len(env.notifier.statuses) != 1 {
+		t.Errorf("audit entries = %d, notified = %d, want 1 each", len(env.auditor.entries), len(env.notifier.statuses))
+	}
+	if len(env.auditor.ignored) != 1 || env.auditor.ignored[0]["e2e_id"] != "E2" || env.auditor.ignored[0]["amount"] != int64(1000) {
+		t.Errorf("ignored = %v, want E2 of 1000", env.auditor.ignored)
+	}
+}
+
+func TestProcessRecordsNewPaymentToCompletedStaticCharge(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cob123", "E1", domain.TransactionStatusCompleted)
+	tx.Type = domain.TransactionTypeQRCodeStatic
+
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{
+		{TxID: "cob123", E2EID: "E1", Amount: 1000},
+		{TxID: "cob123", E2EID: "E2", Amount: 1000},
+	})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if result.Duplicate != 1 || result.Ignored != 1 {
+		t.Errorf("result = %+v, want 1 duplicate and 1 ignored", result)
+	}
+	if got := env.store.txs[tx.ID]; got.E2EID != "E1" {
+		t.Errorf("E2EID = %s, want E1", got.E2EID)
+	}
+	if len(env.auditor.ignored) != 1 || env.auditor.ignored[0]["e2e_id"] != "E2" {
+		t.Errorf("ignored = %v, want payment E2 recorded", env.auditor.ignored)
+	}
+}
+
+func TestProcessUnmatchedAndForeignProvider(t *testing.T) {
+	env := newProcessorTestEnv()
+	foreign := env.addTransaction("", "E-foreign", domain.TransactionStatusPending)
+	foreign.ProviderID = uuid.New()
+
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{
+		{TxID: "unknown"},
+		{E2EID: "E-foreign"},
+	})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if result.Unmatched != 2 || result.Updated != 0 {
+		t.Errorf("result = %+v, want 2 unmatched", result)
+	}
+	if foreign.Status != domain.TransactionStatusPending {
+		t.Errorf("foreign transaction status = %s, want pending", foreign.Status)
+	}
+}
+
+func TestProcessIgnoresClosedTransaction(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cob123", "", domain.TransactionStatusCancelled)
+
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cob123", Amount: 1000}})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if result.Ignored != 1 || env.store.txs[tx.ID].Status != domain.TransactionStatusCancelled {
+		t.Errorf("result = %+v, status = %s", result, env.store.txs[tx.ID].Status)
+	}
+	if len(env.notifier.statuses) != 0 {
+		t.Errorf("notified = %v, want none", env.notifier.statuses)
+	}
+}
+
+func TestProcessDueDateCharge(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cobv1", "", domain.TransactionStatusPending)
+	tx.Type = domain.TransactionTypeQRCodeDueDate
+	tx.DueDate = &domain.DueDateTerms{
+		DueDate:       "2024-03-10",
+		ValidAfterDue: 5,
+		Fine:          &domain.ChargeModifier{Modality: domain.ModalityFixed, Value: 200},
+	}
+
+	paidAt := time.Date(2024, 3, 12, 10, 0, 0, 0, domain.ChargeLocation)
+	if _, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cobv1", Amount: 1200, PaidAt: &paidAt}}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if got := env.store.txs[tx.ID]; got.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Status = %s, want completed", got.Status)
+	}
+	entry := env.auditor.entries[0]
+	if entry["amount_due"] != int64(1200) || entry["paid_amount"] != nil {
+		t.Errorf("audit entry = %v, want amount_due 1200 without divergence", entry)
+	}
+}
+
+func TestProcessRejectsDueDateChargePaidAfterValidity(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cobv1", "", domain.TransactionStatusPending)
+	tx.Type = domain.TransactionTypeQRCodeDueDate
+	tx.DueDate = &domain.DueDateTerms{DueDate: "2024-03-10", ValidAfterDue: 1}
+
+	paidAt := time.Date(2024, 3, 12, 10, 0, 0, 0, domain.ChargeLocation)
+	if _, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cobv1", Amount: 1000, PaidAt: &paidAt}}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusManualReview || got.ErrorCode != "CHARGE_OVERDUE" || got.CompletedAt != nil {
+		t.Errorf("transaction = %+v, want manual_review without completion", got)
+	}
+	if len(env.auditor.entries) != 1 || env.auditor.entries[0]["reason"] == nil {
+		t.Errorf("audit entries = %v, want rejection reason", env.auditor.entries)
+	}
+}
+
+func TestProcessSendsAmountMismatchToReview(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cob123", "", domain.TransactionStatusPending)
+
+	if _, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cob123", Amount: 1}}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusManualReview || got.ErrorCode != "AMOUNT_MISMATCH" || got.CompletedAt != nil {
+		t.Errorf("transaction = %+v, want manual_review without completion", got)
+	}
+	if len(env.auditor.entries) != 1 || env.auditor.entries[0]["paid_amount"] != int64(1) {
+		t.Errorf("audit entries = %v, want paid_amount 1", env.auditor.entries)
+	}
+}
+
+func TestProcessAcceptsAnyAmountForOpenStaticCharge(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cob123", "", domain.TransactionStatusPending)
+	tx.Type = domain.TransactionTypeQRCodeStatic
+	tx.Amount = 0
+
+	if _, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cob123", Amount: 2500}}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if got := env.store.txs[tx.ID]; got.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Status = %s, want completed", got.Status)
+	}
+}
+
+func TestProcessIgnoresTransfers(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("", "E1", domain.TransactionStatusProcessing)
+	tx.Type = domain.TransactionTypeTransfer
+
+	result, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{E2EID: "E1", Amount: 1000}})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if result.Ignored != 1 || env.store.txs[tx.ID].Status != domain.TransactionStatusProcessing {
+		t.Errorf("result = %+v, status = %s", result, env.store.txs[tx.ID].Status)
+	}
+	if len(env.notifier.statuses) != 0 {
+		t.Errorf("notified = %v, want none", env.notifier.statuses)
+	}
+}
+
+func TestProcessReviewsChargeWithInvalidTerms(t *testing.T) {
+	env := newProcessorTestEnv()
+	tx := env.addTransaction("cobv1", "", domain.TransactionStatusPending)
+	tx.Type = domain.TransactionTypeQRCodeDueDate
+	tx.DueDate = &domain.DueDateTerms{DueDate: "10/03/2024"}
+
+	if _, err := env.processor.Process(context.Background(), env.provider, []providers.CallbackNotification{{TxID: "cobv1", Amount: 1000}}); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusManualReview || got.ErrorCode != "INVALID_DUE_DATE_TERMS" {
+		t.Errorf("transaction = %+v, want manual_review for invalid terms", got)
+	}
+}
+
+type processorTestEnv struct {
+	processor *Processor
+	provider  *domain.Provider
+	store     *fakeTransactionStore
+	auditor   *fakeAuditor
+	notifier  *fakeNotifier
+}
+
+func newProcessorTestEnv() *processorTestEnv {
+	store := &fakeTransactionStore{txs: make(map[uuid.UUID]*domain.Transaction)}
+	auditor := &fakeAuditor{}
+	notifier := &fakeNotifier{}
+
+	return &processorTestEnv{
+		processor: NewProcessor(store, auditor, notifier),
+		provider:  &domain.Provider{ID: uuid.New(), Code: "fake"},
+		store:     store,
+		auditor:   auditor,
+		notifier:  notifier,
+	}
+}
+
+func (e *processorTestEnv) addTransaction(providerTxID, e2eID string, status domain.TransactionStatus) *domain.Transaction {
+	tx := &domain.Transaction{
+		ID:           uuid.New(),
+		MerchantID:   uuid.New(),
+		ProviderID:   e.provider.ID,
+		ProviderTxID: providerTxID,
+		E2EID:        e2eID,
+		Type:         domain.TransactionTypeQRCodeDynamic,
+		Status:       status,
+		Amount:       1000,
+	}
+	e.store.txs[tx.ID] = tx
+	return tx
+}
+
+type fakeTransactionStore struct {
+	txs map[uuid.UUID]*domain.Transaction
+}
+
+func (s *fakeTransactionStore) GetByProviderTxID(ctx context.Context, providerID uuid.UUID, providerTxID string) (*domain.Transaction, error) {
+	for _, tx := range s.txs {
+		if tx.ProviderID == providerID && tx.ProviderTxID == providerTxID {
+			found := *tx
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *fakeTransactionStore) GetByE2EID(ctx context.Context, e2eID string) (*domain.Transaction, error) {
+	for _, tx := range s.txs {
+		if tx.E2EID == e2eID {
+			found := *tx
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *fakeTransactionStore) UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error) {
+	if s.txs[tx.ID].Status != expected {
+		return false, nil
+	}
+	updated := *tx
+	s.txs[tx.ID] = &updated
+	return true, nil
+}
+
+type fakeAuditor struct {
+	entries []map[string]interface{}
+	ignored []map[string]interface{}
+}
+
+func (f *fakeAuditor) LogTransaction(ctx context.Context, merchantID, userID, transactionID uuid.UUID, action string, metadata map[string]interface{}) error {
+	switch action {
+	case "status_transition":
+		f.entries = append(f.entries, metadata)
+	case "callback_ignored":
+		f.ignored = append(f.ignored, metadata)
+	}
+	return nil
+}
+
+type fakeNotifier struct {
+	statuses []domain.TransactionStatus
+}
+
+func (f *fakeNotifier) Enqueue(ctx context.Context, tx *domain.Transaction) error {
+	f.statuses = append(f.statuses, tx.Status)
+	return nil
+}
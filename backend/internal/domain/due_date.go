@@ -0,0 +1,308 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Erros das cobranças com vencimento
+var (
+	// ErrInvalidDueDateTerms indica condições de vencimento inconsistentes
+	ErrInvalidDueDateTerms = errors.New("condições de vencimento inválidas")
+	// ErrChargeOverdue indica pagamento após a validade da cobrança
+	ErrChargeOverdue = errors.New("cobrança fora do prazo de validade")
+)
+
+// ChargeModality é a forma de cálculo de multa, juros, abatimento ou desconto
+type ChargeModality string
+
+const (
+	ModalityFixed            ChargeModality = "fixed"              // Valor fixo em centavos
+	ModalityPercent          ChargeModality = "percent"            // Percentual do valor original
+	ModalityFixedPerDay      ChargeModality = "fixed_per_day"      // Centavos por dia corrido
+	ModalityPercentPerDay    ChargeModality = "percent_per_day"    // Percentual por dia corrido
+	ModalityPercentPerMonth  ChargeModality = "percent_per_month"  // Percentual ao mês (30 dias corridos)
+	ModalityPercentPerYear   ChargeModality = "percent_per_year"   // Percentual ao ano (365 dias corridos)
+	ModalityFixedUntilDate   ChargeModality = "fixed_until_date"   // Centavos até cada data informada
+	ModalityPercentUntilDate ChargeModality = "percent_until_date" // Percentual até cada data informada
+)
+
+// Modalidades aceitas em cada componente da cobrança (manual da API PIX do BACEN, dias corridos)
+var (
+	fineModalities     = []ChargeModality{ModalityFixed, ModalityPercent}
+	interestModalities = []ChargeModality{ModalityFixedPerDay, ModalityPercentPerDay, ModalityPercentPerMonth, ModalityPercentPerYear}
+	rebateModalities   = []ChargeModality{ModalityFixed, ModalityPercent}
+	discountModalities = []ChargeModality{ModalityFixedUntilDate, ModalityPercentUntilDate, ModalityFixedPerDay, ModalityPercentPerDay}
+)
+
+const (
+	dueDateLayout       = "2006-01-02"
+	maxDiscountDates    = 3
+	maxValidAfterDue    = 365
+	percentScale        = 10000 // Percentuais em centésimos de ponto percentual (2,5% = 250)
+	daysPerInterestMon  = 30
+	daysPerInterestYear = 365
+)
+
+// ChargeLocation é o fuso das datas de vencimento (horário de Brasília)
+var ChargeLocation = time.FixedZone("BRT", -3*60*60)
+
+// ChargeModifier representa multa, juros ou abatimento. Value é em centavos nas
+// modalidades de valor e em centésimos de ponto percentual nas percentuais (2,5% = 250).
+type ChargeModifier struct {
+	Modality ChargeModality `json:"modality"`
+	Value    int64          `json:"value"`
+}
+
+// DiscountDate representa um desconto válido para pagamentos até a data informada
+type DiscountDate struct {
+	Date  string `json:"date"` // AAAA-MM-DD
+	Value int64  `json:"value"`
+}
+
+// ChargeDiscount representa o desconto por pagamento antecipado
+type ChargeDiscount struct {
+	Modality ChargeModality `json:"modality"`
+	Value    int64          `json:"value,omitempty"` // Modalidades por dia de antecipação
+	Dates    []DiscountDate `json:"dates,omitempty"` // Modalidades até data fixa
+}
+
+// DueDateTerms representa as condições de uma cobrança com vencimento (cobv)
+type DueDateTerms struct {
+	DueDate       string          `json:"due_date"`        // AAAA-MM-DD
+	ValidAfterDue int             `json:"valid_after_due"` // Dias corridos em que ainda pode ser paga após o vencimento
+	Fine          *ChargeModifier `json:"fine,omitempty"`
+	Interest      *ChargeModifier `json:"interest,omitempty"`
+	Rebate        *ChargeModifier `json:"rebate,omitempty"`
+	Discount      *ChargeDiscount `json:"discount,omitempty"`
+}
+
+// Validate verifica as condições para uma cobrança do valor original criada no instante informado
+func (t *DueDateTerms) Validate(original int64, created time.Time) error {
+	if original <= 0 {
+		return fmt.Errorf("%w: cobrança com vencimento exige valor", ErrInvalidDueDateTerms)
+	}
+
+	due, err := parseChargeDate(t.DueDate)
+	if err != nil {
+		return err
+	}
+	if t.ValidAfterDue < 0 || t.ValidAfterDue > maxValidAfterDue {
+		return fmt.Errorf("%w: validade após o vencimento deve estar entre 0 e %d dias", ErrInvalidDueDateTerms, maxValidAfterDue)
+	}
+
+	if err := validateModifier("multa", t.Fine, fineModalities); err != nil {
+		return err
+	}
+	if err := validateModifier("juros", t.Interest, interestModalities); err != nil {
+		return err
+	}
+	if err := validateModifier("abatimento", t.Rebate, rebateModalities); err != nil {
+		return err
+	}
+	if t.rebate(original) >= original {
+		return fmt.Errorf("%w: abatimento deve ser menor que o valor", ErrInvalidDueDateTerms)
+	}
+
+	return t.validateDiscount(original, due, created)
+}
+
+// Deadline retorna o primeiro instante em que a cobrança não pode mais ser paga
+func (t *DueDateTerms) Deadline() (time.Time, error) {
+	due, err := parseChargeDate(t.DueDate)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return due.AddDate(0, 0, t.ValidAfterDue+1), nil
+}
+
+// AmountDue calcula o valor a pagar na data informada: abatimento sempre, desconto até o
+// vencimento, multa e juros depois dele. Pagamentos após a validade retornam ErrChargeOverdue.
+func (t *DueDateTerms) AmountDue(original int64, at time.Time) (int64, error) {
+	due, err := parseChargeDate(t.DueDate)
+	if err != nil {
+		return 0, err
+	}
+
+	local := at.In(ChargeLocation)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, ChargeLocation)
+	if day.After(due.AddDate(0, 0, t.ValidAfterDue)) {
+		return 0, ErrChargeOverdue
+	}
+
+	amount := original - t.rebate(original)
+	if !day.After(due) {
+		discount := t.discount(amount, day, daysBetween(day, due))
+		if discount >= amount {
+			return 0, fmt.Errorf("%w: desconto excede o valor da cobrança", ErrInvalidDueDateTerms)
+		}
+		return amount - discount, nil
+	}
+
+	late := daysBetween(due, day)
+	return amount + t.fine(amount) + t.interest(amount, late), nil
+}
+
+func (t *DueDateTerms) rebate(original int64) int64 {
+	if t.Rebate == nil {
+		return 0
+	}
+	if t.Rebate.Modality == ModalityPercent {
+		return percentOf(original, t.Rebate.Value, 1)
+	}
+	return t.Rebate.Value
+}
+
+func (t *DueDateTerms) fine(amount int64) int64 {
+	if t.Fine == nil {
+		return 0
+	}
+	if t.Fine.Modality == ModalityPercent {
+		return percentOf(amount, t.Fine.Value, 1)
+	}
+	return t.Fine.Value
+}
+
+func (t *DueDateTerms) interest(amount int64, days int64) int64 {
+	if t.Interest == nil {
+		return 0
+	}
+	switch t.Interest.Modality {
+	case ModalityFixedPerDay:
+		return t.Interest.Value * days
+	case ModalityPercentPerDay:
+		return percentOf(amount*days, t.Interest.Value, 1)
+	case ModalityPercentPerMonth:
+		return percentOf(amount*days, t.Interest.Value, daysPerInterestMon)
+	case ModalityPercentPerYear:
+		return percentOf(amount*days, t.Interest.Value, daysPerInterestYear)
+	}
+	return 0
+}
+
+// discount calcula o desconto para pagamento no dia informado, antecipado em early dias
+func (t *DueDateTerms) discount(amount int64, day time.Time, early int64) int64 {
+	if t.Discount == nil {
+		return 0
+	}
+	switch t.Discount.Modality {
+	case ModalityFixedPerDay:
+		return t.Discount.Value * early
+	case ModalityPercentPerDay:
+		return percentOf(amount*early, t.Discount.Value, 1)
+	}
+
+	// Datas em ordem crescente: vale o desconto da primeira data ainda não ultrapassada
+	for _, d := range t.Discount.Dates {
+		limit, err := parseChargeDate(d.Date)
+		if err != nil || day.After(limit) {
+			continue
+		}
+		if t.Discount.Modality == ModalityPercentUntilDate {
+			return percentOf(amount, d.Value, 1)
+		}
+		return d.Value
+	}
+	return 0
+}
+
+func (t *DueDateTerms) validateDiscount(original int64, due, created time.Time) error {
+	d := t.Discount
+	if d == nil {
+		return nil
+	}
+	if !hasModality(discountModalities, d.Modality) {
+		return fmt.Errorf("%w: modalidade de desconto %q", ErrInvalidDueDateTerms, d.Modality)
+	}
+
+	if d.Modality == ModalityFixedPerDay || d.Modality == ModalityPercentPerDay {
+		if d.Value <= 0 || len(d.Dates) > 0 {
+			return fmt.Errorf("%w: desconto por antecipação exige apenas o valor diário", ErrInvalidDueDateTerms)
+		}
+		if err := checkPercent("desconto", d.Modality == ModalityPercentPerDay, d.Value); err != nil {
+			return err
+		}
+		// O maior desconto é o do pagamento no dia da criação
+		local := created.In(ChargeLocation)
+		first := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, ChargeLocation)
+		amount := original - t.rebate(original)
+		if early := daysBetween(first, due); early > 0 && t.discount(amount, first, early) >= amount {
+			return fmt.Errorf("%w: desconto acumulado até o vencimento deve ser menor que o valor", ErrInvalidDueDateTerms)
+		}
+		return nil
+	}
+
+	if len(d.Dates) == 0 || len(d.Dates) > maxDiscountDates || d.Value != 0 {
+		return fmt.Errorf("%w: desconto até data exige de 1 a %d datas", ErrInvalidDueDateTerms, maxDiscountDates)
+	}
+	var previous time.Time
+	for _, date := range d.Dates {
+		limit, err := parseChargeDate(date.Date)
+		if err != nil {
+			return err
+		}
+		if limit.After(due) || !limit.After(previous) {
+			return fmt.Errorf("%w: datas de desconto devem ser crescentes e até o vencimento", ErrInvalidDueDateTerms)
+		}
+		if date.Value <= 0 {
+			return fmt.Errorf("%w: valor do desconto deve ser positivo", ErrInvalidDueDateTerms)
+		}
+		if err := checkPercent("desconto", d.Modality == ModalityPercentUntilDate, date.Value); err != nil {
+			return err
+		}
+		if d.Modality == ModalityFixedUntilDate && date.Value >= original-t.rebate(original) {
+			return fmt.Errorf("%w: desconto deve ser menor que o valor", ErrInvalidDueDateTerms)
+		}
+		previous = limit
+	}
+	return nil
+}
+
+func validateModifier(name string, m *ChargeModifier, allowed []ChargeModality) error {
+	if m == nil {
+		return nil
+	}
+	if !hasModality(allowed, m.Modality) {
+		return fmt.Errorf("%w: modalidade de %s %q", ErrInvalidDueDateTerms, name, m.Modality)
+	}
+	if m.Value <= 0 {
+		return fmt.Errorf("%w: valor de %s deve ser positivo", ErrInvalidDueDateTerms, name)
+	}
+	return checkPercent(name, m.Modality != ModalityFixed && m.Modality != ModalityFixedPerDay, m.Value)
+}
+
+func checkPercent(name string, percent bool, value int64) error {
+	if percent && value > percentScale {
+		return fmt.Errorf("%w: percentual de %s acima de 100%%", ErrInvalidDueDateTerms, name)
+	}
+	return nil
+}
+
+func hasModality(allowed []ChargeModality, modality ChargeModality) bool {
+	for _, m := range allowed {
+		if m == modality {
+			return true
+		}
+	}
+	return false
+}
+
+func parseChargeDate(value string) (time.Time, error) {
+	date, err := time.ParseInLocation(dueDateLayout, value, ChargeLocation)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: data %q (use AAAA-MM-DD)", ErrInvalidDueDateTerms, value)
+	}
+	return date, nil
+}
+
+// percentOf aplica o percentual (em centésimos) dividido por divisor, arredondando ao centavo
+func percentOf(amount, basis, divisor int64) int64 {
+	den := percentScale * divisor
+	return (amount*basis + den/2) / den
+}
+
+// daysBetween conta os dias corridos entre duas datas à meia-noite
+func daysBetween(from, to time.Time) int64 {
+	return int64(to.Sub(from).Hours()) / 24
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDueDateTermsAmountDue(t *testing.T) {
+	terms := &DueDateTerms{
+		DueDate:       "2024-03-10",
+		ValidAfterDue: 30,
+		Fine:          &ChargeModifier{Modality: ModalityPercent, Value: 200},         // 2%
+		Interest:      &ChargeModifier{Modality: ModalityPercentPerMonth, Value: 100}, // 1% a.m.
+		Rebate:        &ChargeModifier{Modality: ModalityFixed, Value: 1000},          // R$ 10,00
+		Discount: &ChargeDiscount{Modality: ModalityPercentUntilDate, Dates: []DiscountDate{
+			{Date: "2024-03-01", Value: 1000}, // 10%
+			{Date: "2024-03-05", Value: 500},  // 5%
+		}},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int64
+	}{
+		{"first discount", chargeDay(2024, 3, 1, 23), 99000 - 9900},
+		{"second discount", chargeDay(2024, 3, 2, 8), 99000 - 4950},
+		{"on due date", chargeDay(2024, 3, 10, 23), 99000},
+		{"one day late", chargeDay(2024, 3, 11, 0), 99000 + 1980 + 33},
+		{"thirty days late", chargeDay(2024, 4, 9, 12), 99000 + 1980 + 990},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := terms.AmountDue(100000, tt.at)
+			if err != nil {
+				t.Fatalf("AmountDue() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("AmountDue() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueDateTermsAmountDueUsesBrasiliaDate(t *testing.T) {
+	terms := &DueDateTerms{DueDate: "2024-03-10", Fine: &ChargeModifier{Modality: ModalityFixed, Value: 500}}
+
+	// 02:00 UTC do dia 11 ainda é dia 10 em Brasília
+	got, err := terms.AmountDue(10000, time.Date(2024, 3, 11, 2, 0, 0, 0, time.UTC))
+	if err != nil || got != 10000 {
+		t.Errorf("AmountDue() = %d, %v; want 10000 without fine", got, err)
+	}
+}
+
+func TestDueDateTermsAmountDuePerDay(t *testing.T) {
+	terms := &DueDateTerms{
+		DueDate:       "2024-03-10",
+		ValidAfterDue: 5,
+		Interest:      &ChargeModifier{Modality: ModalityFixedPerDay, Value: 10},
+		Discount:      &ChargeDiscount{Modality: ModalityPercentPerDay, Value: 10}, // 0,1% ao dia
+	}
+
+	if got, _ := terms.AmountDue(10000, chargeDay(2024, 3, 5, 12)); got != 10000-50 {
+		t.Errorf("AmountDue() 5 days early = %d, want %d", got, 10000-50)
+	}
+	if got, _ := terms.AmountDue(10000, chargeDay(2024, 3, 13, 12)); got != 10000+30 {
+		t.Errorf("AmountDue() 3 days late = %d, want %d", got, 10000+30)
+	}
+}
+
+func TestDueDateTermsRejectsOverduePayment(t *testing.T) {
+	terms := &DueDateTerms{DueDate: "2024-03-10", ValidAfterDue: 2}
+
+	if _, err := terms.AmountDue(10000, chargeDay(2024, 3, 12, 23)); err != nil {
+		t.Errorf("AmountDue() on last valid day error = %v", err)
+	}
+	if _, err := terms.AmountDue(10000, chargeDay(2024, 3, 13, 0)); !errors.Is(err, ErrChargeOverdue) {
+		t.Errorf("AmountDue() after validity error = %v, want ErrChargeOverdue", err)
+	}
+
+	deadline, err := terms.Deadline()
+	if err != nil || !deadline.Equal(chargeDay(2024, 3, 13, 0)) {
+		t.Errorf("Deadline() = %v, %v", deadline, err)
+	}
+}
+
+func TestDueDateTermsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		terms   DueDateTerms
+		wantErr bool
+	}{
+		{"minimal", DueDateTerms{DueDate: "2024-03-10"}, false},
+		{"invalid date", DueDateTerms{DueDate: "10/03/2024"}, true},
+		{"negative validity", DueDateTerms{DueDate: "2024-03-10", ValidAfterDue: -1}, true},
+		{"fine per day", DueDateTerms{DueDate: "2024-03-10", Fine: &ChargeModifier{Modality: ModalityFixedPerDay, Value: 1}}, true},
+		{"interest above 100%", DueDateTerms{DueDate: "2024-03-10", Interest: &ChargeModifier{Modality: ModalityPercentPerDay, Value: 10001}}, true},
+		{"rebate above amount", DueDateTerms{DueDate: "2024-03-10", Rebate: &ChargeModifier{Modality: ModalityFixed, Value: 10000}}, true},
+		{"discount after due date", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityFixedUntilDate, Dates: []DiscountDate{{Date: "2024-03-11", Value: 100}}}}, true},
+		{"discount dates out of order", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityFixedUntilDate, Dates: []DiscountDate{{Date: "2024-03-05", Value: 200}, {Date: "2024-03-01", Value: 100}}}}, true},
+		{"discount per day with dates", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityFixedPerDay, Value: 1, Dates: []DiscountDate{{Date: "2024-03-01", Value: 1}}}}, true},
+		{"discount per day reaching amount", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityPercentPerDay, Value: 1200}}, true},
+		{"fixed discount per day reaching amount", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityFixedPerDay, Value: 1200}}, true},
+		{"bounded discount per day", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityPercentPerDay, Value: 900}}, false},
+		{"valid discount dates", DueDateTerms{DueDate: "2024-03-10", Discount: &ChargeDiscount{Modality: ModalityFixedUntilDate, Dates: []DiscountDate{{Date: "2024-03-01", Value: 200}, {Date: "2024-03-05", Value: 100}}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.terms.Validate(10000, chargeDay(2024, 3, 1, 12))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidDueDateTerms) {
+				t.Errorf("Validate() error = %v, want ErrInvalidDueDateTerms", err)
+			}
+		})
+	}
+}
+
+func chargeDay(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, ChargeLocation)
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ type Merchant struct {
 	Document    string     `json:"document" gorm:"uniqueIndex;not null"` // CPF/CNPJ
 	Email       string     `json:"email" gorm:"uniqueIndex;not null"`
 	Phone       string     `json:"phone"`
+	City        string     `json:"city"` // Cidade do recebedor no BR Code
 	Active      bool       `json:"active" gorm:"default:true"`
 	APIKey      string     `json:"-" gorm:"uniqueIndex;not null"` // Criptografado
 	WebhookURL  string     `json:"webhook_url"`
@@ -72,17 +74,43 @@ const (
 	ProviderTypePSP         ProviderType = "psp"
 )
 
+// Status de saúde de um provider
+const (
+	ProviderHealthUnknown   = "unknown"
+	ProviderHealthHealthy   = "healthy"
+	ProviderHealthDegraded  = "degraded"
+	ProviderHealthUnhealthy = "unhealthy"
+)
+
+// ProviderHealthCheck registra o resultado de cada verificação periódica de saúde de um provider
+type ProviderHealthCheck struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProviderID   uuid.UUID `json:"provider_id" gorm:"type:uuid;not null;index"`
+	Status       string    `json:"status" gorm:"not null"`
+	Latency      int64     `json:"latency"` // Milissegundos
+	ErrorCode    string    `json:"error_code,omitempty"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	CheckedAt    time.Time `json:"checked_at" gorm:"not null;index"`
+}
+
 // ProviderConfig armazena configurações específicas de cada provider
 type ProviderConfig struct {
-	BaseURL          string            `json:"base_url"`
-	AuthURL          string            `json:"auth_url"`
-	SandboxURL       string            `json:"sandbox_url,omitempty"`
-	AuthType         string            `json:"auth_type"` // oauth2, mtls, api_key
-	Timeout          int               `json:"timeout"`   // segundos
-	MaxRetries       int               `json:"max_retries"`
-	RequiresMTLS     bool              `json:"requires_mtls"`
-	SupportedMethods []string          `json:"supported_methods"` // pix_key, account, qrcode
-	CustomHeaders    map[string]string `json:"custom_headers,omitempty"`
+	BaseURL            string            `json:"base_url"`
+	AuthURL            string            `json:"auth_url"`
+	SandboxURL         string            `json:"sandbox_url,omitempty"`
+	AuthType           string            `json:"auth_type"` // oauth2, mtls, api_key
+	Timeout            int               `json:"timeout"`   // segundos
+	MaxRetries         int               `json:"max_retries"`
+	RequiresMTLS       bool              `json:"requires_mtls"`
+	SupportedMethods   []string          `json:"supported_methods"` // pix_key, account, qrcode
+	CustomHeaders      map[string]string `json:"custom_headers,omitempty"`
+	CABundle           string            `json:"ca_bundle,omitempty"`           // CAs confiáveis do provider (PEM)
+	PinnedCertificates []string          `json:"pinned_certificates,omitempty"` // Pins SPKI ("sha256/...")
+
+	// Autenticação dos callbacks (webhooks PIX) enviados pelo banco
+	CallbackCABundle         string   `json:"callback_ca_bundle,omitempty"`         // CAs do certificado de cliente do banco (PEM)
+	CallbackCertFingerprints []string `json:"callback_cert_fingerprints,omitempty"` // SHA-256 (hex) dos certificados aceitos
+	CallbackSecret           string   `json:"callback_secret,omitempty"`            // Segredo HMAC (criptografado)
 }
 
 // MerchantProvider representa a configuração de um merchant com um provider específico
@@ -133,6 +161,7 @@ type Transaction struct {
 	Type         TransactionType   `json:"type" gorm:"not null"`
 	Status       TransactionStatus `json:"status" gorm:"not null;index"`
 	Amount       int64             `json:"amount" gorm:"not null"` // Centavos
+	PaidAmount   *int64            `json:"paid_amount,omitempty"`  // Centavos recebidos na cobrança
 	Currency     string            `json:"currency" gorm:"default:'BRL'"`
 	Description  string            `json:"description"`
 
@@ -159,6 +188,9 @@ type Transaction struct {
 	QRCodeImage     string     `json:"qr_code_image,omitempty"` // Base64
 	QRCodeExpiresAt *time.Time `json:"qr_code_expires_at,omitempty"`
 
+	// Condições da cobrança com vencimento (cobv)
+	DueDate *DueDateTerms `json:"due_date,omitempty" gorm:"type:jsonb;serializer:json"`
+
 	// Metadata
 	Metadata     map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb"`
 	ErrorCode    string                 `json:"error_code,omitempty"`
@@ -171,17 +203,36 @@ type Transaction struct {
 	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 
+	// Consulta de status junto ao provider
+	StatusChecks      int        `json:"-" gorm:"default:0"`
+	NextStatusCheckAt *time.Time `json:"-" gorm:"index"`
+
+	// Execução de transferência agendada (se aplicável)
+	ScheduledTransferID *uuid.UUID `json:"scheduled_transfer_id,omitempty" gorm:"type:uuid;index"`
+
 	// Relacionamentos
 	Merchant Merchant `json:"merchant,omitempty" gorm:"foreignKey:MerchantID"`
 	Provider Provider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"`
 }
 
+// MarkCancelConflict envia para revisão manual a transferência cancelada localmente enquanto o
+// envio ao banco, que pode tê-la aceito, estava em andamento
+func (t *Transaction) MarkCancelConflict(providerTxID, e2eID string, now time.Time) {
+	t.Status = TransactionStatusManualReview
+	t.ProviderTxID = providerTxID
+	t.E2EID = e2eID
+	t.ErrorCode = "CANCEL_CONFLICT"
+	t.ErrorMessage = "transferência cancelada durante o envio ao banco"
+	t.UpdatedAt = now
+}
+
 type TransactionType string
 
 const (
 	TransactionTypeTransfer      TransactionType = "transfer"
 	TransactionTypeQRCodeStatic  TransactionType = "qrcode_static"
 	TransactionTypeQRCodeDynamic TransactionType = "qrcode_dynamic"
+	TransactionTypeQRCodeDueDate TransactionType = "qrcode_due_date"
 	TransactionTypePixCopyPaste  TransactionType = "pix_copy_paste"
 )
 
@@ -194,8 +245,142 @@ const (
 	TransactionStatusFailed     TransactionStatus = "failed"
 	TransactionStatusCancelled  TransactionStatus = "cancelled"
 	TransactionStatusRefunded   TransactionStatus = "refunded"
+
+	// TransactionStatusManualReview indica que o status final não pôde ser confirmado junto ao banco
+	TransactionStatusManualReview TransactionStatus = "manual_review"
+)
+
+// TransactionAttempt registra cada tentativa de execução de uma transação em um provider
+type TransactionAttempt struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TransactionID uuid.UUID `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	ProviderID    uuid.UUID `json:"provider_id" gorm:"type:uuid;not null;index"`
+	Attempt       int       `json:"attempt" gorm:"not null"`
+	Success       bool      `json:"success"`
+	ErrorCode     string    `json:"error_code,omitempty"`
+	ErrorMessage  string    `json:"error_message,omitempty"`
+	Retryable     bool      `json:"retryable"`
+	Duration      int64     `json:"duration"` // Milissegundos
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+
+	// Relacionamento
+	Provider Provider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"`
+}
+
+// Refund representa a devolução (total ou parcial) de um PIX recebido
+type Refund struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TransactionID uuid.UUID    `json:"transaction_id" gorm:"type:uuid;not null;index"` // PIX original
+	MerchantID    uuid.UUID    `json:"merchant_id" gorm:"type:uuid;not null;index"`
+	ProviderID    uuid.UUID    `json:"provider_id" gorm:"type:uuid;not null"`
+	RefundID      string       `json:"refund_id" gorm:"not null;uniqueIndex"` // ID da devolução enviado ao banco
+	RtrID         string       `json:"rtr_id,omitempty" gorm:"index"`         // ID da devolução no SPI
+	Amount        int64        `json:"amount" gorm:"not null"`                // Centavos
+	Reason        RefundReason `json:"reason" gorm:"not null"`
+	Description   string       `json:"description,omitempty"`
+	Status        RefundStatus `json:"status" gorm:"not null;index"`
+	ErrorCode     string       `json:"error_code,omitempty"`
+	ErrorMessage  string       `json:"error_message,omitempty"`
+	CompletedAt   *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+
+	// Relacionamento
+	Transaction Transaction `json:"-" gorm:"foreignKey:TransactionID"`
+}
+
+// RefundReason é o código de motivo da devolução no SPI
+type RefundReason string
+
+const (
+	RefundReasonRequested  RefundReason = "MD06" // Solicitada pelo recebedor
+	RefundReasonWithdrawal RefundReason = "SL02" // Pix Saque ou Pix Troco
+	RefundReasonBankError  RefundReason = "BE08" // MED: falha operacional do PSP
+	RefundReasonFraud      RefundReason = "FR01" // MED: fundada suspeita de fraude
+)
+
+// Valid indica se o motivo é um dos códigos aceitos
+func (r RefundReason) Valid() bool {
+	switch r {
+	case RefundReasonRequested, RefundReasonWithdrawal, RefundReasonBankError, RefundReasonFraud:
+		return true
+	}
+	return false
+}
+
+// IsMED indica devolução do Mecanismo Especial de Devolução (fraude ou falha operacional)
+func (r RefundReason) IsMED() bool {
+	return r == RefundReasonBankError || r == RefundReasonFraud
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending    RefundStatus = "pending"
+	RefundStatusProcessing RefundStatus = "processing"
+	RefundStatusCompleted  RefundStatus = "completed"
+	RefundStatusFailed     RefundStatus = "failed"
 )
 
+// ErrRefundExceedsAmount indica devoluções que somadas ultrapassam o valor do PIX original
+var ErrRefundExceedsAmount = errors.New("valor devolvido excede o valor da transação")
+
+// ScheduledTransfer representa uma transferência agendada para uma data futura, única ou recorrente.
+// Cada execução cria uma Transaction do tipo transfer com ScheduledTransferID preenchido.
+type ScheduledTransfer struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MerchantID   uuid.UUID `json:"merchant_id" gorm:"type:uuid;not null;uniqueIndex:idx_scheduled_transfers_merchant_external"`
+	ExternalID   string    `json:"external_id" gorm:"not null;uniqueIndex:idx_scheduled_transfers_merchant_external"`
+	ProviderCode string    `json:"provider_code,omitempty"` // Provider preferido (vazio usa a prioridade do merchant)
+	Amount       int64     `json:"amount" gorm:"not null"`  // Centavos por execução
+	Description  string    `json:"description"`
+
+	// Recebedor
+	PayeeName          string     `json:"payee_name"`
+	PayeeDocument      string     `json:"payee_document"`
+	PayeePixKey        string     `json:"payee_pix_key,omitempty"`
+	PayeePixKeyType    PixKeyType `json:"payee_pix_key_type,omitempty"`
+	PayeeBank          string     `json:"payee_bank,omitempty"`
+	PayeeISPB          string     `json:"payee_ispb,omitempty"`
+	PayeeAccountAgency string     `json:"payee_account_agency,omitempty"`
+	PayeeAccountNumber string     `json:"payee_account_number,omitempty"`
+	PayeeAccountType   string     `json:"payee_account_type,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	// Agenda
+	Frequency   ScheduleFrequency       `json:"frequency" gorm:"not null"`
+	StartAt     time.Time               `json:"start_at" gorm:"not null"` // Primeira execução
+	EndDate     *time.Time              `json:"end_date,omitempty"`       // Último dia com execução (horário de Brasília)
+	NextRunAt   *time.Time              `json:"next_run_at,omitempty" gorm:"index"`
+	Occurrences int                     `json:"occurrences" gorm:"not null;default:0"` // Execuções iniciadas
+	Status      ScheduledTransferStatus `json:"status" gorm:"not null;index"`
+
+	LastTransactionID *uuid.UUID `json:"last_transaction_id,omitempty" gorm:"type:uuid"`
+	LastRunAt         *time.Time `json:"last_run_at,omitempty"`
+	CancelledAt       *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// IdempotencyKey armazena a requisição e a resposta associadas a um header Idempotency-Key
+type IdempotencyKey struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MerchantID   uuid.UUID  `json:"merchant_id" gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_keys_merchant_key"`
+	Key          string     `json:"key" gorm:"not null;uniqueIndex:idx_idempotency_keys_merchant_key"`
+	Method       string     `json:"method" gorm:"not null"`
+	Path         string     `json:"path" gorm:"not null"`
+	RequestHash  string     `json:"request_hash" gorm:"not null"` // SHA-256 de método, path e corpo
+	StatusCode   int        `json:"status_code"`
+	ContentType  string     `json:"content_type"`
+	ResponseBody []byte     `json:"-"`
+	LockedUntil  time.Time  `json:"locked_until"` // Requisição em andamento até este instante
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
 // AuditLog representa logs de auditoria (retenção 5 anos)
 type AuditLog struct {
 	ID            uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -231,6 +416,10 @@ type Webhook struct {
 	UpdatedAt  time.Time  `json:"updated_at"`
 	DeletedAt  *time.Time `json:"deleted_at,omitempty" gorm:"index"`
 
+	// Rotação de segredo: o segredo anterior continua assinando até expirar
+	PreviousSecret          string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+
 	// Relacionamento
 	Merchant Merchant `json:"merchant,omitempty" gorm:"foreignKey:MerchantID"`
 }
@@ -256,6 +445,13 @@ type WebhookDelivery struct {
 	Transaction Transaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
 }
 
+// Status de uma entrega de webhook
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	WebhookDeliveryFailed  = "failed"
+)
+
 // APIKey representa chaves de API para autenticação
 type APIKey struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
@@ -173,3 +173,18 @@ func TestTransactionCreation(t *testing.T) {
 		t.Errorf("Status = %v, want pending", tx.Status)
 	}
 }
+
+func TestRefundReason(t *testing.T) {
+	for _, reason := range []RefundReason{RefundReasonRequested, RefundReasonWithdrawal, RefundReasonBankError, RefundReasonFraud} {
+		if !reason.Valid() {
+			t.Errorf("%s.Valid() = false", reason)
+		}
+	}
+	if RefundReason("AM09").Valid() {
+		t.Error("AM09.Valid() = true")
+	}
+
+	if !RefundReasonFraud.IsMED() || !RefundReasonBankError.IsMED() || RefundReasonRequested.IsMED() {
+		t.Error("IsMED() should only hold for FR01 and BE08")
+	}
+}
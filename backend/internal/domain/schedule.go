@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidSchedule indica agenda de transferência inconsistente
+var ErrInvalidSchedule = errors.New("agendamento inválido")
+
+// ScheduledExternalIDPrefix inicia o external_id das execuções agendadas. O prefixo é reservado:
+// as transações criadas pelo merchant não podem usá-lo.
+const ScheduledExternalIDPrefix = "scheduled:"
+
+// ScheduleFrequency é a recorrência de uma transferência agendada
+type ScheduleFrequency string
+
+const (
+	ScheduleOnce    ScheduleFrequency = "once"
+	ScheduleDaily   ScheduleFrequency = "daily"
+	ScheduleWeekly  ScheduleFrequency = "weekly"
+	ScheduleMonthly ScheduleFrequency = "monthly" // No dia do mês da primeira execução (ou no último dia do mês)
+)
+
+// Valid indica se a frequência é uma das aceitas
+func (f ScheduleFrequency) Valid() bool {
+	switch f {
+	case ScheduleOnce, ScheduleDaily, ScheduleWeekly, ScheduleMonthly:
+		return true
+	}
+	return false
+}
+
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferActive    ScheduledTransferStatus = "active"
+	ScheduledTransferCompleted ScheduledTransferStatus = "completed" // Sem execuções futuras
+	ScheduledTransferCancelled ScheduledTransferStatus = "cancelled"
+)
+
+// ScheduleLocation é o fuso das datas das transferências agendadas (horário de Brasília)
+var ScheduleLocation = ChargeLocation
+
+// Schedule valida a agenda e define a primeira execução. EndDate, quando informado, é o
+// último dia (no horário de Brasília) em que há execução.
+func (s *ScheduledTransfer) Schedule(now time.Time) error {
+	switch {
+	case !s.Frequency.Valid():
+		return fmt.Errorf("%w: frequência desconhecida", ErrInvalidSchedule)
+	case !s.StartAt.After(now):
+		return fmt.Errorf("%w: a primeira execução deve ser futura", ErrInvalidSchedule)
+	case s.EndDate != nil && s.Frequency == ScheduleOnce:
+		return fmt.Errorf("%w: data final só se aplica a agendamentos recorrentes", ErrInvalidSchedule)
+	case s.EndDate != nil && !s.StartAt.Before(endOfDay(*s.EndDate)):
+		return fmt.Errorf("%w: data final anterior à primeira execução", ErrInvalidSchedule)
+	}
+
+	start := s.StartAt
+	s.NextRunAt = &start
+	s.Occurrences = 0
+	s.Status = ScheduledTransferActive
+	return nil
+}
+
+// Advance registra a execução atual e calcula a próxima. Execuções perdidas (ex: serviço
+// parado) não são repetidas: a próxima é a primeira ocorrência depois de now.
+func (s *ScheduledTransfer) Advance(now time.Time) {
+	s.Occurrences++
+	s.LastRunAt = &now
+
+	if s.Frequency == ScheduleOnce || s.NextRunAt == nil {
+		s.finish()
+		return
+	}
+
+	next := *s.NextRunAt
+	for !next.After(now) {
+		next = s.step(next)
+	}
+	if s.EndDate != nil && !next.Before(endOfDay(*s.EndDate)) {
+		s.finish()
+		return
+	}
+	s.NextRunAt = &next
+}
+
+// OccurrenceExternalID retorna o external_id da execução atual, derivado do ID do agendamento
+// para não colidir com os external_ids escolhidos pelos merchants
+func (s *ScheduledTransfer) OccurrenceExternalID() string {
+	return fmt.Sprintf("%s%s:%d", ScheduledExternalIDPrefix, s.ID, s.Occurrences)
+}
+
+// step retorna a ocorrência seguinte a current
+func (s *ScheduledTransfer) step(current time.Time) time.Time {
+	switch s.Frequency {
+	case ScheduleDaily:
+		return current.AddDate(0, 0, 1)
+	case ScheduleWeekly:
+		return current.AddDate(0, 0, 7)
+	}
+
+	// Mensal: mantém o dia da primeira execução, limitado ao último dia do mês
+	local := current.In(ScheduleLocation)
+	start := s.StartAt.In(ScheduleLocation)
+	firstOfNext := time.Date(local.Year(), local.Month()+1, 1, start.Hour(), start.Minute(), start.Second(), 0, ScheduleLocation)
+	day := start.Day()
+	if last := firstOfNext.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return firstOfNext.AddDate(0, 0, day-1)
+}
+
+func (s *ScheduledTransfer) finish() {
+	s.NextRunAt = nil
+	s.Status = ScheduledTransferCompleted
+}
+
+// endOfDay retorna o início do dia seguinte à data no horário de Brasília
+func endOfDay(date time.Time) time.Time {
+	local := date.In(ScheduleLocation)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, ScheduleLocation)
+}
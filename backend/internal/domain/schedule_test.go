@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func brt(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, ScheduleLocation)
+}
+
+func TestScheduledTransferSchedule(t *testing.T) {
+	now := brt(2026, 1, 10, 12)
+	endDate := brt(2026, 3, 1, 0)
+
+	s := &ScheduledTransfer{Frequency: ScheduleMonthly, StartAt: brt(2026, 1, 31, 9), EndDate: &endDate}
+	if err := s.Schedule(now); err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if s.Status != ScheduledTransferActive || s.NextRunAt == nil || !s.NextRunAt.Equal(s.StartAt) {
+		t.Errorf("Schedule() = %+v", s)
+	}
+
+	sameDay := brt(2026, 1, 31, 0)
+	for name, invalid := range map[string]ScheduledTransfer{
+		"unknown frequency": {Frequency: "yearly", StartAt: brt(2026, 2, 1, 9)},
+		"past start":        {Frequency: ScheduleDaily, StartAt: now},
+		"end date on once":  {Frequency: ScheduleOnce, StartAt: brt(2026, 2, 1, 9), EndDate: &endDate},
+		"end before start":  {Frequency: ScheduleDaily, StartAt: brt(2026, 3, 2, 9), EndDate: &endDate},
+	} {
+		if err := invalid.Schedule(now); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%s: Schedule() error = %v, want ErrInvalidSchedule", name, err)
+		}
+	}
+
+	// Execução no próprio dia final é permitida
+	s = &ScheduledTransfer{Frequency: ScheduleDaily, StartAt: brt(2026, 1, 31, 23), EndDate: &sameDay}
+	if err := s.Schedule(now); err != nil {
+		t.Errorf("Schedule() on end date error = %v", err)
+	}
+}
+
+func TestScheduledTransferAdvance(t *testing.T) {
+	tests := []struct {
+		frequency ScheduleFrequency
+		start     time.Time
+		want      []time.Time
+	}{
+		{ScheduleDaily, brt(2026, 1, 30, 9), []time.Time{brt(2026, 1, 31, 9), brt(2026, 2, 1, 9)}},
+		{ScheduleWeekly, brt(2026, 1, 30, 9), []time.Time{brt(2026, 2, 6, 9), brt(2026, 2, 13, 9)}},
+		// Dia 31 cai no último dia dos meses mais curtos e volta ao 31 quando possível
+		{ScheduleMonthly, brt(2026, 1, 31, 9), []time.Time{brt(2026, 2, 28, 9), brt(2026, 3, 31, 9), brt(2026, 4, 30, 9)}},
+	}
+
+	for _, tt := range tests {
+		s := &ScheduledTransfer{Frequency: tt.frequency, StartAt: tt.start}
+		if err := s.Schedule(tt.start.Add(-time.Hour)); err != nil {
+			t.Fatalf("%s: Schedule() error = %v", tt.frequency, err)
+		}
+		for i, want := range tt.want {
+			s.Advance(*s.NextRunAt)
+			if s.NextRunAt == nil || !s.NextRunAt.Equal(want) {
+				t.Errorf("%s: run %d next = %v, want %v", tt.frequency, i+1, s.NextRunAt, want)
+				break
+			}
+		}
+		if s.Occurrences != len(tt.want) || s.Status != ScheduledTransferActive {
+			t.Errorf("%s: occurrences = %d, status = %s", tt.frequency, s.Occurrences, s.Status)
+		}
+	}
+}
+
+func TestScheduledTransferAdvanceFinishes(t *testing.T) {
+	once := &ScheduledTransfer{Frequency: ScheduleOnce, StartAt: brt(2026, 1, 10, 9)}
+	_ = once.Schedule(brt(2026, 1, 1, 0))
+	once.Advance(brt(2026, 1, 10, 9))
+	if once.Status != ScheduledTransferCompleted || once.NextRunAt != nil || once.Occurrences != 1 {
+		t.Errorf("once after run = %+v", once)
+	}
+
+	endDate := brt(2026, 1, 11, 0)
+	daily := &ScheduledTransfer{Frequency: ScheduleDaily, StartAt: brt(2026, 1, 10, 9), EndDate: &endDate}
+	_ = daily.Schedule(brt(2026, 1, 1, 0))
+	daily.Advance(brt(2026, 1, 10, 9))
+	if daily.Status != ScheduledTransferActive || !daily.NextRunAt.Equal(brt(2026, 1, 11, 9)) {
+		t.Fatalf("daily after first run = %+v", daily)
+	}
+	daily.Advance(brt(2026, 1, 11, 9))
+	if daily.Status != ScheduledTransferCompleted || daily.NextRunAt != nil {
+		t.Errorf("daily after end date = %+v", daily)
+	}
+}
+
+func TestScheduledTransferAdvanceSkipsMissedRuns(t *testing.T) {
+	s := &ScheduledTransfer{Frequency: ScheduleDaily, StartAt: brt(2026, 1, 10, 9)}
+	_ = s.Schedule(brt(2026, 1, 1, 0))
+
+	// Serviço parado por três dias: só a execução atrasada ocorre
+	s.Advance(brt(2026, 1, 13, 10))
+	if s.Occurrences != 1 || !s.NextRunAt.Equal(brt(2026, 1, 14, 9)) {
+		t.Errorf("after late run: occurrences = %d, next = %v", s.Occurrences, s.NextRunAt)
+	}
+}
@@ -0,0 +1,114 @@
+// Package netguard impede que URLs informadas por merchants (webhooks, BR Codes dinâmicos)
+// levem o servidor a acessar a rede interna ou o endpoint de metadados de nuvem.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress indica destino em loopback, rede privada, link-local ou de metadados de nuvem
+var ErrNonPublicAddress = errors.New("destination is not a public address")
+
+// nonPublicNetworks complementa as faixas reconhecidas pelo pacote net (loopback, privadas, link-local)
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",      // "Esta" rede
+	"100.64.0.0/10",  // CGNAT (inclui metadados de alguns provedores de nuvem)
+	"192.0.0.0/24",   // Atribuições do IETF
+	"198.18.0.0/15",  // Testes de desempenho
+	"240.0.0.0/4",    // Reservado
+	"64:ff9b::/96",   // NAT64: pode apontar para endereços IPv4 internos
+	"64:ff9b:1::/48", // NAT64 local
+)
+
+// IsPublicIP indica se o endereço pode ser acessado a partir de uma URL externa. Loopback, redes privadas, link-local
+// (inclui 169.254.169.254, o endpoint de metadados de nuvem) e faixas reservadas são recusados.
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolver resolve o host de uma URL externa (implementado por *net.Resolver)
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// CheckDestination verifica se o host resolve apenas para endereços públicos. Como o DNS pode
+// mudar depois do cadastro, o transporte de NewTransport repete a verificação a cada conexão.
+func CheckDestination(ctx context.Context, resolver Resolver, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+		}
+		return nil
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("host could not be resolved: %w", err)
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrNonPublicAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// NewTransport cria o transporte HTTP para URLs externas. O endereço é verificado no momento da
+// conexão, depois da resolução DNS, então um host que resolva (ou passe a resolver) para a rede
+// interna é recusado, inclusive por DNS rebinding. Proxies são desativados pelo mesmo motivo.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   guardAddress,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// guardAddress recusa a conexão a endereços não públicos
+func guardAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+	}
+	return nil
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
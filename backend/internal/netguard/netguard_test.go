@@ -0,0 +1,77 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"203.0.113.10":    true,
+		"8.8.8.8":         true,
+		"2001:4860::8888": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.0.10":    false,
+		"169.254.169.254": false,
+		"100.100.100.200": false,
+		"0.0.0.0":         false,
+		"fd00:ec2::254":   false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+		"64:ff9b::a00:1":  false,
+	}
+	for address, want := range tests {
+		if got := IsPublicIP(net.ParseIP(address)); got != want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestTransportRefusesHostnameResolvingToLoopback(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// localhost resolve para 127.0.0.1 pelo /etc/hosts: a verificação ocorre após a resolução
+	serverURL, _ := url.Parse(server.URL)
+	target := "http://localhost:" + serverURL.Port()
+
+	client := &http.Client{Transport: NewTransport()}
+	_, err := client.Get(target)
+	if !errors.Is(err, ErrNonPublicAddress) || called {
+		t.Errorf("Get(%s) error = %v, want ErrNonPublicAddress", target, err)
+	}
+}
+
+func TestCheckDestination(t *testing.T) {
+	resolver := fakeResolver{"psp.example.com": "10.0.0.5", "pix.example.com": "203.0.113.10"}
+
+	if err := CheckDestination(context.Background(), resolver, "pix.example.com"); err != nil {
+		t.Errorf("CheckDestination(public) error = %v", err)
+	}
+	for _, host := range []string{"psp.example.com", "169.254.169.254"} {
+		if err := CheckDestination(context.Background(), resolver, host); !errors.Is(err, ErrNonPublicAddress) {
+			t.Errorf("CheckDestination(%s) error = %v, want ErrNonPublicAddress", host, err)
+		}
+	}
+}
+
+type fakeResolver map[string]string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ip, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+}
@@ -0,0 +1,77 @@
+package pixkey
+
+import "strings"
+
+// ValidCPF verifica os dígitos verificadores (módulo 11) de um CPF, com ou sem máscara
+func ValidCPF(value string) bool {
+	digits, ok := documentDigits(value)
+	if !ok || len(digits) != 11 || repeated(digits) {
+		return false
+	}
+	return cpfCheckDigit(digits[:9]) == digits[9] && cpfCheckDigit(digits[:10]) == digits[10]
+}
+
+// ValidCNPJ verifica os dígitos verificadores (módulo 11) de um CNPJ, com ou sem máscara
+func ValidCNPJ(value string) bool {
+	digits, ok := documentDigits(value)
+	if !ok || len(digits) != 14 || repeated(digits) {
+		return false
+	}
+	return cnpjCheckDigit(digits[:12]) == digits[12] && cnpjCheckDigit(digits[:13]) == digits[13]
+}
+
+// ValidDocument verifica se o valor é um CPF ou CNPJ válido
+func ValidDocument(value string) bool {
+	return ValidCPF(value) || ValidCNPJ(value)
+}
+
+// cpfCheckDigit calcula o dígito com pesos decrescentes terminando em 2
+func cpfCheckDigit(digits string) byte {
+	sum := 0
+	weight := len(digits) + 1
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * (weight - i)
+	}
+	return mod11Digit(sum)
+}
+
+// cnpjCheckDigit calcula o dígito com pesos de 2 a 9 da direita para a esquerda
+func cnpjCheckDigit(digits string) byte {
+	sum := 0
+	weight := 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	return mod11Digit(sum)
+}
+
+func mod11Digit(sum int) byte {
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+// documentDigits remove a máscara (pontos, traço e barra) e verifica se restaram só dígitos
+func documentDigits(value string) (string, bool) {
+	digits := strings.NewReplacer(".", "", "-", "", "/", "").Replace(strings.TrimSpace(value))
+	if digits == "" {
+		return "", false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return digits, true
+}
+
+// repeated identifica sequências como 111.111.111-11, que passam no cálculo mas são inválidas
+func repeated(digits string) bool {
+	return strings.Count(digits, digits[:1]) == len(digits)
+}
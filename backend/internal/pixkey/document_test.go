@@ -0,0 +1,34 @@
+package pixkey
+
+import "testing"
+
+func TestValidCPF(t *testing.T) {
+	tests := map[string]bool{
+		"52998224725":    true,
+		"529.982.247-25": true,
+		"52998224724":    false,
+		"11111111111":    false,
+		"5299822472":     false,
+		"5299822472a":    false,
+	}
+	for value, want := range tests {
+		if got := ValidCPF(value); got != want {
+			t.Errorf("ValidCPF(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestValidCNPJ(t *testing.T) {
+	tests := map[string]bool{
+		"11222333000181":     true,
+		"11.222.333/0001-81": true,
+		"11222333000182":     false,
+		"00000000000000":     false,
+		"52998224725":        false,
+	}
+	for value, want := range tests {
+		if got := ValidCNPJ(value); got != want {
+			t.Errorf("ValidCNPJ(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
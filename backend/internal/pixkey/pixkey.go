@@ -0,0 +1,165 @@
+// Package pixkey identifica, valida e normaliza chaves PIX no formato aceito pelo DICT.
+package pixkey
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// ErrInvalidKey indica chave PIX fora do formato do tipo informado
+var ErrInvalidKey = errors.New("chave PIX inválida")
+
+// Formatos das chaves no DICT
+var (
+	emailPattern = regexp.MustCompile(`(?i)^[a-z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-z0-9-]+(\.[a-z0-9-]+)+$`)
+	phonePattern = regexp.MustCompile(`^\+55[1-9][0-9]9[0-9]{8}$`) // Celular com DDD
+	evpPattern   = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+const (
+	maxEmailLength = 77
+	countryCode    = "55"
+)
+
+// Key representa uma chave PIX normalizada
+type Key struct {
+	Value string
+	Type  domain.PixKeyType
+}
+
+// ValidEmail verifica o formato de um endereço de email. A mesma regra vale para chaves PIX
+// e para os demais emails da API; as chaves têm ainda o limite de tamanho do DICT.
+func ValidEmail(value string) bool {
+	return emailPattern.MatchString(value)
+}
+
+// Parse identifica o tipo da chave e a normaliza
+func Parse(value string) (Key, error) {
+	keyType, err := Detect(value)
+	if err != nil {
+		return Key{}, err
+	}
+	return Normalize(keyType, value)
+}
+
+// Detect identifica o tipo de uma chave informada pelo usuário. Números de 11 dígitos são
+// tratados como CPF quando os dígitos verificadores conferem; celulares sem +55 só são
+// reconhecidos quando não formam um CPF válido.
+func Detect(value string) (domain.PixKeyType, error) {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case value == "":
+		return "", fmt.Errorf("%w: chave vazia", ErrInvalidKey)
+	case strings.Contains(value, "@"):
+		return domain.PixKeyTypeEmail, nil
+	case strings.HasPrefix(value, "+"):
+		return domain.PixKeyTypePhone, nil
+	case evpPattern.MatchString(strings.ToLower(value)):
+		return domain.PixKeyTypeRandom, nil
+	}
+
+	if digits, ok := documentDigits(value); ok {
+		switch {
+		case len(digits) == 11 && ValidCPF(digits):
+			return domain.PixKeyTypeCPF, nil
+		case len(digits) == 14 && ValidCNPJ(digits):
+			return domain.PixKeyTypeCNPJ, nil
+		}
+	}
+	if _, err := normalizePhone(value); err == nil {
+		return domain.PixKeyTypePhone, nil
+	}
+
+	return "", fmt.Errorf("%w: formato não reconhecido", ErrInvalidKey)
+}
+
+// Normalize valida a chave conforme o tipo e a converte para o formato do DICT: CPF e CNPJ
+// só com dígitos, email em minúsculas, celular em E.164 (+55) e chave aleatória em minúsculas.
+// Sem tipo, ele é detectado pela chave.
+func Normalize(keyType domain.PixKeyType, value string) (Key, error) {
+	value = strings.TrimSpace(value)
+	if keyType == "" {
+		detected, err := Detect(value)
+		if err != nil {
+			return Key{}, err
+		}
+		keyType = detected
+	}
+
+	switch keyType {
+	case domain.PixKeyTypeCPF:
+		digits, ok := documentDigits(value)
+		if !ok || !ValidCPF(digits) {
+			return Key{}, fmt.Errorf("%w: CPF inválido", ErrInvalidKey)
+		}
+		return Key{Value: digits, Type: keyType}, nil
+
+	case domain.PixKeyTypeCNPJ:
+		digits, ok := documentDigits(value)
+		if !ok || !ValidCNPJ(digits) {
+			return Key{}, fmt.Errorf("%w: CNPJ inválido", ErrInvalidKey)
+		}
+		return Key{Value: digits, Type: keyType}, nil
+
+	case domain.PixKeyTypeEmail:
+		email := strings.ToLower(value)
+		if len(email) > maxEmailLength || !ValidEmail(email) {
+			return Key{}, fmt.Errorf("%w: email inválido", ErrInvalidKey)
+		}
+		return Key{Value: email, Type: keyType}, nil
+
+	case domain.PixKeyTypePhone:
+		phone, err := normalizePhone(value)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{Value: phone, Type: keyType}, nil
+
+	case domain.PixKeyTypeRandom:
+		evp := strings.ToLower(value)
+		if !evpPattern.MatchString(evp) {
+			return Key{}, fmt.Errorf("%w: chave aleatória deve ser um UUID", ErrInvalidKey)
+		}
+		return Key{Value: evp, Type: keyType}, nil
+
+	case domain.PixKeyTypeAccount:
+		// Pagamento por dados bancários: não há chave no DICT
+		return Key{Value: value, Type: keyType}, nil
+	}
+
+	return Key{}, fmt.Errorf("%w: tipo %q desconhecido", ErrInvalidKey, keyType)
+}
+
+// normalizePhone converte o celular para E.164 (+55 DDD número), aceitando máscara e número sem +55
+func normalizePhone(value string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '(', ')', '-', '.':
+			return -1
+		}
+		return r
+	}, value)
+
+	international := strings.HasPrefix(digits, "+")
+	digits = strings.TrimPrefix(digits, "+")
+
+	switch {
+	case international:
+	case len(digits) == 11:
+		digits = countryCode + digits
+	case len(digits) == 13 && strings.HasPrefix(digits, countryCode):
+	default:
+		return "", fmt.Errorf("%w: celular deve ter DDD e 9 dígitos", ErrInvalidKey)
+	}
+
+	phone := "+" + digits
+	if !phonePattern.MatchString(phone) {
+		return "", fmt.Errorf("%w: celular deve estar no formato +55 DDD 9XXXXXXXX", ErrInvalidKey)
+	}
+	return phone, nil
+}
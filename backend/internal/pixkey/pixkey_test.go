@@ -0,0 +1,73 @@
+package pixkey
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		wantType domain.PixKeyType
+	}{
+		{"529.982.247-25", "52998224725", domain.PixKeyTypeCPF},
+		{"11.222.333/0001-81", "11222333000181", domain.PixKeyTypeCNPJ},
+		{" Cliente@Example.COM ", "cliente@example.com", domain.PixKeyTypeEmail},
+		{"+55 (11) 99999-8888", "+5511999998888", domain.PixKeyTypePhone},
+		{"(11) 99999-8888", "+5511999998888", domain.PixKeyTypePhone},
+		{"11999998888", "+5511999998888", domain.PixKeyTypePhone}, // Não é CPF válido
+		{"123E4567-E12B-12D1-A456-426655440000", "123e4567-e12b-12d1-a456-426655440000", domain.PixKeyTypeRandom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			key, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if key.Value != tt.want || key.Type != tt.wantType {
+				t.Errorf("Parse() = %+v, want %s (%s)", key, tt.want, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, input := range []string{"", "abc", "12345", "12345678901", "+1 202 555 0100", "123e4567e12b12d1a456426655440000"} {
+		if key, err := Parse(input); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Parse(%q) = %+v, %v; want ErrInvalidKey", input, key, err)
+		}
+	}
+}
+
+func TestNormalizeWithType(t *testing.T) {
+	tests := []struct {
+		keyType domain.PixKeyType
+		input   string
+		want    string
+		valid   bool
+	}{
+		{domain.PixKeyTypePhone, "5511999998888", "+5511999998888", true},
+		{domain.PixKeyTypePhone, "1133334444", "", false}, // Fixo não é chave
+		{domain.PixKeyTypeCPF, "11222333000181", "", false},
+		{domain.PixKeyTypeEmail, "a@b", "", false}, // Domínio sem ponto
+		{domain.PixKeyTypeEmail, "a%b@example.com", "a%b@example.com", true},
+		{domain.PixKeyTypeEmail, "sem-arroba", "", false},
+		{domain.PixKeyTypeAccount, " 0001-123 ", "0001-123", true},
+		{"iban", "BR00", "", false},
+	}
+
+	for _, tt := range tests {
+		key, err := Normalize(tt.keyType, tt.input)
+		if (err == nil) != tt.valid {
+			t.Errorf("Normalize(%s, %q) error = %v, want valid %v", tt.keyType, tt.input, err, tt.valid)
+			continue
+		}
+		if tt.valid && key.Value != tt.want {
+			t.Errorf("Normalize(%s, %q) = %q, want %q", tt.keyType, tt.input, key.Value, tt.want)
+		}
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/pixsaas/backend/internal/providers"
 )
 
+var _ providers.PixProvider = (*BBProvider)(nil)
+
 // BBProvider implementa o provider do Banco do Brasil
 type BBProvider struct {
 	config     providers.ProviderConfig
@@ -32,8 +34,13 @@ func (p *BBProvider) GetName() string {
 
 // Initialize inicializa o provider com configurações
 func (p *BBProvider) Initialize(config providers.ProviderConfig) error {
+	httpClient, err := providers.NewHTTPClientFromConfig(config)
+	if err != nil {
+		return err
+	}
+
 	p.config = config
-	p.httpClient = providers.NewHTTPClient(config.Timeout, config.RequiresMTLS)
+	p.httpClient = httpClient
 	return nil
 }
 
@@ -114,6 +121,8 @@ func (p *BBProvider) CreateTransfer(ctx context.Context, req *providers.Transfer
 		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
 	}
 
+	ctx = providers.WithIdempotencyHeader(ctx, headers, "X-Idempotency-Key", req.IdempotencyKey)
+
 	resp, err := p.httpClient.Post(ctx, url, payload, headers)
 	if err != nil {
 		return nil, providers.NewProviderError("TRANSFER_FAILED", "Falha ao criar transferência", err)
@@ -213,11 +222,55 @@ func (p *BBProvider) CreateQRCodeStatic(ctx context.Context, req *providers.QRCo
 	}, nil
 }
 
-// CreateQRCodeDynamic cria um QR Code dinâmico
+// CreateQRCodeDynamic cria um QR Code dinâmico ou, com DueDate, uma cobrança com vencimento
 func (p *BBProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	if req.DueDate != nil {
+		return p.createCobV(ctx, req)
+	}
 	return p.CreateQRCodeStatic(ctx, req)
 }
 
+// createCobV cria a cobrança com vencimento; o txid é definido por nós, então o PUT é idempotente
+func (p *BBProvider) createCobV(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	payload, err := providers.NewCobVPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.TxID == "" {
+		return nil, providers.NewProviderError(providers.ErrCodeInvalidRequest, "Cobrança com vencimento exige txid", nil)
+	}
+
+	url := fmt.Sprintf("%s/pix/v1/cobv/%s", p.config.BaseURL, req.TxID)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
+	}
+
+	resp, err := p.httpClient.Put(ctx, url, payload, headers)
+	if err != nil {
+		return nil, providers.NewProviderError("QRCODE_FAILED", "Falha ao gerar cobrança com vencimento", err)
+	}
+
+	var cobv struct {
+		TxID          string `json:"txid"`
+		PixCopiaECola string `json:"pixCopiaECola"`
+		Status        string `json:"status"`
+	}
+
+	if err := json.Unmarshal(resp, &cobv); err != nil {
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
+	}
+
+	return &providers.QRCodeResponse{
+		QRCodeID:    cobv.TxID,
+		QRCode:      cobv.PixCopiaECola,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Status:      cobv.Status,
+	}, nil
+}
+
 // GetQRCode consulta um QR Code
 func (p *BBProvider) GetQRCode(ctx context.Context, req *providers.GetQRCodeRequest) (*providers.QRCodeResponse, error) {
 	url := fmt.Sprintf("%s/pix/v1/cobqrcode/%s", p.config.BaseURL, req.QRCodeID)
@@ -250,9 +303,48 @@ func (p *BBProvider) GetQRCode(ctx context.Context, req *providers.GetQRCodeRequ
 	}, nil
 }
 
-// ValidatePixKey valida uma chave PIX
+// RequestRefund solicita a devolução de um PIX recebido. O ID da devolução é definido por nós,
+// então o PUT é idempotente.
+func (p *BBProvider) RequestRefund(ctx context.Context, req *providers.RefundRequest) (*providers.RefundResponse, error) {
+	payload, err := providers.NewRefundPayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	url := p.config.BaseURL + "/pix/v1" + providers.RefundPath(req.E2EID, req.RefundID)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
+	}
+
+	resp, err := p.httpClient.Put(ctx, url, payload, headers)
+	if err != nil {
+		return nil, providers.NewProviderError("REFUND_FAILED", "Falha ao solicitar devolução", err)
+	}
+
+	return providers.ParseRefundResponse(resp)
+}
+
+// GetRefund consulta uma devolução
+func (p *BBProvider) GetRefund(ctx context.Context, req *providers.GetRefundRequest) (*providers.RefundResponse, error) {
+	url := p.config.BaseURL + "/pix/v1" + providers.RefundPath(req.E2EID, req.RefundID)
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
+	}
+
+	resp, err := p.httpClient.Get(ctx, url, headers)
+	if err != nil {
+		return nil, providers.NewProviderError("GET_FAILED", "Falha ao consultar devolução", err)
+	}
+
+	return providers.ParseRefundResponse(resp)
+}
+
+// ValidatePixKey consulta no DICT o vínculo da chave PIX (titular e conta)
 func (p *BBProvider) ValidatePixKey(ctx context.Context, req *providers.ValidatePixKeyRequest) (*providers.ValidatePixKeyResponse, error) {
-	return nil, providers.NewProviderError("NOT_IMPLEMENTED", "Validação de chave não implementada", nil)
+	return providers.LookupDictEntry(ctx, &p.httpClient, p.config.BaseURL+"/pix/v1", req, nil)
 }
 
 // HealthCheck verifica a saúde do provider
@@ -267,14 +359,22 @@ func (p *BBProvider) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ParseCallback autentica e interpreta um callback PIX enviado pelo banco
+func (p *BBProvider) ParseCallback(ctx context.Context, req *providers.CallbackRequest) ([]providers.CallbackNotification, error) {
+	return providers.ParsePixCallback(req, providers.DefaultCallbackSignatureHeader)
+}
+
 // GetSupportedMethods retorna os métodos suportados
 func (p *BBProvider) GetSupportedMethods() []string {
 	return []string{
 		"transfer",
 		"qrcode_static",
 		"qrcode_dynamic",
+		"qrcode_due_date",
 		"get_transfer",
 		"get_qrcode",
+		"refund",
+		"validate_pix_key",
 	}
 }
 
@@ -1,12 +1,10 @@
 package bradesco
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -20,21 +18,19 @@ const (
 	ISPB         = "60746948"
 )
 
+var _ providers.PixProvider = (*BradescoProvider)(nil)
+
 // BradescoProvider implementa a interface PixProvider para o Bradesco
 type BradescoProvider struct {
-	config     domain.ProviderConfig
-	httpClient *http.Client
+	config     providers.ProviderConfig
+	httpClient providers.HTTPClient
 	baseURL    string
 	authURL    string
 }
 
 // NewBradescoProvider cria uma nova instância do provider Bradesco
 func NewBradescoProvider() *BradescoProvider {
-	return &BradescoProvider{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+	return &BradescoProvider{}
 }
 
 func (p *BradescoProvider) GetCode() string {
@@ -45,38 +41,21 @@ func (p *BradescoProvider) GetName() string {
 	return ProviderName
 }
 
-func (p *BradescoProvider) Initialize(config domain.ProviderConfig) error {
+func (p *BradescoProvider) Initialize(config providers.ProviderConfig) error {
+	// Certificado mTLS do merchant é configurado uma única vez por instância
+	httpClient, err := providers.NewHTTPClientFromConfig(config)
+	if err != nil {
+		return err
+	}
+
 	p.config = config
+	p.httpClient = httpClient
 	p.baseURL = config.BaseURL
 	p.authURL = config.AuthURL
-
-	if config.Timeout > 0 {
-		p.httpClient.Timeout = time.Duration(config.Timeout) * time.Second
-	}
-
 	return nil
 }
 
 func (p *BradescoProvider) Authenticate(ctx context.Context, credentials providers.ProviderCredentials) (*providers.AuthToken, error) {
-	// Bradesco usa OAuth 2.0 com mTLS
-	if p.config.RequiresMTLS {
-		cert, err := tls.X509KeyPair(credentials.Certificate, credentials.PrivateKey)
-		if err != nil {
-			return nil, &providers.ProviderError{
-				Code:    "CERT_ERROR",
-				Message: "Erro ao carregar certificado mTLS",
-				Details: map[string]interface{}{"error": err.Error()},
-			}
-		}
-
-		p.httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				Certificates: []tls.Certificate{cert},
-				MinVersion:   tls.VersionTLS12,
-			},
-		}
-	}
-
 	// Requisição OAuth2
 	data := map[string]string{
 		"grant_type":    "client_credentials",
@@ -84,43 +63,13 @@ func (p *BradescoProvider) Authenticate(ctx context.Context, credentials provide
 		"client_secret": credentials.ClientSecret,
 	}
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:    "MARSHAL_ERROR",
-			Message: "Erro ao serializar dados",
-			Details: map[string]interface{}{"error": err.Error()},
-		}
-	}
-	req, err := http.NewRequestWithContext(ctx, "POST", p.authURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+	headers := map[string]string{
+		"Content-Type": "application/json",
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := p.httpClient.Do(req)
+	resp, err := p.httpClient.Post(ctx, p.authURL, data, headers)
 	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "AUTH_ERROR",
-			Message:   "Erro ao autenticar com Bradesco",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			body = []byte("failed to read response body")
-		}
-		return nil, &providers.ProviderError{
-			Code:       "AUTH_FAILED",
-			Message:    "Autenticação falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("AUTH_FAILED", "Autenticação falhou", err)
 	}
 
 	var authResp struct {
@@ -130,8 +79,8 @@ func (p *BradescoProvider) Authenticate(ctx context.Context, credentials provide
 		RefreshToken string `json:"refresh_token,omitempty"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		return nil, err
+	if err := json.Unmarshal(resp, &authResp); err != nil {
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta de autenticação", err)
 	}
 
 	return &providers.AuthToken{
@@ -144,8 +93,7 @@ func (p *BradescoProvider) Authenticate(ctx context.Context, credentials provide
 }
 
 func (p *BradescoProvider) RefreshToken(ctx context.Context, refreshToken string) (*providers.AuthToken, error) {
-	// Implementar refresh token
-	return nil, fmt.Errorf("refresh token não implementado")
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Refresh token não suportado", nil)
 }
 
 func (p *BradescoProvider) CreateTransfer(ctx context.Context, req *providers.TransferRequest) (*providers.TransferResponse, error) {
@@ -189,45 +137,15 @@ func (p *BradescoProvider) CreateTransfer(ctx context.Context, req *providers.Tr
 	}
 	payload["recebedor"] = recebedor
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:    "MARSHAL_ERROR",
-			Message: "Erro ao serializar payload",
-			Details: map[string]interface{}{"error": err.Error()},
-		}
-	}
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	// Token deve ser passado via context ou armazenado
-
-	resp, err := p.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "TRANSFER_ERROR",
-			Message:   "Erro ao criar transferência",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+	ctx = providers.WithIdempotencyHeader(ctx, headers, "X-Idempotency-Key", req.IdempotencyKey)
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := p.httpClient.Post(ctx, endpoint, payload, headers)
 	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, &providers.ProviderError{
-			Code:       "TRANSFER_FAILED",
-			Message:    "Transferência falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("TRANSFER_FAILED", "Transferência falhou", err)
 	}
 
 	var bradescoResp struct {
@@ -240,7 +158,7 @@ func (p *BradescoProvider) CreateTransfer(ctx context.Context, req *providers.Tr
 	}
 
 	if err := json.Unmarshal(body, &bradescoResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	// Mapear status do Bradesco para nosso status
@@ -267,38 +185,17 @@ func (p *BradescoProvider) CreateTransfer(ctx context.Context, req *providers.Tr
 	return response, nil
 }
 
-func (p *BradescoProvider) GetTransfer(ctx context.Context, txID string) (*providers.TransferResponse, error) {
+func (p *BradescoProvider) GetTransfer(ctx context.Context, getReq *providers.GetTransferRequest) (*providers.TransferResponse, error) {
 	// Endpoint: GET /v1/spi/consultar-transferencia/{idTransacao}
-	endpoint := fmt.Sprintf("%s/v1/spi/consultar-transferencia/%s", p.baseURL, txID)
+	endpoint := fmt.Sprintf("%s/v1/spi/consultar-transferencia/%s", p.baseURL, getReq.ProviderTxID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, http.NoBody)
-	if err != nil {
-		return nil, err
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", getReq.AuthToken),
 	}
 
-	resp, err := p.httpClient.Do(req)
+	body, err := p.httpClient.Get(ctx, endpoint, headers)
 	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "QUERY_ERROR",
-			Message:   "Erro ao consultar transferência",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, &providers.ProviderError{
-			Code:       "QUERY_FAILED",
-			Message:    "Consulta falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("QUERY_FAILED", "Consulta falhou", err)
 	}
 
 	var bradescoResp struct {
@@ -310,7 +207,7 @@ func (p *BradescoProvider) GetTransfer(ctx context.Context, txID string) (*provi
 	}
 
 	if err := json.Unmarshal(body, &bradescoResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	return &providers.TransferResponse{
@@ -322,49 +219,60 @@ func (p *BradescoProvider) GetTransfer(ctx context.Context, txID string) (*provi
 	}, nil
 }
 
-func (p *BradescoProvider) CancelTransfer(ctx context.Context, txID string) error {
-	return fmt.Errorf("cancelamento não suportado pelo Bradesco")
+func (p *BradescoProvider) CancelTransfer(ctx context.Context, req *providers.CancelTransferRequest) error {
+	return providers.NewProviderError("NOT_SUPPORTED", "Cancelamento não suportado pelo Bradesco", nil)
 }
 
+// CreateQRCodeStatic gera o BR Code localmente: o QR Code estático só depende da chave PIX
 func (p *BradescoProvider) CreateQRCodeStatic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
-	// TODO: Implementar criação de QR Code estático
-	return nil, fmt.Errorf("QR Code estático não implementado")
+	return providers.NewLocalStaticQRCode(req)
 }
 
 func (p *BradescoProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	if err := providers.RejectDueDate(req); err != nil {
+		return nil, err
+	}
 	// TODO: Implementar criação de QR Code dinâmico
 	return nil, fmt.Errorf("QR Code dinâmico não implementado")
 }
 
-func (p *BradescoProvider) GetQRCode(ctx context.Context, qrCodeID string) (*providers.QRCodeResponse, error) {
+func (p *BradescoProvider) GetQRCode(ctx context.Context, getReq *providers.GetQRCodeRequest) (*providers.QRCodeResponse, error) {
 	return nil, fmt.Errorf("consulta de QR Code não implementada")
 }
 
-func (p *BradescoProvider) ValidatePixKey(ctx context.Context, pixKey string, pixKeyType domain.PixKeyType) (*providers.PixKeyInfo, error) {
+// RequestRefund não é suportado pela API do Bradesco integrada
+func (p *BradescoProvider) RequestRefund(ctx context.Context, req *providers.RefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Devolução não suportada pelo Bradesco", nil)
+}
+
+// GetRefund não é suportado pela API do Bradesco integrada
+func (p *BradescoProvider) GetRefund(ctx context.Context, req *providers.GetRefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Devolução não suportada pelo Bradesco", nil)
+}
+
+func (p *BradescoProvider) ValidatePixKey(ctx context.Context, req *providers.ValidatePixKeyRequest) (*providers.ValidatePixKeyResponse, error) {
 	// TODO: Implementar validação de chave PIX
-	return nil, fmt.Errorf("validação de chave PIX não implementada")
+	return nil, providers.NewProviderError("NOT_IMPLEMENTED", "Validação de chave PIX não implementada", nil)
 }
 
 func (p *BradescoProvider) HealthCheck(ctx context.Context) error {
 	// Fazer uma requisição simples para verificar conectividade
-	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL, http.NoBody)
-	if err != nil {
-		return err
-	}
+	_, err := p.httpClient.Get(ctx, p.baseURL, nil)
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	if resp.StatusCode >= 500 {
-		return fmt.Errorf("provider unhealthy: status %d", resp.StatusCode)
+	// Respostas abaixo de 500 indicam que o provider está no ar
+	var httpErr *providers.HTTPError
+	if err != nil && (!errors.As(err, &httpErr) || httpErr.StatusCode >= http.StatusInternalServerError) {
+		return providers.NewProviderError("HEALTH_CHECK_FAILED", "Provider indisponível", err)
 	}
 
 	return nil
 }
 
+// ParseCallback autentica e interpreta um callback PIX enviado pelo banco
+func (p *BradescoProvider) ParseCallback(ctx context.Context, req *providers.CallbackRequest) ([]providers.CallbackNotification, error) {
+	return providers.ParsePixCallback(req, providers.DefaultCallbackSignatureHeader)
+}
+
 func (p *BradescoProvider) GetSupportedMethods() []string {
 	return []string{"pix_key", "account", "transfer"}
 }
@@ -0,0 +1,248 @@
+package providers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// Códigos de erro de callbacks recebidos dos bancos
+const (
+	ErrCodeCallbackUnauthorized = "CALLBACK_UNAUTHORIZED"
+	ErrCodeCallbackInvalid      = "CALLBACK_INVALID"
+)
+
+// DefaultCallbackSignatureHeader é o header com a assinatura HMAC-SHA256 (hex) do corpo do callback
+const DefaultCallbackSignatureHeader = "X-Signature"
+
+// CallbackRequest representa uma notificação recebida de um banco (webhook PIX da API BACEN)
+type CallbackRequest struct {
+	Body             []byte
+	Headers          map[string]string   // Nomes em minúsculas
+	PeerCertificates []*x509.Certificate // Certificados apresentados pelo banco (mTLS)
+	Auth             CallbackAuth
+}
+
+// Header retorna o valor de um header da requisição (sem diferenciar maiúsculas)
+func (r *CallbackRequest) Header(name string) string {
+	return r.Headers[strings.ToLower(name)]
+}
+
+// CallbackAuth define como as notificações de um provider são autenticadas.
+// Todos os métodos configurados precisam ser atendidos.
+type CallbackAuth struct {
+	HMACSecret       string         // Segredo da assinatura HMAC do corpo
+	ClientCAs        *x509.CertPool // CAs aceitas para o certificado de cliente do banco
+	CertFingerprints []string       // SHA-256 (hex) dos certificados de cliente aceitos
+}
+
+// NewCallbackAuth monta a autenticação de callbacks a partir da configuração do provider.
+// O segredo HMAC é recebido já descriptografado.
+func NewCallbackAuth(config domain.ProviderConfig, hmacSecret string) (CallbackAuth, error) {
+	auth := CallbackAuth{HMACSecret: hmacSecret}
+
+	if config.CallbackCABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CallbackCABundle)) {
+			return CallbackAuth{}, NewProviderError("CA_BUNDLE_INVALID", "Bundle de CAs de callback inválido", nil)
+		}
+		auth.ClientCAs = pool
+	}
+
+	for _, fingerprint := range config.CallbackCertFingerprints {
+		auth.CertFingerprints = append(auth.CertFingerprints, normalizeFingerprint(fingerprint))
+	}
+
+	return auth, nil
+}
+
+// configured indica se ao menos um método de autenticação foi configurado
+func (a CallbackAuth) configured() bool {
+	return a.HMACSecret != "" || a.ClientCAs != nil || len(a.CertFingerprints) > 0
+}
+
+// CallbackNotification representa um PIX recebido informado pelo banco
+type CallbackNotification struct {
+	E2EID         string
+	TxID          string
+	Amount        int64 // Centavos
+	PayerName     string
+	PayerDocument string
+	PayerMessage  string
+	PaidAt        *time.Time
+	Raw           map[string]interface{}
+}
+
+// AuthenticateCallback verifica o certificado de cliente e/ou a assinatura HMAC do callback.
+// Callbacks de providers sem autenticação configurada são sempre rejeitados.
+func AuthenticateCallback(req *CallbackRequest, signatureHeader string) error {
+	auth := req.Auth
+	if !auth.configured() {
+		return NewProviderError(ErrCodeCallbackUnauthorized, "Autenticação de callbacks não configurada para o provider", nil)
+	}
+
+	if auth.ClientCAs != nil || len(auth.CertFingerprints) > 0 {
+		if err := verifyCallbackCertificate(req.PeerCertificates, auth); err != nil {
+			return NewProviderError(ErrCodeCallbackUnauthorized, "Certificado do banco inválido", err)
+		}
+	}
+
+	if auth.HMACSecret != "" {
+		if !verifyCallbackHMAC(req.Body, req.Header(signatureHeader), auth.HMACSecret) {
+			return NewProviderError(ErrCodeCallbackUnauthorized, "Assinatura do callback inválida", nil)
+		}
+	}
+
+	return nil
+}
+
+// ParsePixCallback autentica e interpreta um callback no formato da API PIX do BACEN
+// ({"pix": [{"endToEndId", "txid", "valor", "horario", ...}]})
+func ParsePixCallback(req *CallbackRequest, signatureHeader string) ([]CallbackNotification, error) {
+	if err := AuthenticateCallback(req, signatureHeader); err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Pix []json.RawMessage `json:"pix"`
+	}
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil, NewProviderError(ErrCodeCallbackInvalid, "Corpo do callback inválido", err)
+	}
+	if len(payload.Pix) == 0 {
+		return nil, NewProviderError(ErrCodeCallbackInvalid, "Callback sem PIX", nil)
+	}
+
+	notifications := make([]CallbackNotification, 0, len(payload.Pix))
+	for _, raw := range payload.Pix {
+		var pix struct {
+			EndToEndID  string `json:"endToEndId"`
+			TxID        string `json:"txid"`
+			Valor       string `json:"valor"`
+			Horario     string `json:"horario"`
+			InfoPagador string `json:"infoPagador"`
+			Pagador     struct {
+				CPF  string `json:"cpf"`
+				CNPJ string `json:"cnpj"`
+				Nome string `json:"nome"`
+			} `json:"pagador"`
+		}
+		if err := json.Unmarshal(raw, &pix); err != nil {
+			return nil, NewProviderError(ErrCodeCallbackInvalid, "PIX inválido no callback", err)
+		}
+		if pix.EndToEndID == "" && pix.TxID == "" {
+			return nil, NewProviderError(ErrCodeCallbackInvalid, "PIX sem endToEndId e txid", nil)
+		}
+
+		amount, err := parseAmount(pix.Valor)
+		if err != nil {
+			return nil, NewProviderError(ErrCodeCallbackInvalid, "Valor inválido no callback", err)
+		}
+
+		notification := CallbackNotification{
+			E2EID:         pix.EndToEndID,
+			TxID:          pix.TxID,
+			Amount:        amount,
+			PayerName:     pix.Pagador.Nome,
+			PayerDocument: pix.Pagador.CPF,
+			PayerMessage:  pix.InfoPagador,
+		}
+		if notification.PayerDocument == "" {
+			notification.PayerDocument = pix.Pagador.CNPJ
+		}
+		if pix.Horario != "" {
+			paidAt, err := time.Parse(time.RFC3339, pix.Horario)
+			if err != nil {
+				return nil, NewProviderError(ErrCodeCallbackInvalid, "Horário inválido no callback", err)
+			}
+			notification.PaidAt = &paidAt
+		}
+		_ = json.Unmarshal(raw, &notification.Raw) //nolint:errcheck
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// verifyCallbackCertificate valida o certificado de cliente apresentado pelo banco
+func verifyCallbackCertificate(certs []*x509.Certificate, auth CallbackAuth) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("certificado de cliente ausente")
+	}
+	leaf := certs[0]
+
+	if auth.ClientCAs != nil {
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         auth.ClientCAs,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(auth.CertFingerprints) > 0 {
+		sum := sha256.Sum256(leaf.Raw)
+		fingerprint := hex.EncodeToString(sum[:])
+		for _, allowed := range auth.CertFingerprints {
+			if hmac.Equal([]byte(fingerprint), []byte(allowed)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("certificado %s não autorizado", fingerprint)
+	}
+
+	return nil
+}
+
+// verifyCallbackHMAC compara a assinatura recebida (hex, opcionalmente com prefixo "sha256=") com o HMAC-SHA256 do corpo
+func verifyCallbackHMAC(body []byte, signature, secret string) bool {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+	received, err := hex.DecodeString(signature)
+	if err != nil || len(received) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+// normalizeFingerprint aceita fingerprints com ou sem ":" e em maiúsculas
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// parseAmount converte um valor decimal da API PIX ("110.00") para centavos
+func parseAmount(valor string) (int64, error) {
+	valor = strings.TrimSpace(valor)
+	if valor == "" {
+		return 0, fmt.Errorf("valor ausente")
+	}
+
+	integer, fraction, _ := strings.Cut(valor, ".")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("valor com mais de duas casas decimais: %s", valor)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	cents, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil || cents < 0 || integer == "" {
+		return 0, fmt.Errorf("valor inválido: %s", valor)
+	}
+	return cents, nil
+}
@@ -0,0 +1,176 @@
+package providers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+const testCallbackBody = `{"pix":[{"endToEndId":"E12345678202401011200abcdefghijk","txid":"cob123","valor":"110.50","horario":"2024-01-01T12:00:00.358Z","infoPagador":"pedido 42","pagador":{"cnpj":"12345678000199","nome":"Empresa X"}}]}`
+
+func TestParsePixCallbackWithHMAC(t *testing.T) {
+	body := []byte(testCallbackBody)
+	req := &CallbackRequest{
+		Body:    body,
+		Headers: map[string]string{"x-signature": "sha256=" + signCallback(body, "segredo")},
+		Auth:    CallbackAuth{HMACSecret: "segredo"},
+	}
+
+	notifications, err := ParsePixCallback(req, DefaultCallbackSignatureHeader)
+	if err != nil {
+		t.Fatalf("ParsePixCallback() error = %v", err)
+	}
+	if len(notifications) != 1 {
+		t.Fatalf("notifications = %d, want 1", len(notifications))
+	}
+
+	got := notifications[0]
+	if got.E2EID != "E12345678202401011200abcdefghijk" || got.TxID != "cob123" || got.Amount != 11050 {
+		t.Errorf("notification = %+v", got)
+	}
+	if got.PayerName != "Empresa X" || got.PayerDocument != "12345678000199" || got.PayerMessage != "pedido 42" {
+		t.Errorf("payer = %+v", got)
+	}
+	if got.PaidAt == nil || !got.PaidAt.Equal(time.Date(2024, 1, 1, 12, 0, 0, 358000000, time.UTC)) {
+		t.Errorf("PaidAt = %v", got.PaidAt)
+	}
+}
+
+func TestAuthenticateCallbackRejects(t *testing.T) {
+	body := []byte(testCallbackBody)
+
+	tests := []struct {
+		name string
+		req  *CallbackRequest
+	}{
+		{"no auth configured", &CallbackRequest{Body: body}},
+		{"missing signature", &CallbackRequest{Body: body, Auth: CallbackAuth{HMACSecret: "segredo"}}},
+		{"wrong secret", &CallbackRequest{
+			Body:    body,
+			Headers: map[string]string{"x-signature": signCallback(body, "outro")},
+			Auth:    CallbackAuth{HMACSecret: "segredo"},
+		}},
+		{"tampered body", &CallbackRequest{
+			Body:    []byte(strings.Replace(testCallbackBody, "110.50", "999.00", 1)),
+			Headers: map[string]string{"x-signature": signCallback(body, "segredo")},
+			Auth:    CallbackAuth{HMACSecret: "segredo"},
+		}},
+		{"missing client certificate", &CallbackRequest{Body: body, Auth: CallbackAuth{CertFingerprints: []string{"00"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthenticateCallback(tt.req, DefaultCallbackSignatureHeader)
+			assertProviderErrorCode(t, err, ErrCodeCallbackUnauthorized)
+		})
+	}
+}
+
+func TestAuthenticateCallbackClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	certPEM, _ := ca.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	cert := parseTestCertificate(t, certPEM)
+	sum := sha256.Sum256(cert.Raw)
+	fingerprint := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	otherCA := newTestCA(t)
+	otherPEM, _ := otherCA.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	other := parseTestCertificate(t, otherPEM)
+
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+
+	tests := []struct {
+		name    string
+		config  domain.ProviderConfig
+		cert    *x509.Certificate
+		wantErr bool
+	}{
+		{"trusted CA", domain.ProviderConfig{CallbackCABundle: caPEM}, cert, false},
+		{"untrusted CA", domain.ProviderConfig{CallbackCABundle: caPEM}, other, true},
+		{"pinned fingerprint", domain.ProviderConfig{CallbackCertFingerprints: []string{fingerprint}}, cert, false},
+		{"unknown fingerprint", domain.ProviderConfig{CallbackCertFingerprints: []string{fingerprint}}, other, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewCallbackAuth(tt.config, "")
+			if err != nil {
+				t.Fatalf("NewCallbackAuth() error = %v", err)
+			}
+
+			err = AuthenticateCallback(&CallbackRequest{Body: []byte(testCallbackBody), PeerCertificates: []*x509.Certificate{tt.cert}, Auth: auth}, DefaultCallbackSignatureHeader)
+			if tt.wantErr {
+				assertProviderErrorCode(t, err, ErrCodeCallbackUnauthorized)
+			} else if err != nil {
+				t.Errorf("AuthenticateCallback() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePixCallbackInvalidPayload(t *testing.T) {
+	for _, body := range []string{
+		`not json`,
+		`{"pix":[]}`,
+		`{"pix":[{"valor":"1.00"}]}`,
+		`{"pix":[{"txid":"a","valor":"1,00"}]}`,
+		`{"pix":[{"txid":"a","valor":"1.00","horario":"ontem"}]}`,
+	} {
+		req := &CallbackRequest{
+			Body:    []byte(body),
+			Headers: map[string]string{"x-signature": signCallback([]byte(body), "segredo")},
+			Auth:    CallbackAuth{HMACSecret: "segredo"},
+		}
+		_, err := ParsePixCallback(req, DefaultCallbackSignatureHeader)
+		assertProviderErrorCode(t, err, ErrCodeCallbackInvalid)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		valor   string
+		want    int64
+		wantErr bool
+	}{
+		{"110.00", 11000, false},
+		{"0.01", 1, false},
+		{"5", 500, false},
+		{"5.5", 550, false},
+		{"", 0, true},
+		{"1.001", 0, true},
+		{"-1.00", 0, true},
+		{".50", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.valor)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAmount(%q) = %d, %v; want %d, wantErr %v", tt.valor, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func signCallback(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func parseTestCertificate(t *testing.T, certPEM []byte) *x509.Certificate {
+	t.Helper()
+
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return cert
+}
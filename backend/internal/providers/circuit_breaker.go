@@ -0,0 +1,291 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// CircuitState representa o estado de um circuit breaker
+type CircuitState string
+
+// Estados do circuit breaker
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// ErrCodeCircuitOpen indica que o provider foi ignorado por estar com o circuito aberto
+const ErrCodeCircuitOpen = "CIRCUIT_OPEN"
+
+// CircuitBreakerConfig define os limites do circuit breaker
+type CircuitBreakerConfig struct {
+	FailureThreshold    int           // Falhas consecutivas para abrir o circuito
+	OpenTimeout         time.Duration // Tempo aberto antes de permitir chamadas de teste
+	HalfOpenMaxCalls    int           // Chamadas de teste simultâneas em half-open
+	SuccessThreshold    int           // Sucessos consecutivos em half-open para fechar o circuito
+	PerMerchantProvider bool          // Um circuito por merchant-provider em vez de por provider
+}
+
+// DefaultCircuitBreakerConfig retorna a configuração padrão do circuit breaker
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 2,
+	}
+}
+
+// HealthStatusUpdater persiste o status de saúde de um provider
+type HealthStatusUpdater interface {
+	UpdateHealthStatus(ctx context.Context, id uuid.UUID, status string) error
+}
+
+// CircuitBreakers mantém os circuit breakers dos providers
+type CircuitBreakers struct {
+	mu       sync.Mutex
+	config   CircuitBreakerConfig
+	store    HealthStatusUpdater
+	breakers map[uuid.UUID]*circuitBreaker
+	now      func() time.Time
+}
+
+type circuitBreaker struct {
+	providerID       uuid.UUID
+	state            CircuitState
+	failures         int
+	successes        int
+	halfOpenInFlight int
+	openedAt         time.Time
+}
+
+// NewCircuitBreakers cria o conjunto de circuit breakers
+func NewCircuitBreakers(config CircuitBreakerConfig, store HealthStatusUpdater) *CircuitBreakers {
+	return &CircuitBreakers{
+		config:   config,
+		store:    store,
+		breakers: make(map[uuid.UUID]*circuitBreaker),
+		now:      time.Now,
+	}
+}
+
+// Ready indica se o provider pode ser selecionado (circuito fechado, ou aberto há tempo suficiente para teste)
+func (b *CircuitBreakers) Ready(mp *domain.MerchantProvider) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.breakerFor(mp)
+	switch cb.state {
+	case CircuitOpen:
+		return b.now().Sub(cb.openedAt) >= b.config.OpenTimeout
+	case CircuitHalfOpen:
+		return cb.halfOpenInFlight < b.config.HalfOpenMaxCalls
+	default:
+		return true
+	}
+}
+
+// Allow reserva uma chamada ao provider, ou retorna CIRCUIT_OPEN se o circuito não permitir
+func (b *CircuitBreakers) Allow(mp *domain.MerchantProvider) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.breakerFor(mp)
+
+	if cb.state == CircuitOpen {
+		if b.now().Sub(cb.openedAt) < b.config.OpenTimeout {
+			return b.openError(mp)
+		}
+		b.transition(cb, CircuitHalfOpen)
+	}
+
+	if cb.state == CircuitHalfOpen {
+		if cb.halfOpenInFlight >= b.config.HalfOpenMaxCalls {
+			return b.openError(mp)
+		}
+		cb.halfOpenInFlight++
+	}
+
+	return nil
+}
+
+// Record registra o resultado de uma chamada autorizada por Allow
+func (b *CircuitBreakers) Record(mp *domain.MerchantProvider, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cb := b.breakerFor(mp)
+	failed := isProviderFailure(err)
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if cb.halfOpenInFlight > 0 {
+			cb.halfOpenInFlight--
+		}
+		if failed {
+			b.transition(cb, CircuitOpen)
+			return
+		}
+		cb.successes++
+		if cb.successes >= b.config.SuccessThreshold {
+			b.transition(cb, CircuitClosed)
+		}
+	case CircuitClosed:
+		if !failed {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= b.config.FailureThreshold {
+			b.transition(cb, CircuitOpen)
+		}
+	}
+}
+
+// RecordProbe aplica o resultado de um health check aos circuitos do provider: uma falha abre
+// o circuito; um sucesso libera chamadas de teste (half-open) e conta para fechar o circuito.
+func (b *CircuitBreakers) RecordProbe(provider *domain.Provider, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var circuits []*circuitBreaker
+	if b.config.PerMerchantProvider {
+		for _, cb := range b.breakers {
+			if cb.providerID == provider.ID {
+				circuits = append(circuits, cb)
+			}
+		}
+	} else {
+		circuits = append(circuits, b.breakerForKey(provider.ID, provider))
+	}
+
+	for _, cb := range circuits {
+		switch {
+		case err != nil:
+			b.transition(cb, CircuitOpen)
+			cb.openedAt = b.now()
+		case cb.state == CircuitOpen:
+			b.transition(cb, CircuitHalfOpen)
+		case cb.state == CircuitHalfOpen:
+			cb.successes++
+			if cb.successes >= b.config.SuccessThreshold {
+				b.transition(cb, CircuitClosed)
+			}
+		}
+	}
+}
+
+// persistsHealthStatus indica se as transições do circuito são as únicas a persistir o status de saúde
+func (b *CircuitBreakers) persistsHealthStatus() bool {
+	return b != nil && b.store != nil && !b.config.PerMerchantProvider
+}
+
+// State retorna o estado atual do circuito do merchant-provider
+func (b *CircuitBreakers) State(mp *domain.MerchantProvider) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.breakerFor(mp).state
+}
+
+// breakerFor retorna (ou cria) o circuito; o estado inicial reflete o último status persistido
+func (b *CircuitBreakers) breakerFor(mp *domain.MerchantProvider) *circuitBreaker {
+	key := mp.ProviderID
+	if b.config.PerMerchantProvider {
+		key = mp.ID
+	}
+	return b.breakerForKey(key, &mp.Provider)
+}
+
+func (b *CircuitBreakers) breakerForKey(key uuid.UUID, provider *domain.Provider) *circuitBreaker {
+	cb, ok := b.breakers[key]
+	if !ok {
+		cb = &circuitBreaker{providerID: provider.ID, state: CircuitClosed}
+		if provider.HealthStatus == domain.ProviderHealthUnhealthy {
+			cb.state = CircuitOpen
+			cb.openedAt = b.now()
+		}
+		b.breakers[key] = cb
+	}
+	return cb
+}
+
+// transition altera o estado do circuito e persiste o novo status de saúde do provider
+func (b *CircuitBreakers) transition(cb *circuitBreaker, state CircuitState) {
+	if cb.state == state {
+		return
+	}
+
+	cb.state = state
+	cb.failures = 0
+	cb.successes = 0
+	cb.halfOpenInFlight = 0
+	if state == CircuitOpen {
+		cb.openedAt = b.now()
+	}
+
+	log.Printf("Circuit breaker do provider %s: %s", cb.providerID, state)
+
+	// Circuitos por merchant-provider não representam a saúde global do provider
+	if b.store == nil || b.config.PerMerchantProvider {
+		return
+	}
+
+	status := circuitHealthStatus(state)
+	go func(providerID uuid.UUID) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := b.store.UpdateHealthStatus(ctx, providerID, status); err != nil {
+			log.Printf("Aviso: falha ao persistir status de saúde do provider %s: %v", providerID, err)
+		}
+	}(cb.providerID)
+}
+
+func (b *CircuitBreakers) openError(mp *domain.MerchantProvider) error {
+	return &ProviderError{
+		Code:      ErrCodeCircuitOpen,
+		Message:   "Provider " + mp.Provider.Code + " temporariamente indisponível (circuit breaker aberto)",
+		Retryable: true,
+	}
+}
+
+// circuitHealthStatus converte o estado do circuito em status de saúde do provider
+func circuitHealthStatus(state CircuitState) string {
+	switch state {
+	case CircuitOpen:
+		return domain.ProviderHealthUnhealthy
+	case CircuitHalfOpen:
+		return domain.ProviderHealthDegraded
+	default:
+		return domain.ProviderHealthHealthy
+	}
+}
+
+// isProviderFailure indica se o erro representa indisponibilidade do provider.
+// Erros de negócio (chave inválida, saldo insuficiente) mostram que o banco está respondendo.
+func isProviderFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if httpErr, ok := asHTTPError(err); ok {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.StatusCode != 0 {
+		return providerErr.StatusCode == http.StatusTooManyRequests || providerErr.StatusCode >= 500
+	}
+
+	// Sem resposta HTTP: apenas falhas de rede e timeouts contam
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
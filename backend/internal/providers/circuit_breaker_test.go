@@ -0,0 +1,275 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestCircuitBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	store := newMockHealthStore()
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1, SuccessThreshold: 1}, store)
+	mp := newMerchantProvider("bank", 1, domain.ProviderHealthHealthy)
+
+	unavailable := NewHTTPError(503, nil, nil)
+	for i := 0; i < 2; i++ {
+		if err := breakers.Allow(&mp); err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		breakers.Record(&mp, unavailable)
+	}
+
+	// Um sucesso zera o contador de falhas consecutivas
+	breakers.Record(&mp, nil)
+	breakers.Record(&mp, unavailable)
+	breakers.Record(&mp, unavailable)
+	if state := breakers.State(&mp); state != CircuitClosed {
+		t.Fatalf("State() = %s, want closed", state)
+	}
+
+	breakers.Record(&mp, unavailable)
+	if state := breakers.State(&mp); state != CircuitOpen {
+		t.Fatalf("State() = %s, want open", state)
+	}
+
+	assertProviderErrorCode(t, breakers.Allow(&mp), ErrCodeCircuitOpen)
+	if breakers.Ready(&mp) {
+		t.Error("Ready() = true, want false while open")
+	}
+
+	if status := store.wait(t); status != domain.ProviderHealthUnhealthy {
+		t.Errorf("persisted status = %s, want unhealthy", status)
+	}
+}
+
+func TestCircuitBreakerIgnoresBusinessErrors(t *testing.T) {
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1, SuccessThreshold: 1}, nil)
+	mp := newMerchantProvider("bank", 1, domain.ProviderHealthHealthy)
+
+	businessErrors := []error{
+		NewProviderError(ErrCodeInvalidPixKey, "Chave inválida", NewHTTPError(400, nil, nil)),
+		NewHTTPError(404, nil, nil),
+		&ProviderError{Code: ErrCodeInsufficientBalance, Message: "Saldo insuficiente"},
+	}
+	for _, err := range businessErrors {
+		breakers.Record(&mp, err)
+	}
+
+	if state := breakers.State(&mp); state != CircuitClosed {
+		t.Errorf("State() = %s, want closed", state)
+	}
+
+	breakers.Record(&mp, context.DeadlineExceeded)
+	if state := breakers.State(&mp); state != CircuitOpen {
+		t.Errorf("State() after timeout = %s, want open", state)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	store := newMockHealthStore()
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 30 * time.Second, HalfOpenMaxCalls: 1, SuccessThreshold: 2}, store)
+	now := time.Now()
+	breakers.now = func() time.Time { return now }
+
+	mp := newMerchantProvider("bank", 1, domain.ProviderHealthHealthy)
+	breakers.Record(&mp, NewHTTPError(502, nil, nil))
+	store.wait(t)
+
+	now = now.Add(31 * time.Second)
+	if !breakers.Ready(&mp) {
+		t.Fatal("Ready() = false, want true after open timeout")
+	}
+
+	// Apenas uma chamada de teste por vez em half-open
+	if err := breakers.Allow(&mp); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if status := store.wait(t); status != domain.ProviderHealthDegraded {
+		t.Errorf("persisted status = %s, want degraded", status)
+	}
+	assertProviderErrorCode(t, breakers.Allow(&mp), ErrCodeCircuitOpen)
+
+	breakers.Record(&mp, nil)
+	if state := breakers.State(&mp); state != CircuitHalfOpen {
+		t.Fatalf("State() = %s, want half_open", state)
+	}
+
+	if err := breakers.Allow(&mp); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	breakers.Record(&mp, nil)
+	if state := breakers.State(&mp); state != CircuitClosed {
+		t.Fatalf("State() = %s, want closed", state)
+	}
+	if status := store.wait(t); status != domain.ProviderHealthHealthy {
+		t.Errorf("persisted status = %s, want healthy", status)
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 30 * time.Second, HalfOpenMaxCalls: 1, SuccessThreshold: 1}, nil)
+	now := time.Now()
+	breakers.now = func() time.Time { return now }
+
+	mp := newMerchantProvider("bank", 1, domain.ProviderHealthUnhealthy)
+	if state := breakers.State(&mp); state != CircuitOpen {
+		t.Fatalf("State() = %s, want open for unhealthy provider", state)
+	}
+
+	now = now.Add(time.Minute)
+	if err := breakers.Allow(&mp); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	breakers.Record(&mp, NewHTTPError(500, nil, nil))
+
+	if state := breakers.State(&mp); state != CircuitOpen {
+		t.Errorf("State() = %s, want open", state)
+	}
+	if breakers.Ready(&mp) {
+		t.Error("Ready() = true, want false right after reopening")
+	}
+}
+
+func TestCircuitBreakerRecordProbe(t *testing.T) {
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 5, OpenTimeout: time.Hour, HalfOpenMaxCalls: 1, SuccessThreshold: 2}, nil)
+	mp := newMerchantProvider("bank", 1, domain.ProviderHealthHealthy)
+
+	breakers.RecordProbe(&mp.Provider, errors.New("health check failed"))
+	if state := breakers.State(&mp); state != CircuitOpen || breakers.Ready(&mp) {
+		t.Fatalf("State() = %s, want open and not ready after failed probe", state)
+	}
+
+	// Sucesso no health check libera chamadas de teste sem esperar o OpenTimeout
+	breakers.RecordProbe(&mp.Provider, nil)
+	if state := breakers.State(&mp); state != CircuitHalfOpen || !breakers.Ready(&mp) {
+		t.Fatalf("State() = %s, want half-open and ready after successful probe", state)
+	}
+
+	breakers.RecordProbe(&mp.Provider, nil)
+	breakers.RecordProbe(&mp.Provider, nil)
+	if state := breakers.State(&mp); state != CircuitClosed {
+		t.Errorf("State() = %s, want closed after %d successful probes", state, 2)
+	}
+}
+
+func TestCircuitBreakerPerMerchantProvider(t *testing.T) {
+	store := newMockHealthStore()
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1, SuccessThreshold: 1, PerMerchantProvider: true}, store)
+
+	first := newMerchantProvider("bank", 1, domain.ProviderHealthHealthy)
+	second := first
+	second.ID = uuid.New()
+
+	breakers.Record(&first, NewHTTPError(503, nil, nil))
+
+	if state := breakers.State(&first); state != CircuitOpen {
+		t.Errorf("State(first) = %s, want open", state)
+	}
+	if state := breakers.State(&second); state != CircuitClosed {
+		t.Errorf("State(second) = %s, want closed", state)
+	}
+
+	select {
+	case status := <-store.updates:
+		t.Errorf("persisted status = %s, want no update for per merchant-provider circuits", status)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestExecuteWithFallbackSkipsOpenCircuit(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("primary", "Primary"))
+	registry.Register(mockFactory("secondary", "Secondary"))
+
+	lister := &mockMerchantProviderLister{mps: []domain.MerchantProvider{
+		newMerchantProvider("primary", 10, domain.ProviderHealthHealthy),
+		newMerchantProvider("secondary", 1, domain.ProviderHealthHealthy),
+	}}
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1, SuccessThreshold: 1}, nil)
+	manager := NewProviderManager(registry, lister, nil, breakers)
+
+	primaryDown := func(provider PixProvider, mp *domain.MerchantProvider) error {
+		if provider.GetCode() == "primary" {
+			return NewProviderError(ErrCodeBankUnavailable, "Indisponível", NewHTTPError(503, nil, nil))
+		}
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		attempts, err := manager.ExecuteWithFallback(context.Background(), uuid.New(), "", primaryDown)
+		if err != nil {
+			t.Fatalf("ExecuteWithFallback() error = %v", err)
+		}
+		if len(attempts) != 2 {
+			t.Fatalf("attempts = %+v, want primary and secondary", attempts)
+		}
+	}
+
+	var called []string
+	attempts, err := manager.ExecuteWithFallback(context.Background(), uuid.New(), "", func(provider PixProvider, mp *domain.MerchantProvider) error {
+		called = append(called, provider.GetCode())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithFallback() error = %v", err)
+	}
+	if len(called) != 1 || called[0] != "secondary" || len(attempts) != 1 {
+		t.Errorf("called = %v, want [secondary]", called)
+	}
+}
+
+func TestIsProviderFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"http 503", NewHTTPError(503, nil, nil), true},
+		{"http 429", NewHTTPError(429, nil, nil), true},
+		{"http 400", NewHTTPError(400, nil, nil), false},
+		{"wrapped http 500", NewProviderError(ErrCodeBankUnavailable, "erro", NewHTTPError(500, nil, nil)), true},
+		{"provider error with status", &ProviderError{Code: ErrCodeRateLimited, StatusCode: 429}, true},
+		{"business error", &ProviderError{Code: ErrCodeInvalidPixKey}, false},
+		{"deadline", context.DeadlineExceeded, true},
+		{"generic error", errors.New("decode error"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isProviderFailure(tt.err); got != tt.want {
+				t.Errorf("isProviderFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type mockHealthStore struct {
+	updates chan string
+}
+
+func newMockHealthStore() *mockHealthStore {
+	return &mockHealthStore{updates: make(chan string, 10)}
+}
+
+func (m *mockHealthStore) UpdateHealthStatus(ctx context.Context, id uuid.UUID, status string) error {
+	m.updates <- status
+	return nil
+}
+
+// wait aguarda a persistência assíncrona do status de saúde
+func (m *mockHealthStore) wait(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case status := <-m.updates:
+		return status
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for health status update")
+		return ""
+	}
+}
@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// Tipos de chave no DICT
+var dictKeyTypes = map[string]domain.PixKeyType{
+	"CPF":   domain.PixKeyTypeCPF,
+	"CNPJ":  domain.PixKeyTypeCNPJ,
+	"EMAIL": domain.PixKeyTypeEmail,
+	"PHONE": domain.PixKeyTypePhone,
+	"EVP":   domain.PixKeyTypeRandom,
+}
+
+// Tipos de conta no DICT (ISO 20022)
+var dictAccountTypes = map[string]string{
+	"CACC": "checking",
+	"SVGS": "savings",
+	"SLRY": "salary",
+	"TRAN": "payment",
+}
+
+// DictKeyPath retorna o caminho da consulta de vínculo de chave: /dict/{chave}
+func DictKeyPath(key string) string {
+	return fmt.Sprintf("/dict/%s", url.PathEscape(key))
+}
+
+// LookupDictEntry consulta o vínculo da chave em baseURL + /dict/{chave}, no formato comum aos
+// bancos. headers traz os headers específicos do banco; o Authorization é incluído aqui.
+func LookupDictEntry(ctx context.Context, client *HTTPClient, baseURL string, req *ValidatePixKeyRequest, headers map[string]string) (*ValidatePixKeyResponse, error) {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", req.AuthToken)
+
+	body, err := client.Get(ctx, baseURL+DictKeyPath(req.PixKey), headers)
+	if err != nil {
+		return nil, NewProviderError("VALIDATE_FAILED", "Falha ao consultar chave PIX", err)
+	}
+
+	return ParseDictEntry(body)
+}
+
+// ParseDictEntry interpreta o vínculo de chave retornado no formato da API do DICT
+func ParseDictEntry(body []byte) (*ValidatePixKeyResponse, error) {
+	var entry struct {
+		Key     string `json:"Key"`
+		KeyType string `json:"KeyType"`
+		Account struct {
+			Participant   string `json:"Participant"`
+			Branch        string `json:"Branch"`
+			AccountNumber string `json:"AccountNumber"`
+			AccountType   string `json:"AccountType"`
+		} `json:"Account"`
+		Owner struct {
+			Type        string `json:"Type"` // NATURAL_PERSON ou LEGAL_PERSON
+			TaxIDNumber string `json:"TaxIdNumber"`
+			Name        string `json:"Name"`
+			TradeName   string `json:"TradeName"`
+		} `json:"Owner"`
+	}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return nil, NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
+	}
+	if entry.Key == "" || entry.Account.Participant == "" {
+		return nil, NewProviderError("PARSE_ERROR", "Vínculo de chave incompleto", nil)
+	}
+
+	accountType, ok := dictAccountTypes[strings.ToUpper(entry.Account.AccountType)]
+	if !ok {
+		accountType = strings.ToLower(entry.Account.AccountType)
+	}
+
+	return &ValidatePixKeyResponse{
+		Valid:       true,
+		PixKey:      entry.Key,
+		PixKeyType:  dictKeyTypes[strings.ToUpper(entry.KeyType)],
+		Name:        entry.Owner.Name,
+		Document:    entry.Owner.TaxIDNumber,
+		ISPB:        entry.Account.Participant,
+		AccountType: accountType,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestDictKeyPath(t *testing.T) {
+	if got := DictKeyPath("+5511987654321"); got != "/dict/+5511987654321" {
+		t.Errorf("DictKeyPath(phone) = %s", got)
+	}
+	if got := DictKeyPath("a/b@x.com"); got != "/dict/a%2Fb@x.com" {
+		t.Errorf("DictKeyPath(email) = %s", got)
+	}
+}
+
+func TestParseDictEntry(t *testing.T) {
+	body := []byte(`{
+		"Key": "joao@example.com",
+		"KeyType": "EMAIL",
+		"Account": {"Participant": "00000000", "Branch": "0001", "AccountNumber": "123456", "AccountType": "CACC"},
+		"Owner": {"Type": "NATURAL_PERSON", "TaxIdNumber": "52998224725", "Name": "João da Silva"}
+	}`)
+
+	resp, err := ParseDictEntry(body)
+	if err != nil {
+		t.Fatalf("ParseDictEntry() error = %v", err)
+	}
+	if !resp.Valid || resp.PixKey != "joao@example.com" || resp.PixKeyType != domain.PixKeyTypeEmail {
+		t.Errorf("key = %+v", resp)
+	}
+	if resp.Name != "João da Silva" || resp.Document != "52998224725" || resp.ISPB != "00000000" || resp.AccountType != "checking" {
+		t.Errorf("owner/account = %+v", resp)
+	}
+}
+
+func TestParseDictEntryRejectsIncompleteEntry(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid json":    `{`,
+		"missing key":     `{"Account": {"Participant": "00000000"}}`,
+		"missing account": `{"Key": "joao@example.com"}`,
+	} {
+		if _, err := ParseDictEntry([]byte(body)); err == nil {
+			t.Errorf("%s: ParseDictEntry() error = nil", name)
+		} else {
+			assertProviderErrorCode(t, err, "PARSE_ERROR")
+		}
+	}
+}
+
+func TestLookupDictEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pix/v1/dict/joao@example.com" || r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Application-Key") != "client" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Key": "joao@example.com", "KeyType": "EMAIL", "Account": {"Participant": "00000000"}}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(5, false)
+	req := &ValidatePixKeyRequest{PixKey: "joao@example.com", AuthToken: "token", ClientID: "client"}
+
+	resp, err := LookupDictEntry(context.Background(), &client, server.URL+"/pix/v1", req, map[string]string{"X-Application-Key": req.ClientID})
+	if err != nil {
+		t.Fatalf("LookupDictEntry() error = %v", err)
+	}
+	if resp.PixKey != "joao@example.com" || resp.ISPB != "00000000" {
+		t.Errorf("entry = %+v", resp)
+	}
+
+	if _, err := LookupDictEntry(context.Background(), &client, server.URL+"/pix/v1", req, nil); err == nil {
+		t.Error("LookupDictEntry() without bank header error = nil")
+	} else {
+		assertProviderErrorCode(t, err, ErrCodeInvalidRequest)
+	}
+}
@@ -0,0 +1,271 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Códigos normalizados de erro de provider
+const (
+	ErrCodeInsufficientBalance = "INSUFFICIENT_BALANCE"
+	ErrCodeInvalidPixKey       = "INVALID_PIX_KEY"
+	ErrCodeInvalidAccount      = "INVALID_ACCOUNT"
+	ErrCodeLimitExceeded       = "LIMIT_EXCEEDED"
+	ErrCodeDuplicate           = "DUPLICATE_TRANSACTION"
+	ErrCodeInvalidRequest      = "INVALID_REQUEST"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeRateLimited         = "RATE_LIMITED"
+	ErrCodeBankUnavailable     = "BANK_UNAVAILABLE"
+	ErrCodeParseError          = "PARSE_ERROR"
+)
+
+// handshakeErrorCodes são falhas de TLS detectadas antes do envio da requisição
+var handshakeErrorCodes = map[string]bool{
+	"MTLS_NOT_CONFIGURED":          true,
+	"MTLS_HANDSHAKE_FAILED":        true,
+	"CERTIFICATE_PIN_MISMATCH":     true,
+	"SERVER_CERTIFICATE_EXPIRED":   true,
+	"SERVER_CERTIFICATE_UNTRUSTED": true,
+	"SERVER_CERTIFICATE_MISMATCH":  true,
+}
+
+// HTTPError representa uma resposta de erro (4xx/5xx) de um provider
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Problem    *Problem // Corpo RFC 7807, quando presente
+}
+
+// Problem representa o corpo de erro padrão da API PIX do BACEN (RFC 7807)
+type Problem struct {
+	Type          string     `json:"type"`
+	Title         string     `json:"title"`
+	Status        int        `json:"status"`
+	Detail        string     `json:"detail"`
+	CorrelationID string     `json:"correlationId,omitempty"`
+	Violacoes     []Violacao `json:"violacoes,omitempty"`
+}
+
+// Violacao representa uma violação de campo em um Problem
+type Violacao struct {
+	Razao       string `json:"razao"`
+	Propriedade string `json:"propriedade"`
+	Valor       string `json:"valor,omitempty"`
+}
+
+// NewHTTPError cria um erro HTTP a partir da resposta do provider
+func NewHTTPError(statusCode int, header http.Header, body []byte) *HTTPError {
+	return &HTTPError{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       body,
+		Problem:    parseProblem(body),
+	}
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, string(e.Body))
+}
+
+// parseProblem interpreta o corpo como RFC 7807 (ou o formato codigo/mensagem usado por alguns bancos)
+func parseProblem(body []byte) *Problem {
+	var raw struct {
+		Problem
+		Codigo   string `json:"codigo"`
+		Mensagem string `json:"mensagem"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+
+	problem := raw.Problem
+	if problem.Title == "" {
+		problem.Title = raw.Codigo
+	}
+	if problem.Detail == "" {
+		problem.Detail = raw.Mensagem
+	}
+
+	if problem.Type == "" && problem.Title == "" && problem.Detail == "" && len(problem.Violacoes) == 0 {
+		return nil
+	}
+	return &problem
+}
+
+// spiReasonCodes mapeia códigos de rejeição do SPI (ISO 20022) para códigos normalizados
+var spiReasonCodes = map[string]string{
+	"AM04": ErrCodeInsufficientBalance,
+	"AM02": ErrCodeLimitExceeded,
+	"AM09": ErrCodeInvalidRequest,
+	"AC03": ErrCodeInvalidAccount,
+	"AC06": ErrCodeInvalidAccount,
+	"AC07": ErrCodeInvalidAccount,
+	"AC14": ErrCodeInvalidAccount,
+	"AB03": ErrCodeBankUnavailable,
+	"AB09": ErrCodeBankUnavailable,
+	"ED05": ErrCodeBankUnavailable,
+	"DS27": ErrCodeInvalidPixKey,
+}
+
+// problemTypes mapeia o sufixo do "type" dos erros da API PIX para códigos normalizados
+var problemTypes = map[string]string{
+	"RequisicaoInvalida":    ErrCodeInvalidRequest,
+	"CobOperacaoInvalida":   ErrCodeInvalidRequest,
+	"CobVOperacaoInvalida":  ErrCodeInvalidRequest,
+	"PixOperacaoInvalida":   ErrCodeInvalidRequest,
+	"AcessoNegado":          ErrCodeForbidden,
+	"NaoEncontrado":         ErrCodeNotFound,
+	"CobNaoEncontrado":      ErrCodeNotFound,
+	"CobVNaoEncontrada":     ErrCodeNotFound,
+	"PixNaoEncontrado":      ErrCodeNotFound,
+	"ServicoIndisponivel":   ErrCodeBankUnavailable,
+	"ErroInternoDoServidor": ErrCodeBankUnavailable,
+}
+
+// ClassifyHTTPError converte uma resposta de erro em código normalizado e indica se a operação
+// pode ser tentada em outro provider. Apenas respostas que garantem que a requisição não foi
+// processada (429, 503) são retentáveis; 500, 502 e 504 são ambíguos para transferências.
+func ClassifyHTTPError(httpErr *HTTPError) (code string, retryable bool) {
+	switch httpErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		retryable = true
+	}
+
+	if problem := httpErr.Problem; problem != nil {
+		if code := classifyProblem(problem); code != "" {
+			return code, retryable
+		}
+	}
+
+	switch {
+	case httpErr.StatusCode == http.StatusUnauthorized:
+		return ErrCodeUnauthorized, false
+	case httpErr.StatusCode == http.StatusForbidden:
+		return ErrCodeForbidden, false
+	case httpErr.StatusCode == http.StatusNotFound:
+		return ErrCodeNotFound, false
+	case httpErr.StatusCode == http.StatusConflict:
+		return ErrCodeDuplicate, false
+	case httpErr.StatusCode == http.StatusTooManyRequests:
+		return ErrCodeRateLimited, retryable
+	case httpErr.StatusCode >= 500:
+		return ErrCodeBankUnavailable, retryable
+	default:
+		return ErrCodeInvalidRequest, false
+	}
+}
+
+// classifyProblem procura códigos SPI, tipo do erro e palavras-chave no corpo RFC 7807
+func classifyProblem(problem *Problem) string {
+	texts := []string{problem.Title, problem.Detail}
+	for _, v := range problem.Violacoes {
+		texts = append(texts, v.Razao)
+	}
+
+	for _, text := range texts {
+		for _, word := range strings.FieldsFunc(strings.ToUpper(text), isNotAlphanumeric) {
+			if code, ok := spiReasonCodes[word]; ok {
+				return code
+			}
+		}
+	}
+
+	typeCode := problemTypes[problem.Type[strings.LastIndex(problem.Type, "/")+1:]]
+	if typeCode != "" && typeCode != ErrCodeInvalidRequest {
+		return typeCode
+	}
+
+	for _, text := range texts {
+		lower := strings.ToLower(text)
+		switch {
+		case strings.Contains(lower, "saldo insuficiente"):
+			return ErrCodeInsufficientBalance
+		case strings.Contains(lower, "chave") && (strings.Contains(lower, "inválida") || strings.Contains(lower, "invalida") || strings.Contains(lower, "não encontrada") || strings.Contains(lower, "nao encontrada")):
+			return ErrCodeInvalidPixKey
+		case strings.Contains(lower, "limite"):
+			return ErrCodeLimitExceeded
+		}
+	}
+
+	// Tipos genéricos (requisição inválida) só são usados se nada mais específico foi encontrado
+	return typeCode
+}
+
+func isNotAlphanumeric(r rune) bool {
+	return (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+}
+
+// errorDetails monta os detalhes de um ProviderError a partir da resposta HTTP
+func errorDetails(httpErr *HTTPError) map[string]interface{} {
+	details := map[string]interface{}{
+		"status":   httpErr.StatusCode,
+		"response": string(httpErr.Body),
+	}
+	if httpErr.Problem != nil {
+		details["problem"] = httpErr.Problem
+	}
+	return details
+}
+
+// asHTTPError extrai o HTTPError de uma cadeia de erros
+func asHTTPError(err error) (*HTTPError, bool) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+	return nil, false
+}
+
+// OutcomeUnknown indica se não é possível saber se o banco processou a requisição:
+// respostas 5xx ambíguas (500, 502, 504), timeouts, conexões interrompidas após o envio
+// e respostas de sucesso ilegíveis. Falhas anteriores ao envio (conexão recusada, circuito
+// aberto, handshake TLS) e respostas 4xx, 429 e 503 têm resultado conhecido.
+func OutcomeUnknown(err error) bool {
+	if err == nil || isDialError(err) {
+		return false
+	}
+
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		switch {
+		case providerErr.Retryable || handshakeErrorCodes[providerErr.Code]:
+			return false
+		case providerErr.StatusCode != 0:
+			return ambiguousStatus(providerErr.StatusCode)
+		case providerErr.Code == ErrCodeParseError:
+			return true
+		}
+	}
+	if httpErr, ok := asHTTPError(err); ok {
+		return ambiguousStatus(httpErr.StatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// ambiguousStatus indica respostas 5xx que não garantem que a requisição foi descartada
+func ambiguousStatus(status int) bool {
+	return status >= 500 && status != http.StatusNotImplemented && status != http.StatusServiceUnavailable
+}
+
+// isDialError indica falha ao abrir a conexão (DNS, conexão recusada): nada foi enviado ao banco
+func isDialError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,165 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClassifyHTTPError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		code      string
+		retryable bool
+	}{
+		{
+			name:   "insufficient balance by SPI reason",
+			status: http.StatusUnprocessableEntity,
+			body:   `{"type":"https://pix.bcb.gov.br/api/v2/error/PixOperacaoInvalida","title":"Operação inválida","status":422,"detail":"Pagamento rejeitado: AM04"}`,
+			code:   ErrCodeInsufficientBalance,
+		},
+		{
+			name:   "invalid key in violations",
+			status: http.StatusBadRequest,
+			body:   `{"type":"https://pix.bcb.gov.br/api/v2/error/RequisicaoInvalida","title":"Requisição inválida","status":400,"violacoes":[{"razao":"Chave PIX não encontrada","propriedade":"chave"}]}`,
+			code:   ErrCodeInvalidPixKey,
+		},
+		{
+			name:   "generic invalid request",
+			status: http.StatusBadRequest,
+			body:   `{"type":"https://pix.bcb.gov.br/api/v2/error/RequisicaoInvalida","title":"Requisição inválida","status":400,"detail":"campo valor ausente"}`,
+			code:   ErrCodeInvalidRequest,
+		},
+		{
+			name:   "not found by problem type",
+			status: http.StatusNotFound,
+			body:   `{"type":"https://pix.bcb.gov.br/api/v2/error/PixNaoEncontrado","title":"Pix não encontrado","status":404}`,
+			code:   ErrCodeNotFound,
+		},
+		{
+			name:   "bank specific codigo/mensagem body",
+			status: http.StatusUnprocessableEntity,
+			body:   `{"codigo":"SALDO","mensagem":"Saldo insuficiente para a operação"}`,
+			code:   ErrCodeInsufficientBalance,
+		},
+		{
+			name:      "service unavailable",
+			status:    http.StatusServiceUnavailable,
+			body:      `<html>maintenance</html>`,
+			code:      ErrCodeBankUnavailable,
+			retryable: true,
+		},
+		{
+			name:   "ambiguous internal error",
+			status: http.StatusInternalServerError,
+			body:   ``,
+			code:   ErrCodeBankUnavailable,
+		},
+		{
+			name:   "ambiguous bad gateway",
+			status: http.StatusBadGateway,
+			code:   ErrCodeBankUnavailable,
+		},
+		{
+			name:      "rate limited",
+			status:    http.StatusTooManyRequests,
+			code:      ErrCodeRateLimited,
+			retryable: true,
+		},
+		{
+			name:   "unauthorized",
+			status: http.StatusUnauthorized,
+			code:   ErrCodeUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, retryable := ClassifyHTTPError(NewHTTPError(tt.status, http.Header{}, []byte(tt.body)))
+			if code != tt.code || retryable != tt.retryable {
+				t.Errorf("ClassifyHTTPError() = %v, %v, want %v, %v", code, retryable, tt.code, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestOutcomeUnknown(t *testing.T) {
+	refused := &url.Error{Op: "Post", URL: "https://bank", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	reset := &url.Error{Op: "Post", URL: "https://bank", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", NewProviderError("TRANSFER_FAILED", "Transferência falhou", refused), false},
+		{"connection reset after send", NewProviderError("TRANSFER_FAILED", "Transferência falhou", reset), true},
+		{"timeout", NewProviderError("TRANSFER_FAILED", "Transferência falhou", context.DeadlineExceeded), true},
+		{"bad gateway", NewProviderError("TRANSFER_FAILED", "Transferência falhou", NewHTTPError(http.StatusBadGateway, nil, nil)), true},
+		{"gateway timeout", NewHTTPError(http.StatusGatewayTimeout, nil, nil), true},
+		{"service unavailable", NewProviderError("TRANSFER_FAILED", "Transferência falhou", NewHTTPError(http.StatusServiceUnavailable, nil, nil)), false},
+		{"rate limited", NewProviderError("TRANSFER_FAILED", "Transferência falhou", NewHTTPError(http.StatusTooManyRequests, nil, nil)), false},
+		{"rejected", NewProviderError("TRANSFER_FAILED", "Transferência falhou", NewHTTPError(http.StatusUnprocessableEntity, nil, nil)), false},
+		{"unreadable success", NewProviderError(ErrCodeParseError, "Erro ao processar resposta", errors.New("unexpected end of JSON input")), true},
+		{"circuit open", &ProviderError{Code: ErrCodeCircuitOpen, Retryable: true}, false},
+		{"handshake", NewProviderError("TRANSFER_FAILED", "Transferência falhou", NewProviderError("MTLS_HANDSHAKE_FAILED", "Servidor recusou o certificado do cliente", reset)), false},
+	}
+
+	for _, tt := range tests {
+		if got := OutcomeUnknown(tt.err); got != tt.want {
+			t.Errorf("%s: OutcomeUnknown() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var providerErr *ProviderError
+	if !errors.As(tests[0].err, &providerErr) || !providerErr.Retryable || providerErr.Code != ErrCodeBankUnavailable {
+		t.Errorf("connection refused = %+v, want retryable BANK_UNAVAILABLE", providerErr)
+	}
+}
+
+func TestHTTPClientReturnsTypedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Header().Set("X-Correlation-Id", "abc123")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"type":"https://pix.bcb.gov.br/api/v2/error/PixOperacaoInvalida","title":"Operação inválida","status":422,"detail":"Saldo insuficiente"}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(5, false)
+	_, err := client.Post(context.Background(), server.URL, map[string]string{}, nil)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Post() error = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusUnprocessableEntity || httpErr.Header.Get("X-Correlation-Id") != "abc123" {
+		t.Errorf("HTTPError = %+v", httpErr)
+	}
+	if httpErr.Problem == nil || httpErr.Problem.Detail != "Saldo insuficiente" {
+		t.Errorf("Problem = %+v", httpErr.Problem)
+	}
+
+	// Adapters encapsulam o erro com NewProviderError
+	wrapped := NewProviderError("TRANSFER_FAILED", "Falha ao criar transferência", err)
+
+	var providerErr *ProviderError
+	if !errors.As(wrapped, &providerErr) {
+		t.Fatalf("NewProviderError() = %v, want *ProviderError", wrapped)
+	}
+	if providerErr.Code != ErrCodeInsufficientBalance || providerErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("ProviderError = %+v, want INSUFFICIENT_BALANCE with status 422", providerErr)
+	}
+	if providerErr.Details["response"] == "" {
+		t.Error("ProviderError should keep the response body in Details")
+	}
+	if !errors.As(wrapped, &httpErr) {
+		t.Error("ProviderError should unwrap to *HTTPError")
+	}
+}
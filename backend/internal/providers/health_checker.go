@@ -0,0 +1,190 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// HealthCheckStore persiste o status e o histórico de saúde dos providers
+type HealthCheckStore interface {
+	List(ctx context.Context, activeOnly bool) ([]domain.Provider, error)
+	UpdateHealthStatus(ctx context.Context, id uuid.UUID, status string) error
+	RecordHealthCheck(ctx context.Context, check *domain.ProviderHealthCheck) error
+	DeleteHealthChecksBefore(ctx context.Context, before time.Time) error
+}
+
+// MerchantProviderFinder busca as configurações de merchants que usam um provider
+type MerchantProviderFinder interface {
+	ListByProvider(ctx context.Context, providerID uuid.UUID, activeOnly bool) ([]domain.MerchantProvider, error)
+}
+
+// HealthCheckerConfig define a periodicidade e os limites das verificações de saúde
+type HealthCheckerConfig struct {
+	Interval        time.Duration // Intervalo entre verificações
+	Timeout         time.Duration // Tempo máximo de cada verificação
+	DegradedLatency time.Duration // Latência a partir da qual o provider é considerado degradado
+	Retention       time.Duration // Tempo de retenção do histórico (0 mantém indefinidamente)
+}
+
+// HealthChecker verifica periodicamente a saúde de cada provider registrado
+type HealthChecker struct {
+	manager           *ProviderManager
+	store             HealthCheckStore
+	merchantProviders MerchantProviderFinder
+	config            HealthCheckerConfig
+	now               func() time.Time
+}
+
+// NewHealthChecker cria o verificador de saúde dos providers
+func NewHealthChecker(
+	manager *ProviderManager,
+	store HealthCheckStore,
+	merchantProviders MerchantProviderFinder,
+	config HealthCheckerConfig,
+) *HealthChecker {
+	return &HealthChecker{
+		manager:           manager,
+		store:             store,
+		merchantProviders: merchantProviders,
+		config:            config,
+		now:               time.Now,
+	}
+}
+
+// Run executa as verificações até o contexto ser cancelado
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		h.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll verifica todos os providers ativos com implementação registrada
+func (h *HealthChecker) CheckAll(ctx context.Context) {
+	providers, err := h.store.List(ctx, true)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Aviso: falha ao listar providers para health check: %v", err)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range providers {
+		if _, exists := h.manager.registry.Get(providers[i].Code); !exists {
+			continue
+		}
+
+		wg.Add(1)
+		go func(provider domain.Provider) {
+			defer wg.Done()
+			h.check(ctx, provider)
+		}(providers[i])
+	}
+	wg.Wait()
+
+	if h.config.Retention > 0 && ctx.Err() == nil {
+		if err := h.store.DeleteHealthChecksBefore(ctx, h.now().Add(-h.config.Retention)); err != nil {
+			log.Printf("Aviso: falha ao remover histórico de health check: %v", err)
+		}
+	}
+}
+
+// check verifica um provider usando a instância de um merchant que o utiliza,
+// pois os bancos exigem credenciais e certificado mTLS mesmo para chamadas simples
+func (h *HealthChecker) check(ctx context.Context, provider domain.Provider) {
+	instance, err := h.probeInstance(ctx, provider)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Aviso: health check do provider %s ignorado: %v", provider.Code, err)
+		}
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	startedAt := h.now()
+	checkErr := instance.HealthCheck(checkCtx)
+	latency := h.now().Sub(startedAt)
+
+	// Cancelamento por desligamento do servidor não indica falha do provider
+	if ctx.Err() != nil {
+		return
+	}
+
+	check := &domain.ProviderHealthCheck{
+		ProviderID: provider.ID,
+		Status:     h.status(checkErr, latency),
+		Latency:    latency.Milliseconds(),
+		CheckedAt:  startedAt,
+	}
+	if checkErr != nil {
+		check.ErrorMessage = checkErr.Error()
+		var providerErr *ProviderError
+		if errors.As(checkErr, &providerErr) {
+			check.ErrorCode = providerErr.Code
+		}
+	}
+
+	if err := h.store.RecordHealthCheck(ctx, check); err != nil {
+		log.Printf("Aviso: falha ao registrar health check do provider %s: %v", provider.Code, err)
+	}
+
+	// Com circuit breakers o resultado alimenta o circuito, que passa a ser o único a persistir
+	// o status de saúde (exceto com circuitos por merchant-provider, que não o persistem)
+	if breakers := h.manager.breakers; breakers != nil {
+		breakers.RecordProbe(&provider, checkErr)
+	}
+	if h.manager.breakers.persistsHealthStatus() {
+		return
+	}
+	if err := h.store.UpdateHealthStatus(ctx, provider.ID, check.Status); err != nil {
+		log.Printf("Aviso: falha ao atualizar status de saúde do provider %s: %v", provider.Code, err)
+	}
+}
+
+// probeInstance retorna a primeira instância configurável do provider entre os merchants que o utilizam
+func (h *HealthChecker) probeInstance(ctx context.Context, provider domain.Provider) (PixProvider, error) {
+	mps, err := h.merchantProviders.ListByProvider(ctx, provider.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := errors.New("nenhum merchant ativo configurado")
+	for i := range mps {
+		mps[i].Provider = provider
+		instance, err := h.manager.Instance(&mps[i])
+		if err == nil {
+			return instance, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// status converte o resultado da verificação em status de saúde
+func (h *HealthChecker) status(err error, latency time.Duration) string {
+	switch {
+	case err != nil:
+		return domain.ProviderHealthUnhealthy
+	case h.config.DegradedLatency > 0 && latency >= h.config.DegradedLatency:
+		return domain.ProviderHealthDegraded
+	default:
+		return domain.ProviderHealthHealthy
+	}
+}
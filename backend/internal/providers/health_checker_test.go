@@ -0,0 +1,216 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestHealthCheckerRecordsStatusAndLatency(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("up", "Up"))
+	registry.Register(func() PixProvider {
+		return &MockProvider{code: "down", name: "Down", healthErr: NewProviderError("HEALTH_CHECK_FAILED", "Provider indisponível", nil)}
+	})
+
+	up := newMerchantProvider("up", 1, domain.ProviderHealthUnknown)
+	down := newMerchantProvider("down", 1, domain.ProviderHealthUnknown)
+	unregistered := newMerchantProvider("sicoob", 1, domain.ProviderHealthUnknown)
+
+	store := newMockHealthCheckStore(up.Provider, down.Provider, unregistered.Provider)
+	finder := &mockMerchantProviderFinder{mps: []domain.MerchantProvider{up, down, unregistered}}
+	checker := NewHealthChecker(NewProviderManager(registry, nil, nil, nil), store, finder, HealthCheckerConfig{
+		Interval: time.Minute,
+		Timeout:  time.Second,
+	})
+
+	checker.CheckAll(context.Background())
+
+	if got := store.status[up.ProviderID]; got != domain.ProviderHealthHealthy {
+		t.Errorf("status(up) = %s, want healthy", got)
+	}
+	if got := store.status[down.ProviderID]; got != domain.ProviderHealthUnhealthy {
+		t.Errorf("status(down) = %s, want unhealthy", got)
+	}
+	if _, checked := store.status[unregistered.ProviderID]; checked {
+		t.Error("provider without registered implementation should not be checked")
+	}
+
+	if len(store.checks) != 2 {
+		t.Fatalf("recorded checks = %d, want 2", len(store.checks))
+	}
+	for _, check := range store.checks {
+		if check.ProviderID == down.ProviderID && check.ErrorCode != "HEALTH_CHECK_FAILED" {
+			t.Errorf("ErrorCode = %q, want HEALTH_CHECK_FAILED", check.ErrorCode)
+		}
+		if check.CheckedAt.IsZero() {
+			t.Error("CheckedAt not set")
+		}
+	}
+}
+
+func TestHealthCheckerDegradedLatency(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("slow", "Slow"))
+
+	slow := newMerchantProvider("slow", 1, domain.ProviderHealthHealthy)
+	store := newMockHealthCheckStore(slow.Provider)
+	checker := NewHealthChecker(NewProviderManager(registry, nil, nil, nil), store, &mockMerchantProviderFinder{mps: []domain.MerchantProvider{slow}}, HealthCheckerConfig{
+		Interval:        time.Minute,
+		Timeout:         time.Second,
+		DegradedLatency: 2 * time.Second,
+	})
+
+	// Cada leitura do relógio avança 3 segundos
+	now := time.Now()
+	checker.now = func() time.Time {
+		now = now.Add(3 * time.Second)
+		return now
+	}
+
+	checker.CheckAll(context.Background())
+
+	if got := store.status[slow.ProviderID]; got != domain.ProviderHealthDegraded {
+		t.Errorf("status = %s, want degraded", got)
+	}
+	if len(store.checks) != 1 || store.checks[0].Latency != 3000 {
+		t.Errorf("checks = %+v, want latency 3000ms", store.checks)
+	}
+}
+
+func TestHealthCheckerFeedsCircuitBreakers(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(func() PixProvider {
+		return &MockProvider{code: "down", name: "Down", healthErr: NewProviderError("HEALTH_CHECK_FAILED", "Provider indisponível", nil)}
+	})
+
+	down := newMerchantProvider("down", 1, domain.ProviderHealthHealthy)
+	breakerStore := newMockHealthStore()
+	breakers := NewCircuitBreakers(DefaultCircuitBreakerConfig(), breakerStore)
+	manager := NewProviderManager(registry, &mockMerchantProviderLister{mps: []domain.MerchantProvider{down}}, nil, breakers)
+
+	store := newMockHealthCheckStore(down.Provider)
+	checker := NewHealthChecker(manager, store, &mockMerchantProviderFinder{mps: []domain.MerchantProvider{down}}, HealthCheckerConfig{
+		Interval: time.Minute,
+		Timeout:  time.Second,
+	})
+
+	checker.CheckAll(context.Background())
+
+	// O provider reprovado no health check deixa de ser selecionado
+	if state := breakers.State(&down); state != CircuitOpen {
+		t.Errorf("State() = %s, want open after failed probe", state)
+	}
+	if _, err := manager.SelectProviders(context.Background(), down.MerchantID, ""); !errors.Is(err, ErrNoHealthyProvider) {
+		t.Errorf("SelectProviders() error = %v, want ErrNoHealthyProvider", err)
+	}
+
+	// O status persistido tem um único escritor: o circuito
+	if status := breakerStore.wait(t); status != domain.ProviderHealthUnhealthy {
+		t.Errorf("persisted status = %s, want unhealthy", status)
+	}
+	if len(store.status) != 0 {
+		t.Errorf("health checker wrote status %v, want only the circuit breaker to write it", store.status)
+	}
+	if len(store.checks) != 1 {
+		t.Errorf("recorded checks = %d, want 1", len(store.checks))
+	}
+}
+
+func TestHealthCheckerSkipsProviderWithoutMerchant(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("idle", "Idle"))
+
+	idle := newMerchantProvider("idle", 1, domain.ProviderHealthUnknown)
+	store := newMockHealthCheckStore(idle.Provider)
+	checker := NewHealthChecker(NewProviderManager(registry, nil, nil, nil), store, &mockMerchantProviderFinder{}, HealthCheckerConfig{
+		Interval:  time.Minute,
+		Timeout:   time.Second,
+		Retention: time.Hour,
+	})
+
+	checker.CheckAll(context.Background())
+
+	if len(store.status) != 0 || len(store.checks) != 0 {
+		t.Errorf("status = %v, checks = %v, want no updates", store.status, store.checks)
+	}
+	if store.prunedBefore.IsZero() {
+		t.Error("history retention not applied")
+	}
+}
+
+func TestHealthCheckerRunStopsOnCancel(t *testing.T) {
+	store := newMockHealthCheckStore()
+	checker := NewHealthChecker(NewProviderManager(NewProviderRegistry(), nil, nil, nil), store, &mockMerchantProviderFinder{}, HealthCheckerConfig{
+		Interval: time.Hour,
+		Timeout:  time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after context cancellation")
+	}
+}
+
+type mockHealthCheckStore struct {
+	mu           sync.Mutex
+	providers    []domain.Provider
+	status       map[uuid.UUID]string
+	checks       []*domain.ProviderHealthCheck
+	prunedBefore time.Time
+}
+
+func newMockHealthCheckStore(providers ...domain.Provider) *mockHealthCheckStore {
+	return &mockHealthCheckStore{providers: providers, status: make(map[uuid.UUID]string)}
+}
+
+func (m *mockHealthCheckStore) List(ctx context.Context, activeOnly bool) ([]domain.Provider, error) {
+	return m.providers, nil
+}
+
+func (m *mockHealthCheckStore) UpdateHealthStatus(ctx context.Context, id uuid.UUID, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status[id] = status
+	return nil
+}
+
+func (m *mockHealthCheckStore) RecordHealthCheck(ctx context.Context, check *domain.ProviderHealthCheck) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, check)
+	return nil
+}
+
+func (m *mockHealthCheckStore) DeleteHealthChecksBefore(ctx context.Context, before time.Time) error {
+	m.prunedBefore = before
+	return nil
+}
+
+type mockMerchantProviderFinder struct {
+	mps []domain.MerchantProvider
+}
+
+func (m *mockMerchantProviderFinder) ListByProvider(ctx context.Context, providerID uuid.UUID, activeOnly bool) ([]domain.MerchantProvider, error) {
+	var result []domain.MerchantProvider
+	for _, mp := range m.mps {
+		if mp.ProviderID == providerID {
+			result = append(result, mp)
+		}
+	}
+	return result, nil
+}
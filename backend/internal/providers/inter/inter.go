@@ -9,6 +9,8 @@ import (
 	"github.com/pixsaas/backend/internal/providers"
 )
 
+var _ providers.PixProvider = (*InterProvider)(nil)
+
 // InterProvider implementa o provider do Banco Inter
 type InterProvider struct {
 	config     providers.ProviderConfig
@@ -32,8 +34,13 @@ func (p *InterProvider) GetName() string {
 
 // Initialize inicializa o provider
 func (p *InterProvider) Initialize(config providers.ProviderConfig) error {
+	httpClient, err := providers.NewHTTPClientFromConfig(config)
+	if err != nil {
+		return err
+	}
+
 	p.config = config
-	p.httpClient = providers.NewHTTPClient(config.Timeout, config.RequiresMTLS)
+	p.httpClient = httpClient
 	return nil
 }
 
@@ -94,11 +101,21 @@ func (p *InterProvider) CreateTransfer(ctx context.Context, req *providers.Trans
 		"descricao": req.Description,
 	}
 
+	// Pagamento de Pix copia e cola: o banco liquida a cobrança identificada no código
+	if req.QRCode != "" {
+		payload["destinatario"] = map[string]interface{}{
+			"tipo":          "PIX_COPIA_E_COLA",
+			"pixCopiaECola": req.QRCode,
+		}
+	}
+
 	headers := map[string]string{
 		"Content-Type":  "application/json",
 		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
 	}
 
+	ctx = providers.WithIdempotencyHeader(ctx, headers, "x-id-idempotente", req.IdempotencyKey)
+
 	resp, err := p.httpClient.Post(ctx, url, payload, headers)
 	if err != nil {
 		return nil, providers.NewProviderError("TRANSFER_FAILED", "Falha ao criar transferência", err)
@@ -192,8 +209,12 @@ func (p *InterProvider) CreateQRCodeStatic(ctx context.Context, req *providers.Q
 	}, nil
 }
 
-// CreateQRCodeDynamic cria um QR Code dinâmico
+// CreateQRCodeDynamic cria um QR Code dinâmico ou, com DueDate, uma cobrança com vencimento
 func (p *InterProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	if req.DueDate != nil {
+		return p.createCobV(ctx, req)
+	}
+
 	url := fmt.Sprintf("%s/banking/v2/pix/qrcode-dinamico", p.config.BaseURL)
 
 	payload := map[string]interface{}{
@@ -229,6 +250,47 @@ func (p *InterProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.
 	}, nil
 }
 
+// createCobV cria a cobrança com vencimento; o txid é definido por nós, então o PUT é idempotente
+func (p *InterProvider) createCobV(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	payload, err := providers.NewCobVPayload(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.TxID == "" {
+		return nil, providers.NewProviderError(providers.ErrCodeInvalidRequest, "Cobrança com vencimento exige txid", nil)
+	}
+
+	url := fmt.Sprintf("%s/pix/v2/cobv/%s", p.config.BaseURL, req.TxID)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
+	}
+
+	resp, err := p.httpClient.Put(ctx, url, payload, headers)
+	if err != nil {
+		return nil, providers.NewProviderError("QRCODE_FAILED", "Falha ao gerar cobrança com vencimento", err)
+	}
+
+	var cobv struct {
+		TxID          string `json:"txid"`
+		PixCopiaECola string `json:"pixCopiaECola"`
+		Status        string `json:"status"`
+	}
+
+	if err := json.Unmarshal(resp, &cobv); err != nil {
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
+	}
+
+	return &providers.QRCodeResponse{
+		QRCodeID:    cobv.TxID,
+		QRCode:      cobv.PixCopiaECola,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Status:      cobv.Status,
+	}, nil
+}
+
 // GetQRCode consulta um QR Code
 func (p *InterProvider) GetQRCode(ctx context.Context, req *providers.GetQRCodeRequest) (*providers.QRCodeResponse, error) {
 	url := fmt.Sprintf("%s/banking/v2/pix/qrcode/%s", p.config.BaseURL, req.QRCodeID)
@@ -259,9 +321,48 @@ func (p *InterProvider) GetQRCode(ctx context.Context, req *providers.GetQRCodeR
 	}, nil
 }
 
-// ValidatePixKey valida uma chave PIX
+// RequestRefund solicita a devolução de um PIX recebido. O ID da devolução é definido por nós,
+// então o PUT é idempotente.
+func (p *InterProvider) RequestRefund(ctx context.Context, req *providers.RefundRequest) (*providers.RefundResponse, error) {
+	payload, err := providers.NewRefundPayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	url := p.config.BaseURL + "/pix/v2" + providers.RefundPath(req.E2EID, req.RefundID)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
+	}
+
+	resp, err := p.httpClient.Put(ctx, url, payload, headers)
+	if err != nil {
+		return nil, providers.NewProviderError("REFUND_FAILED", "Falha ao solicitar devolução", err)
+	}
+
+	return providers.ParseRefundResponse(resp)
+}
+
+// GetRefund consulta uma devolução
+func (p *InterProvider) GetRefund(ctx context.Context, req *providers.GetRefundRequest) (*providers.RefundResponse, error) {
+	url := p.config.BaseURL + "/pix/v2" + providers.RefundPath(req.E2EID, req.RefundID)
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
+	}
+
+	resp, err := p.httpClient.Get(ctx, url, headers)
+	if err != nil {
+		return nil, providers.NewProviderError("GET_FAILED", "Falha ao consultar devolução", err)
+	}
+
+	return providers.ParseRefundResponse(resp)
+}
+
+// ValidatePixKey consulta no DICT o vínculo da chave PIX (titular e conta)
 func (p *InterProvider) ValidatePixKey(ctx context.Context, req *providers.ValidatePixKeyRequest) (*providers.ValidatePixKeyResponse, error) {
-	return nil, providers.NewProviderError("NOT_IMPLEMENTED", "Validação de chave não implementada", nil)
+	return providers.LookupDictEntry(ctx, &p.httpClient, p.config.BaseURL+"/banking/v2/pix", req, nil)
 }
 
 // HealthCheck verifica a saúde do provider
@@ -270,14 +371,23 @@ func (p *InterProvider) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ParseCallback autentica e interpreta um callback PIX enviado pelo banco
+func (p *InterProvider) ParseCallback(ctx context.Context, req *providers.CallbackRequest) ([]providers.CallbackNotification, error) {
+	return providers.ParsePixCallback(req, providers.DefaultCallbackSignatureHeader)
+}
+
 // GetSupportedMethods retorna os métodos suportados
 func (p *InterProvider) GetSupportedMethods() []string {
 	return []string{
 		"transfer",
+		"pix_copy_paste",
 		"qrcode_static",
 		"qrcode_dynamic",
+		"qrcode_due_date",
 		"get_transfer",
 		"get_qrcode",
+		"refund",
+		"validate_pix_key",
 	}
 }
 
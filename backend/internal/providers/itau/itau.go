@@ -1,12 +1,10 @@
 package itau
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -20,21 +18,19 @@ const (
 	ISPB         = "60701190"
 )
 
+var _ providers.PixProvider = (*ItauProvider)(nil)
+
 // ItauProvider implementa a interface PixProvider para o Itaú
 type ItauProvider struct {
-	config     domain.ProviderConfig
-	httpClient *http.Client
+	config     providers.ProviderConfig
+	httpClient providers.HTTPClient
 	baseURL    string
 	authURL    string
 }
 
 // NewItauProvider cria uma nova instância do provider Itaú
 func NewItauProvider() *ItauProvider {
-	return &ItauProvider{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+	return &ItauProvider{}
 }
 
 func (p *ItauProvider) GetCode() string {
@@ -45,38 +41,21 @@ func (p *ItauProvider) GetName() string {
 	return ProviderName
 }
 
-func (p *ItauProvider) Initialize(config domain.ProviderConfig) error {
+func (p *ItauProvider) Initialize(config providers.ProviderConfig) error {
+	// Certificado mTLS do merchant é configurado uma única vez por instância
+	httpClient, err := providers.NewHTTPClientFromConfig(config)
+	if err != nil {
+		return err
+	}
+
 	p.config = config
+	p.httpClient = httpClient
 	p.baseURL = config.BaseURL
 	p.authURL = config.AuthURL
-
-	if config.Timeout > 0 {
-		p.httpClient.Timeout = time.Duration(config.Timeout) * time.Second
-	}
-
 	return nil
 }
 
 func (p *ItauProvider) Authenticate(ctx context.Context, credentials providers.ProviderCredentials) (*providers.AuthToken, error) {
-	// Itaú usa OAuth 2.0 com Private Key JWT e mTLS
-	if p.config.RequiresMTLS {
-		cert, err := tls.X509KeyPair(credentials.Certificate, credentials.PrivateKey)
-		if err != nil {
-			return nil, &providers.ProviderError{
-				Code:    "CERT_ERROR",
-				Message: "Erro ao carregar certificado mTLS",
-				Details: map[string]interface{}{"error": err.Error()},
-			}
-		}
-
-		p.httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				Certificates: []tls.Certificate{cert},
-				MinVersion:   tls.VersionTLS12,
-			},
-		}
-	}
-
 	// Requisição OAuth2 com client_credentials
 	data := map[string]string{
 		"grant_type":    "client_credentials",
@@ -85,43 +64,13 @@ func (p *ItauProvider) Authenticate(ctx context.Context, credentials providers.P
 		"scope":         "sispag",
 	}
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:    "MARSHAL_ERROR",
-			Message: "Erro ao serializar dados de autenticação",
-			Details: map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Content-Type": "application/json",
 	}
-	req, err := http.NewRequestWithContext(ctx, "POST", p.authURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := p.httpClient.Do(req)
+	resp, err := p.httpClient.Post(ctx, p.authURL, data, headers)
 	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "AUTH_ERROR",
-			Message:   "Erro ao autenticar com Itaú",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	if resp.StatusCode != http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			body = []byte("failed to read response body")
-		}
-		return nil, &providers.ProviderError{
-			Code:       "AUTH_FAILED",
-			Message:    "Autenticação falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("AUTH_FAILED", "Autenticação falhou", err)
 	}
 
 	var authResp struct {
@@ -131,8 +80,8 @@ func (p *ItauProvider) Authenticate(ctx context.Context, credentials providers.P
 		Scope       string `json:"scope"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
-		return nil, err
+	if err := json.Unmarshal(resp, &authResp); err != nil {
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta de autenticação", err)
 	}
 
 	return &providers.AuthToken{
@@ -145,7 +94,7 @@ func (p *ItauProvider) Authenticate(ctx context.Context, credentials providers.P
 }
 
 func (p *ItauProvider) RefreshToken(ctx context.Context, refreshToken string) (*providers.AuthToken, error) {
-	return nil, fmt.Errorf("refresh token não implementado")
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Refresh token não suportado", nil)
 }
 
 func (p *ItauProvider) CreateTransfer(ctx context.Context, req *providers.TransferRequest) (*providers.TransferResponse, error) {
@@ -187,59 +136,16 @@ func (p *ItauProvider) CreateTransfer(ctx context.Context, req *providers.Transf
 		payload["cpf_cnpj_recebedor"] = req.PayeeDocument
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:    "MARSHAL_ERROR",
-			Message: "Erro ao serializar payload de transferência",
-			Details: map[string]interface{}{"error": err.Error()},
-		}
-	}
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	// Token deve ser passado via context ou armazenado
-
-	resp, err := p.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "TRANSFER_ERROR",
-			Message:   "Erro ao criar transferência",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+	ctx = providers.WithIdempotencyHeader(ctx, headers, "X-Idempotency-Key", req.IdempotencyKey)
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := p.httpClient.Post(ctx, endpoint, payload, headers)
 	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var errorResp struct {
-			Codigo   string `json:"codigo"`
-			Mensagem string `json:"mensagem"`
-			Detalhes []struct {
-				Campo    string `json:"campo"`
-				Mensagem string `json:"mensagem"`
-			} `json:"detalhes"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err != nil {
-			// Se falhar ao parsear, usa mensagem genérica
-			errorResp.Codigo = "PARSE_ERROR"
-			errorResp.Mensagem = "Erro ao processar resposta de erro"
-		}
-
-		return nil, &providers.ProviderError{
-			Code:       errorResp.Codigo,
-			Message:    errorResp.Mensagem,
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		// Corpo de erro (codigo/mensagem) é interpretado e normalizado pelo ProviderError
+		return nil, providers.NewProviderError("TRANSFER_FAILED", "Transferência falhou", err)
 	}
 
 	var itauResp struct {
@@ -256,7 +162,7 @@ func (p *ItauProvider) CreateTransfer(ctx context.Context, req *providers.Transf
 	}
 
 	if err := json.Unmarshal(body, &itauResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	status := mapItauStatus(itauResp.Status)
@@ -281,46 +187,25 @@ func (p *ItauProvider) CreateTransfer(ctx context.Context, req *providers.Transf
 	return response, nil
 }
 
-func (p *ItauProvider) GetTransfer(ctx context.Context, txID string) (*providers.TransferResponse, error) {
+func (p *ItauProvider) GetTransfer(ctx context.Context, getReq *providers.GetTransferRequest) (*providers.TransferResponse, error) {
 	// Endpoint: GET /sispag/v1/pagamentos/pix/{id_requisicao}
-	endpoint := fmt.Sprintf("%s/sispag/v1/pagamentos/pix/%s", p.baseURL, txID)
+	endpoint := fmt.Sprintf("%s/sispag/v1/pagamentos/pix/%s", p.baseURL, getReq.ProviderTxID)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, http.NoBody)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "QUERY_ERROR",
-			Message:   "Erro ao consultar transferência",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", getReq.AuthToken),
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := p.httpClient.Get(ctx, endpoint, headers)
 	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, &providers.ProviderError{
-			Code:       "NOT_FOUND",
-			Message:    "Transferência não encontrada",
-			StatusCode: resp.StatusCode,
-		}
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, &providers.ProviderError{
-			Code:       "QUERY_FAILED",
-			Message:    "Consulta falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
+		var httpErr *providers.HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return nil, &providers.ProviderError{
+				Code:       "NOT_FOUND",
+				Message:    "Transferência não encontrada",
+				StatusCode: httpErr.StatusCode,
+			}
 		}
+		return nil, providers.NewProviderError("QUERY_FAILED", "Consulta falhou", err)
 	}
 
 	var itauResp struct {
@@ -332,7 +217,7 @@ func (p *ItauProvider) GetTransfer(ctx context.Context, txID string) (*providers
 	}
 
 	if err := json.Unmarshal(body, &itauResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	return &providers.TransferResponse{
@@ -344,8 +229,8 @@ func (p *ItauProvider) GetTransfer(ctx context.Context, txID string) (*providers
 	}, nil
 }
 
-func (p *ItauProvider) CancelTransfer(ctx context.Context, txID string) error {
-	return fmt.Errorf("cancelamento não suportado pelo Itaú")
+func (p *ItauProvider) CancelTransfer(ctx context.Context, req *providers.CancelTransferRequest) error {
+	return providers.NewProviderError("NOT_SUPPORTED", "Cancelamento não suportado pelo Itaú", nil)
 }
 
 func (p *ItauProvider) CreateQRCodeStatic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
@@ -358,44 +243,14 @@ func (p *ItauProvider) CreateQRCodeStatic(ctx context.Context, req *providers.QR
 		"descricao": req.Description,
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:    "MARSHAL_ERROR",
-			Message: "Erro ao serializar payload de QR Code",
-			Details: map[string]interface{}{"error": err.Error()},
-		}
-	}
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := p.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "QRCODE_ERROR",
-			Message:   "Erro ao criar QR Code",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := p.httpClient.Post(ctx, endpoint, payload, headers)
 	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, &providers.ProviderError{
-			Code:       "QRCODE_FAILED",
-			Message:    "Criação de QR Code falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("QRCODE_FAILED", "Criação de QR Code falhou", err)
 	}
 
 	var qrResp struct {
@@ -405,7 +260,7 @@ func (p *ItauProvider) CreateQRCodeStatic(ctx context.Context, req *providers.QR
 	}
 
 	if err := json.Unmarshal(body, &qrResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	return &providers.QRCodeResponse{
@@ -421,6 +276,10 @@ func (p *ItauProvider) CreateQRCodeStatic(ctx context.Context, req *providers.QR
 }
 
 func (p *ItauProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	if err := providers.RejectDueDate(req); err != nil {
+		return nil, err
+	}
+
 	// Endpoint: POST /sispag/v1/qrcodes/dinamico
 	endpoint := fmt.Sprintf("%s/sispag/v1/qrcodes/dinamico", p.baseURL)
 
@@ -432,44 +291,14 @@ func (p *ItauProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.Q
 		"permite_alterar": req.AllowChange,
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:    "MARSHAL_ERROR",
-			Message: "Erro ao serializar payload de QR Code dinâmico",
-			Details: map[string]interface{}{"error": err.Error()},
-		}
-	}
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := p.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "QRCODE_ERROR",
-			Message:   "Erro ao criar QR Code dinâmico",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", req.AuthToken),
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := p.httpClient.Post(ctx, endpoint, payload, headers)
 	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, &providers.ProviderError{
-			Code:       "QRCODE_FAILED",
-			Message:    "Criação de QR Code dinâmico falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("QRCODE_FAILED", "Criação de QR Code dinâmico falhou", err)
 	}
 
 	var qrResp struct {
@@ -480,7 +309,7 @@ func (p *ItauProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.Q
 	}
 
 	if err := json.Unmarshal(body, &qrResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
@@ -498,37 +327,16 @@ func (p *ItauProvider) CreateQRCodeDynamic(ctx context.Context, req *providers.Q
 	}, nil
 }
 
-func (p *ItauProvider) GetQRCode(ctx context.Context, qrCodeID string) (*providers.QRCodeResponse, error) {
-	endpoint := fmt.Sprintf("%s/sispag/v1/qrcodes/%s", p.baseURL, qrCodeID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, http.NoBody)
-	if err != nil {
-		return nil, err
-	}
+func (p *ItauProvider) GetQRCode(ctx context.Context, getReq *providers.GetQRCodeRequest) (*providers.QRCodeResponse, error) {
+	endpoint := fmt.Sprintf("%s/sispag/v1/qrcodes/%s", p.baseURL, getReq.QRCodeID)
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, &providers.ProviderError{
-			Code:      "QUERY_ERROR",
-			Message:   "Erro ao consultar QR Code",
-			Retryable: true,
-			Details:   map[string]interface{}{"error": err.Error()},
-		}
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", getReq.AuthToken),
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := p.httpClient.Get(ctx, endpoint, headers)
 	if err != nil {
-		body = []byte("failed to read response body")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, &providers.ProviderError{
-			Code:       "QUERY_FAILED",
-			Message:    "Consulta de QR Code falhou",
-			StatusCode: resp.StatusCode,
-			Details:    map[string]interface{}{"response": string(body)},
-		}
+		return nil, providers.NewProviderError("QUERY_FAILED", "Consulta de QR Code falhou", err)
 	}
 
 	var qrResp struct {
@@ -540,7 +348,7 @@ func (p *ItauProvider) GetQRCode(ctx context.Context, qrCodeID string) (*provide
 	}
 
 	if err := json.Unmarshal(body, &qrResp); err != nil {
-		return nil, err
+		return nil, providers.NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
 	}
 
 	return &providers.QRCodeResponse{
@@ -553,30 +361,38 @@ func (p *ItauProvider) GetQRCode(ctx context.Context, qrCodeID string) (*provide
 	}, nil
 }
 
-func (p *ItauProvider) ValidatePixKey(ctx context.Context, pixKey string, pixKeyType domain.PixKeyType) (*providers.PixKeyInfo, error) {
+// RequestRefund não é suportado pela API do Itaú integrada
+func (p *ItauProvider) RequestRefund(ctx context.Context, req *providers.RefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Devolução não suportada pelo Itaú", nil)
+}
+
+// GetRefund não é suportado pela API do Itaú integrada
+func (p *ItauProvider) GetRefund(ctx context.Context, req *providers.GetRefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Devolução não suportada pelo Itaú", nil)
+}
+
+func (p *ItauProvider) ValidatePixKey(ctx context.Context, req *providers.ValidatePixKeyRequest) (*providers.ValidatePixKeyResponse, error) {
 	// TODO: Implementar validação de chave PIX via DICT
-	return nil, fmt.Errorf("validação de chave PIX não implementada")
+	return nil, providers.NewProviderError("NOT_IMPLEMENTED", "Validação de chave PIX não implementada", nil)
 }
 
 func (p *ItauProvider) HealthCheck(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL, http.NoBody)
-	if err != nil {
-		return err
-	}
+	_, err := p.httpClient.Get(ctx, p.baseURL, nil)
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	if resp.StatusCode >= 500 {
-		return fmt.Errorf("provider unhealthy: status %d", resp.StatusCode)
+	// Respostas abaixo de 500 indicam que o provider está no ar
+	var httpErr *providers.HTTPError
+	if err != nil && (!errors.As(err, &httpErr) || httpErr.StatusCode >= http.StatusInternalServerError) {
+		return providers.NewProviderError("HEALTH_CHECK_FAILED", "Provider indisponível", err)
 	}
 
 	return nil
 }
 
+// ParseCallback autentica e interpreta um callback PIX enviado pelo banco
+func (p *ItauProvider) ParseCallback(ctx context.Context, req *providers.CallbackRequest) ([]providers.CallbackNotification, error) {
+	return providers.ParsePixCallback(req, providers.DefaultCallbackSignatureHeader)
+}
+
 func (p *ItauProvider) GetSupportedMethods() []string {
 	return []string{"pix_key", "account", "transfer", "qrcode_static", "qrcode_dynamic"}
 }
@@ -3,13 +3,17 @@ package providers
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -51,16 +55,35 @@ type PixProvider interface {
 	// GetQRCode consulta informações de um QR Code
 	GetQRCode(ctx context.Context, req *GetQRCodeRequest) (*QRCodeResponse, error)
 
+	// RequestRefund solicita a devolução (total ou parcial) de um PIX recebido
+	RequestRefund(ctx context.Context, req *RefundRequest) (*RefundResponse, error)
+
+	// GetRefund consulta uma devolução
+	GetRefund(ctx context.Context, req *GetRefundRequest) (*RefundResponse, error)
+
 	// ValidatePixKey valida se uma chave PIX existe e retorna informações
 	ValidatePixKey(ctx context.Context, req *ValidatePixKeyRequest) (*ValidatePixKeyResponse, error)
 
 	// HealthCheck verifica se o provider está saudável
 	HealthCheck(ctx context.Context) error
 
+	// ParseCallback autentica e interpreta um callback (webhook PIX) enviado pelo banco
+	ParseCallback(ctx context.Context, req *CallbackRequest) ([]CallbackNotification, error)
+
 	// GetSupportedMethods retorna os métodos suportados pelo provider
 	GetSupportedMethods() []string
 }
 
+// SupportsMethod verifica se o provider declara suporte ao método informado
+func SupportsMethod(provider PixProvider, method string) bool {
+	for _, m := range provider.GetSupportedMethods() {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 // ProviderConfig representa a configuração de um provider
 type ProviderConfig struct {
 	BaseURL      string
@@ -69,6 +92,7 @@ type ProviderConfig struct {
 	Timeout      int
 	MaxRetries   int
 	RequiresMTLS bool
+	TLS          TLSOptions
 }
 
 // ProviderCredentials representa as credenciais de autenticação
@@ -121,9 +145,16 @@ type TransferRequest struct {
 	PayeeBank          string
 	PayeeISPB          string
 
+	// Pagamento de BR Code (Pix copia e cola)
+	QRCode     string // Código pago; os dados do recebedor já vêm validados do código
+	QRCodeTxID string // txid da cobrança no PSP do recebedor
+
 	// Metadata adicional
 	Metadata map[string]interface{}
 
+	// Chave de idempotência (ID da transação): permite repetir a requisição no mesmo banco (ver WithIdempotencyKey)
+	IdempotencyKey string
+
 	// Auth token
 	AuthToken string
 	ClientID  string
@@ -151,6 +182,36 @@ type GetQRCodeRequest struct {
 	ClientID  string
 }
 
+// RefundRequest representa a solicitação de devolução de um PIX recebido
+type RefundRequest struct {
+	E2EID       string // End-to-end ID do PIX original
+	RefundID    string // ID da devolução, definido por nós (até 35 caracteres alfanuméricos)
+	Amount      int64  // Centavos
+	Reason      domain.RefundReason
+	Description string
+	AuthToken   string
+	ClientID    string
+}
+
+// GetRefundRequest representa uma consulta de devolução
+type GetRefundRequest struct {
+	E2EID     string
+	RefundID  string
+	AuthToken string
+	ClientID  string
+}
+
+// RefundResponse representa a devolução retornada pelo banco
+type RefundResponse struct {
+	RefundID     string
+	RtrID        string // ID da devolução no SPI
+	Amount       int64
+	Status       domain.RefundStatus
+	ErrorMessage string // Motivo informado pelo banco quando a devolução não é realizada
+	RequestedAt  *time.Time
+	CompletedAt  *time.Time
+}
+
 // ValidatePixKeyRequest representa uma requisição de validação de chave PIX
 type ValidatePixKeyRequest struct {
 	PixKey     string
@@ -216,11 +277,16 @@ type QRCodeRequest struct {
 	Description     string
 	PayeeName       string
 	PayeeDocument   string
+	PayeeCity       string // Obrigatório no BR Code gerado localmente
 	PixKey          string
 	PayeePixKey     string
 	PayeePixKeyType domain.PixKeyType
-	ExpiresIn       int  // Segundos (para QR Code dinâmico)
-	AllowChange     bool // Permite alterar valor
+	TxID            string               // Identificador da cobrança (até 25 caracteres alfanuméricos)
+	ExpiresIn       int                  // Segundos (para QR Code dinâmico)
+	AllowChange     bool                 // Permite alterar valor
+	DueDate         *domain.DueDateTerms // Cobrança com vencimento (cobv)
+	PayerName       string               // Devedor, obrigatório na cobv
+	PayerDocument   string
 	Metadata        map[string]interface{}
 	AuthToken       string
 	ClientID        string
@@ -258,60 +324,430 @@ type ProviderError struct {
 	StatusCode int
 	Retryable  bool
 	Details    map[string]interface{}
+	Err        error // Erro original (ex: *HTTPError)
 }
 
 func (e *ProviderError) Error() string {
 	return e.Message
 }
 
-// ProviderRegistry gerencia todos os providers registrados
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderFactory cria uma nova instância (não inicializada) de um provider
+type ProviderFactory func() PixProvider
+
+// ProviderRegistry gerencia todos os providers registrados.
+// Cada configuração merchant-provider recebe sua própria instância inicializada,
+// para que configuração, credenciais e certificados nunca sejam compartilhados entre merchants.
 type ProviderRegistry struct {
-	providers map[string]PixProvider
+	mu            sync.RWMutex
+	providers     map[string]PixProvider // Instâncias de referência (código, nome, métodos suportados)
+	factories     map[string]ProviderFactory
+	instances     map[uuid.UUID]*providerInstance // Por MerchantProvider.ID
+	invalidations []func(merchantID, providerID uuid.UUID)
+}
+
+type providerInstance struct {
+	provider    PixProvider
+	fingerprint string
+	merchantID  uuid.UUID
+	providerID  uuid.UUID
 }
 
 // NewProviderRegistry cria um novo registro de providers
 func NewProviderRegistry() *ProviderRegistry {
 	return &ProviderRegistry{
 		providers: make(map[string]PixProvider),
+		factories: make(map[string]ProviderFactory),
+		instances: make(map[uuid.UUID]*providerInstance),
 	}
 }
 
-// Register registra um novo provider
-func (r *ProviderRegistry) Register(provider PixProvider) {
+// Register registra um novo provider a partir de sua factory
+func (r *ProviderRegistry) Register(factory ProviderFactory) {
+	provider := factory()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.providers[provider.GetCode()] = provider
+	r.factories[provider.GetCode()] = factory
 }
 
-// Get retorna um provider pelo código
+// Get retorna a instância de referência de um provider pelo código.
+// Não deve ser usada para operações: use Instance para obter uma instância configurada.
 func (r *ProviderRegistry) Get(code string) (PixProvider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	provider, exists := r.providers[code]
 	return provider, exists
 }
 
 // GetAll retorna todos os providers registrados
 func (r *ProviderRegistry) GetAll() map[string]PixProvider {
-	return r.providers
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make(map[string]PixProvider, len(r.providers))
+	for code, provider := range r.providers {
+		all[code] = provider
+	}
+	return all
+}
+
+// OnInvalidate registra uma função chamada quando a instância de um merchant-provider é descartada
+func (r *ProviderRegistry) OnInvalidate(fn func(merchantID, providerID uuid.UUID)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invalidations = append(r.invalidations, fn)
+}
+
+// Instance retorna a instância do provider configurada para o merchant-provider.
+// A instância é reutilizada enquanto a configuração e as credenciais não mudarem.
+func (r *ProviderRegistry) Instance(mp *domain.MerchantProvider, config ProviderConfig) (PixProvider, error) {
+	fingerprint := instanceFingerprint(mp, config)
+
+	r.mu.RLock()
+	cached, ok := r.instances[mp.ID]
+	r.mu.RUnlock()
+	if ok && cached.fingerprint == fingerprint {
+		return cached.provider, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Outra goroutine pode ter criado a instância enquanto aguardávamos o lock
+	if cached, ok := r.instances[mp.ID]; ok {
+		if cached.fingerprint == fingerprint {
+			return cached.provider, nil
+		}
+		r.notifyInvalidation(cached)
+		delete(r.instances, mp.ID)
+	}
+
+	factory, exists := r.factories[mp.Provider.Code]
+	if !exists {
+		return nil, NewProviderError("PROVIDER_NOT_FOUND", fmt.Sprintf("provider %s não registrado", mp.Provider.Code), nil)
+	}
+
+	provider := factory()
+	if err := provider.Initialize(config); err != nil {
+		var providerErr *ProviderError
+		if errors.As(err, &providerErr) {
+			return nil, providerErr
+		}
+		return nil, NewProviderError("INIT_FAILED", "Falha ao inicializar provider", err)
+	}
+
+	r.instances[mp.ID] = &providerInstance{
+		provider:    provider,
+		fingerprint: fingerprint,
+		merchantID:  mp.MerchantID,
+		providerID:  mp.ProviderID,
+	}
+
+	return provider, nil
+}
+
+// Invalidate descarta a instância em cache de um merchant-provider
+func (r *ProviderRegistry) Invalidate(merchantProviderID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.instances[merchantProviderID]; ok {
+		r.notifyInvalidation(cached)
+		delete(r.instances, merchantProviderID)
+	}
+}
+
+func (r *ProviderRegistry) notifyInvalidation(instance *providerInstance) {
+	for _, fn := range r.invalidations {
+		fn(instance.merchantID, instance.providerID)
+	}
+}
+
+// instanceFingerprint identifica a configuração e as credenciais (criptografadas) de um merchant-provider
+func instanceFingerprint(mp *domain.MerchantProvider, config ProviderConfig) string {
+	configJSON, _ := json.Marshal(config) //nolint:errcheck
+
+	h := sha256.New()
+	for _, part := range []string{
+		mp.Provider.Code,
+		string(configJSON),
+		mp.ClientID,
+		mp.ClientSecret,
+		mp.CertificateData,
+		mp.PrivateKeyData,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewProviderConfig converte a configuração persistida de um provider
+func NewProviderConfig(config domain.ProviderConfig) ProviderConfig {
+	return ProviderConfig{
+		BaseURL:      config.BaseURL,
+		AuthURL:      config.AuthURL,
+		SandboxURL:   config.SandboxURL,
+		Timeout:      config.Timeout,
+		MaxRetries:   config.MaxRetries,
+		RequiresMTLS: config.RequiresMTLS,
+		TLS: TLSOptions{
+			CACertificates:     []byte(config.CABundle),
+			PinnedCertificates: config.PinnedCertificates,
+		},
+	}
+}
+
+// ErrNoHealthyProvider indica que o merchant não possui providers ativos e saudáveis
+var ErrNoHealthyProvider = errors.New("no healthy provider available")
+
+// ErrProviderNotConfigured indica que o provider solicitado não está configurado para o merchant
+var ErrProviderNotConfigured = errors.New("merchant not configured for this provider")
+
+// MerchantProviderLister lista as configurações merchant-provider de um merchant
+type MerchantProviderLister interface {
+	ListByMerchant(ctx context.Context, merchantID uuid.UUID, activeOnly bool) ([]domain.MerchantProvider, error)
+}
+
+// ProviderAttempt representa uma tentativa de execução em um provider
+type ProviderAttempt struct {
+	ProviderID   uuid.UUID
+	ProviderCode string
+	Attempt      int
+	Success      bool
+	ErrorCode    string
+	ErrorMessage string
+	Retryable    bool
+	Duration     int64 // Milissegundos
+	StartedAt    time.Time
 }
 
 // ProviderManager gerencia a interação com providers
 type ProviderManager struct {
-	registry *ProviderRegistry
+	registry          *ProviderRegistry
+	merchantProviders MerchantProviderLister
+	decrypter         CredentialDecrypter
+	breakers          *CircuitBreakers
+}
+
+// CredentialDecrypter descriptografa credenciais armazenadas de um merchant-provider
+type CredentialDecrypter interface {
+	Decrypt(ciphertext string) (string, error)
 }
 
 // NewProviderManager cria um novo gerenciador de providers
-func NewProviderManager(registry *ProviderRegistry) *ProviderManager {
+func NewProviderManager(
+	registry *ProviderRegistry,
+	merchantProviders MerchantProviderLister,
+	decrypter CredentialDecrypter,
+	breakers *CircuitBreakers,
+) *ProviderManager {
 	return &ProviderManager{
-		registry: registry,
+		registry:          registry,
+		merchantProviders: merchantProviders,
+		decrypter:         decrypter,
+		breakers:          breakers,
+	}
+}
+
+// Instance retorna a instância configurada do provider, incluindo o certificado mTLS do merchant
+func (r *ProviderManager) Instance(mp *domain.MerchantProvider) (PixProvider, error) {
+	config := NewProviderConfig(mp.Provider.Config)
+
+	if mp.CertificateData != "" || mp.PrivateKeyData != "" {
+		if r.decrypter == nil {
+			return nil, NewProviderError("MTLS_NOT_CONFIGURED", "Nenhum serviço de criptografia configurado para certificados", nil)
+		}
+
+		certificate, err := r.decrypter.Decrypt(mp.CertificateData)
+		if err != nil {
+			return nil, NewProviderError("CERTIFICATE_INVALID", "Falha ao descriptografar certificado", err)
+		}
+		privateKey, err := r.decrypter.Decrypt(mp.PrivateKeyData)
+		if err != nil {
+			return nil, NewProviderError("PRIVATE_KEY_INVALID", "Falha ao descriptografar chave privada", err)
+		}
+
+		config.TLS.Certificate = []byte(certificate)
+		config.TLS.PrivateKey = []byte(privateKey)
+	}
+
+	return r.registry.Instance(mp, config)
+}
+
+// Credentials descriptografa as credenciais de autenticação de um merchant-provider
+func (r *ProviderManager) Credentials(mp *domain.MerchantProvider) (ProviderCredentials, error) {
+	if r.decrypter == nil {
+		return ProviderCredentials{}, NewProviderError("CREDENTIALS_INVALID", "Nenhum serviço de criptografia configurado para credenciais", nil)
+	}
+
+	clientID, err := r.decrypter.Decrypt(mp.ClientID)
+	if err != nil {
+		return ProviderCredentials{}, NewProviderError("CREDENTIALS_INVALID", "Falha ao descriptografar client id", err)
+	}
+	clientSecret, err := r.decrypter.Decrypt(mp.ClientSecret)
+	if err != nil {
+		return ProviderCredentials{}, NewProviderError("CREDENTIALS_INVALID", "Falha ao descriptografar client secret", err)
+	}
+
+	return ProviderCredentials{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		AccountAgency: mp.AccountAgency,
+		AccountNumber: mp.AccountNumber,
+		AccountType:   mp.AccountType,
+		PixKey:        mp.PixKey,
+		PixKeyType:    mp.PixKeyType,
+	}, nil
+}
+
+// Registry retorna o registro de providers
+func (r *ProviderManager) Registry() *ProviderRegistry {
+	return r.registry
+}
+
+// SelectProviders retorna as configurações elegíveis do merchant em ordem de tentativa.
+// O provider preferido (se informado) vem primeiro; os demais seguem por prioridade.
+// Providers inativos, sem implementação registrada ou indisponíveis são ignorados: com circuit
+// breakers configurados vale o estado do circuito (que também recebe os resultados do health
+// checker), caso contrário o status de saúde persistido.
+func (r *ProviderManager) SelectProviders(
+	ctx context.Context,
+	merchantID uuid.UUID,
+	preferredProvider string,
+) ([]domain.MerchantProvider, error) {
+	mps, err := r.merchantProviders.ListByMerchant(ctx, merchantID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	preferredFound := false
+	candidates := make([]domain.MerchantProvider, 0, len(mps))
+	for i := range mps {
+		provider := mps[i].Provider
+		if provider.Code == preferredProvider {
+			preferredFound = true
+		}
+		if !provider.Active || provider.DeletedAt != nil {
+			continue
+		}
+		if r.breakers != nil {
+			if !r.breakers.Ready(&mps[i]) {
+				continue
+			}
+		} else if provider.HealthStatus == domain.ProviderHealthUnhealthy {
+			continue
+		}
+		if _, exists := r.registry.Get(provider.Code); !exists {
+			continue
+		}
+		candidates = append(candidates, mps[i])
+	}
+
+	if preferredProvider != "" && !preferredFound {
+		return nil, ErrProviderNotConfigured
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi, pj := candidates[i].Provider, candidates[j].Provider
+		if preferredProvider != "" && (pi.Code == preferredProvider) != (pj.Code == preferredProvider) {
+			return pi.Code == preferredProvider
+		}
+		return pi.Priority > pj.Priority
+	})
+
+	if len(candidates) == 0 {
+		return nil, ErrNoHealthyProvider
 	}
+
+	return candidates, nil
 }
 
-// ExecuteWithFallback executa uma operação com fallback para outros providers
+// ExecuteWithFallback executa uma operação com fallback para outros providers.
+// A operação só é repetida no próximo provider quando o erro retornado é um
+// *ProviderError com Retryable. Todas as tentativas são retornadas, inclusive em caso de erro.
 func (r *ProviderManager) ExecuteWithFallback(
 	ctx context.Context,
 	merchantID uuid.UUID,
-	operation func(provider PixProvider) error,
+	preferredProvider string,
+	operation func(provider PixProvider, mp *domain.MerchantProvider) error,
+) ([]ProviderAttempt, error) {
+	candidates, err := r.SelectProviders(ctx, merchantID, preferredProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []ProviderAttempt
+	var lastErr error
+
+	for i := range candidates {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return attempts, ctxErr
+		}
+
+		mp := &candidates[i]
+
+		attempt := ProviderAttempt{
+			ProviderID:   mp.ProviderID,
+			ProviderCode: mp.Provider.Code,
+			Attempt:      len(attempts) + 1,
+			StartedAt:    time.Now(),
+		}
+
+		provider, opErr := r.Instance(mp)
+		if opErr != nil {
+			// Falha local de configuração: nada foi enviado ao banco, é seguro tentar o próximo
+			if providerErr, ok := opErr.(*ProviderError); ok {
+				providerErr.Retryable = true
+			}
+		} else {
+			opErr = r.call(provider, mp, operation)
+		}
+		attempt.Duration = time.Since(attempt.StartedAt).Milliseconds()
+
+		if opErr == nil {
+			attempt.Success = true
+			attempts = append(attempts, attempt)
+			return attempts, nil
+		}
+
+		attempt.ErrorMessage = opErr.Error()
+		var providerErr *ProviderError
+		if errors.As(opErr, &providerErr) {
+			attempt.ErrorCode = providerErr.Code
+			attempt.Retryable = providerErr.Retryable
+		}
+		attempts = append(attempts, attempt)
+		lastErr = opErr
+
+		if !attempt.Retryable {
+			break
+		}
+	}
+
+	return attempts, lastErr
+}
+
+// call executa a operação passando pelo circuit breaker do provider, se configurado
+func (r *ProviderManager) call(
+	provider PixProvider,
+	mp *domain.MerchantProvider,
+	operation func(provider PixProvider, mp *domain.MerchantProvider) error,
 ) error {
-	// TODO: Implementar lógica de fallback baseada em prioridade e saúde dos providers
-	return nil
+	if r.breakers == nil {
+		return operation(provider, mp)
+	}
+
+	if err := r.breakers.Allow(mp); err != nil {
+		return err
+	}
+
+	err := operation(provider, mp)
+	r.breakers.Record(mp, err)
+	return err
 }
 
 // GetHealthyProvider retorna um provider saudável para o merchant
@@ -320,15 +756,23 @@ func (r *ProviderManager) GetHealthyProvider(
 	merchantID uuid.UUID,
 	preferredProvider string,
 ) (PixProvider, error) {
-	// TODO: Implementar lógica de seleção de provider baseada em saúde
-	return nil, errors.New("provider selection not implemented yet")
+	candidates, err := r.SelectProviders(ctx, merchantID, preferredProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Instance(&candidates[0])
 }
 
 // HTTPClient é um cliente HTTP para comunicação com providers
 type HTTPClient struct {
-	client      *http.Client
-	timeout     time.Duration
-	requireMTLS bool
+	client         *http.Client
+	timeout        time.Duration
+	requireMTLS    bool
+	mtlsConfigured bool
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
 }
 
 // NewHTTPClient cria um novo cliente HTTP
@@ -337,11 +781,19 @@ func NewHTTPClient(timeout int, requireMTLS bool) HTTPClient {
 		client: &http.Client{
 			Timeout: time.Duration(timeout) * time.Second,
 		},
-		timeout:     time.Duration(timeout) * time.Second,
-		requireMTLS: requireMTLS,
+		timeout:        time.Duration(timeout) * time.Second,
+		requireMTLS:    requireMTLS,
+		retryBaseDelay: DefaultRetryBaseDelay,
+		retryMaxDelay:  DefaultRetryMaxDelay,
 	}
 }
 
+// WithRetries retorna uma cópia do cliente que repete falhas transitórias até maxRetries vezes
+func (c HTTPClient) WithRetries(maxRetries int) HTTPClient {
+	c.maxRetries = maxRetries
+	return c
+}
+
 // Post faz uma requisição POST
 func (c *HTTPClient) Post(ctx context.Context, url string, payload interface{}, headers map[string]string) ([]byte, error) {
 	jsonData, err := json.Marshal(payload)
@@ -358,22 +810,26 @@ func (c *HTTPClient) Post(ctx context.Context, url string, payload interface{},
 		req.Header.Set(key, value)
 	}
 
-	resp, err := c.client.Do(req)
+	return c.do(req)
+}
+
+// Put faz uma requisição PUT
+func (c *HTTPClient) Put(ctx context.Context, url string, payload interface{}, headers map[string]string) ([]byte, error) {
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
 
-	body, err := io.ReadAll(resp.Body)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
 
-	return body, nil
+	return c.do(req)
 }
 
 // Get faz uma requisição GET
@@ -387,22 +843,7 @@ func (c *HTTPClient) Get(ctx context.Context, url string, headers map[string]str
 		req.Header.Set(key, value)
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
-	}
-
-	return body, nil
+	return c.do(req)
 }
 
 // PostForm faz uma requisição POST com form data
@@ -422,22 +863,7 @@ func (c *HTTPClient) PostForm(ctx context.Context, urlStr string, data, headers
 		req.Header.Set(key, value)
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
-	}
-
-	return body, nil
+	return c.do(req)
 }
 
 // PostFormWithBasicAuth faz uma requisição POST com form data e Basic Auth
@@ -458,33 +884,98 @@ func (c *HTTPClient) PostFormWithBasicAuth(ctx context.Context, urlStr string, d
 		req.Header.Set(key, value)
 	}
 
+	return c.do(req)
+}
+
+// do executa a requisição e retorna o corpo da resposta
+func (c *HTTPClient) do(req *http.Request) ([]byte, error) {
+	// Nunca enviar requisições sem certificado a um provider que exige mTLS
+	if c.requireMTLS && !c.mtlsConfigured {
+		return nil, NewProviderError("MTLS_NOT_CONFIGURED", "Provider exige mTLS mas nenhum certificado foi configurado", nil)
+	}
+
+	// Requisições não idempotentes só são repetidas com chave de idempotência
+	maxRetries := c.maxRetries
+	if !isRetryableRequest(req) {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		body, resp, err := c.send(req)
+		if err == nil {
+			return body, nil
+		}
+		if attempt >= maxRetries || !shouldRetry(resp, err) {
+			return nil, err
+		}
+
+		if sleepErr := sleepContext(req.Context(), c.backoffDelay(attempt, resp)); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// send executa uma única tentativa da requisição
+func (c *HTTPClient) send(req *http.Request) ([]byte, *http.Response, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, classifyTransportError(err)
 	}
 	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, resp, err
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return body, resp, NewHTTPError(resp.StatusCode, resp.Header, body)
 	}
 
-	return body, nil
+	return body, resp, nil
 }
 
-// NewProviderError cria um novo erro de provider
+// NewProviderError cria um novo erro de provider.
+// Respostas HTTP de erro são classificadas em códigos normalizados (ex: INSUFFICIENT_BALANCE),
+// falhas ao abrir a conexão viram BANK_UNAVAILABLE retentável e erros de provider encapsulados
+// preservam seu código original.
 func NewProviderError(code, message string, err error) error {
-	if err != nil {
-		message = fmt.Sprintf("%s: %v", message, err)
-	}
-	return &ProviderError{
+	providerErr := &ProviderError{
 		Code:    code,
 		Message: message,
+		Err:     err,
+	}
+	if err == nil {
+		return providerErr
 	}
+
+	providerErr.Message = fmt.Sprintf("%s: %v", message, err)
+
+	var inner *ProviderError
+	if errors.As(err, &inner) {
+		providerErr.Code = inner.Code
+		providerErr.StatusCode = inner.StatusCode
+		providerErr.Retryable = inner.Retryable
+		providerErr.Details = inner.Details
+	} else if httpErr, ok := asHTTPError(err); ok {
+		providerErr.Code, providerErr.Retryable = ClassifyHTTPError(httpErr)
+		providerErr.StatusCode = httpErr.StatusCode
+		providerErr.Details = errorDetails(httpErr)
+	} else if isDialError(err) {
+		// A conexão não foi aberta: é seguro tentar outro provider
+		providerErr.Code = ErrCodeBankUnavailable
+		providerErr.Retryable = true
+	}
+
+	return providerErr
 }
 
 // TransactionStatus representa o status de uma transação
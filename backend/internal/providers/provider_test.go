@@ -2,8 +2,13 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
 )
 
 func TestNewProviderRegistry(t *testing.T) {
@@ -27,7 +32,7 @@ func TestProviderRegistryRegisterAndGet(t *testing.T) {
 	}
 
 	// Register
-	registry.Register(mockProvider)
+	registry.Register(func() PixProvider { return mockProvider })
 
 	// Get
 	provider, exists := registry.Get("test")
@@ -55,8 +60,8 @@ func TestProviderRegistryGetAll(t *testing.T) {
 	mock1 := &MockProvider{code: "provider1", name: "Provider 1"}
 	mock2 := &MockProvider{code: "provider2", name: "Provider 2"}
 
-	registry.Register(mock1)
-	registry.Register(mock2)
+	registry.Register(func() PixProvider { return mock1 })
+	registry.Register(func() PixProvider { return mock2 })
 
 	all := registry.GetAll()
 	if len(all) != 2 {
@@ -64,6 +69,97 @@ func TestProviderRegistryGetAll(t *testing.T) {
 	}
 }
 
+func TestProviderRegistryInstanceIsolatedPerMerchantProvider(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("test", "Test"))
+
+	mpA := newMerchantProvider("test", 1, domain.ProviderHealthHealthy)
+	mpB := newMerchantProvider("test", 1, domain.ProviderHealthHealthy)
+
+	instanceA, err := registry.Instance(&mpA, ProviderConfig{BaseURL: "https://a.example.com"})
+	if err != nil {
+		t.Fatalf("Instance() error = %v", err)
+	}
+	instanceB, err := registry.Instance(&mpB, ProviderConfig{BaseURL: "https://b.example.com"})
+	if err != nil {
+		t.Fatalf("Instance() error = %v", err)
+	}
+
+	if instanceA == instanceB {
+		t.Fatal("Instance() returned the same instance for different merchant-providers")
+	}
+
+	if got := instanceA.(*MockProvider).config.BaseURL; got != "https://a.example.com" {
+		t.Errorf("instance A BaseURL = %v, want https://a.example.com", got)
+	}
+
+	// Instância de referência não deve ser inicializada
+	reference, _ := registry.Get("test")
+	if reference.(*MockProvider).config.BaseURL != "" {
+		t.Error("Instance() should not initialize the reference provider")
+	}
+
+	again, err := registry.Instance(&mpA, ProviderConfig{BaseURL: "https://a.example.com"})
+	if err != nil {
+		t.Fatalf("Instance() error = %v", err)
+	}
+	if again != instanceA {
+		t.Error("Instance() should reuse the instance while configuration is unchanged")
+	}
+}
+
+func TestProviderRegistryInstanceRebuildsOnCredentialChange(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("test", "Test"))
+
+	var invalidated []uuid.UUID
+	registry.OnInvalidate(func(merchantID, providerID uuid.UUID) {
+		invalidated = append(invalidated, providerID)
+	})
+
+	mp := newMerchantProvider("test", 1, domain.ProviderHealthHealthy)
+	mp.ClientSecret = "encrypted-secret-v1"
+	config := ProviderConfig{BaseURL: "https://api.example.com"}
+
+	first, err := registry.Instance(&mp, config)
+	if err != nil {
+		t.Fatalf("Instance() error = %v", err)
+	}
+
+	mp.ClientSecret = "encrypted-secret-v2"
+	second, err := registry.Instance(&mp, config)
+	if err != nil {
+		t.Fatalf("Instance() error = %v", err)
+	}
+
+	if first == second {
+		t.Error("Instance() should rebuild the instance after a credential change")
+	}
+
+	if len(invalidated) != 1 || invalidated[0] != mp.ProviderID {
+		t.Errorf("invalidated = %v, want [%v]", invalidated, mp.ProviderID)
+	}
+
+	registry.Invalidate(mp.ID)
+	third, err := registry.Instance(&mp, config)
+	if err != nil {
+		t.Fatalf("Instance() error = %v", err)
+	}
+	if third == second {
+		t.Error("Instance() should rebuild the instance after Invalidate()")
+	}
+}
+
+func TestSupportsMethod(t *testing.T) {
+	provider := &MockProvider{}
+	if !SupportsMethod(provider, "transfer") {
+		t.Error("SupportsMethod(transfer) = false, want true")
+	}
+	if SupportsMethod(provider, "cancel_transfer") {
+		t.Error("SupportsMethod(cancel_transfer) = true, want false")
+	}
+}
+
 func TestNewHTTPClient(t *testing.T) {
 	client := NewHTTPClient(30, false)
 
@@ -112,10 +208,179 @@ func TestProviderErrorWithWrappedError(t *testing.T) {
 	}
 }
 
+func TestExecuteWithFallbackOrdersByPriorityAndSkipsUnhealthy(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("low", "Low"))
+	registry.Register(mockFactory("high", "High"))
+	registry.Register(mockFactory("down", "Down"))
+
+	merchantID := uuid.New()
+	lister := &mockMerchantProviderLister{mps: []domain.MerchantProvider{
+		newMerchantProvider("low", 1, domain.ProviderHealthHealthy),
+		newMerchantProvider("down", 10, domain.ProviderHealthUnhealthy),
+		newMerchantProvider("high", 5, domain.ProviderHealthUnknown),
+	}}
+	manager := NewProviderManager(registry, lister, nil, nil)
+
+	var called []string
+	attempts, err := manager.ExecuteWithFallback(context.Background(), merchantID, "", func(provider PixProvider, mp *domain.MerchantProvider) error {
+		called = append(called, provider.GetCode())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecuteWithFallback() error = %v", err)
+	}
+
+	if len(called) != 1 || called[0] != "high" {
+		t.Errorf("called = %v, want [high]", called)
+	}
+
+	if len(attempts) != 1 || !attempts[0].Success {
+		t.Errorf("attempts = %+v, want one successful attempt", attempts)
+	}
+}
+
+func TestExecuteWithFallbackRetriesOnlyRetryableErrors(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("first", "First"))
+	registry.Register(mockFactory("second", "Second"))
+	registry.Register(mockFactory("third", "Third"))
+
+	lister := &mockMerchantProviderLister{mps: []domain.MerchantProvider{
+		newMerchantProvider("first", 3, domain.ProviderHealthHealthy),
+		newMerchantProvider("second", 2, domain.ProviderHealthHealthy),
+		newMerchantProvider("third", 1, domain.ProviderHealthHealthy),
+	}}
+	manager := NewProviderManager(registry, lister, nil, nil)
+
+	errs := map[string]error{
+		"first":  &ProviderError{Code: "UNAVAILABLE", Message: "bank unavailable", Retryable: true},
+		"second": &ProviderError{Code: "INVALID_KEY", Message: "invalid key"},
+	}
+
+	var called []string
+	attempts, err := manager.ExecuteWithFallback(context.Background(), uuid.New(), "", func(provider PixProvider, mp *domain.MerchantProvider) error {
+		called = append(called, provider.GetCode())
+		return errs[provider.GetCode()]
+	})
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Code != "INVALID_KEY" {
+		t.Fatalf("ExecuteWithFallback() error = %v, want INVALID_KEY", err)
+	}
+
+	if len(called) != 2 {
+		t.Errorf("called = %v, want [first second]", called)
+	}
+
+	if len(attempts) != 2 || !attempts[0].Retryable || attempts[1].Retryable {
+		t.Errorf("attempts = %+v", attempts)
+	}
+}
+
+func TestExecuteWithFallbackPreferredProvider(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(mockFactory("a", "A"))
+	registry.Register(mockFactory("b", "B"))
+
+	lister := &mockMerchantProviderLister{mps: []domain.MerchantProvider{
+		newMerchantProvider("a", 10, domain.ProviderHealthHealthy),
+		newMerchantProvider("b", 1, domain.ProviderHealthHealthy),
+	}}
+	manager := NewProviderManager(registry, lister, nil, nil)
+
+	provider, err := manager.GetHealthyProvider(context.Background(), uuid.New(), "b")
+	if err != nil {
+		t.Fatalf("GetHealthyProvider() error = %v", err)
+	}
+	if provider.GetCode() != "b" {
+		t.Errorf("GetHealthyProvider() = %v, want b", provider.GetCode())
+	}
+
+	_, err = manager.GetHealthyProvider(context.Background(), uuid.New(), "missing")
+	if !errors.Is(err, ErrProviderNotConfigured) {
+		t.Errorf("GetHealthyProvider() error = %v, want ErrProviderNotConfigured", err)
+	}
+}
+
+func TestExecuteWithFallbackNoProviders(t *testing.T) {
+	manager := NewProviderManager(NewProviderRegistry(), &mockMerchantProviderLister{}, nil, nil)
+
+	_, err := manager.ExecuteWithFallback(context.Background(), uuid.New(), "", func(provider PixProvider, mp *domain.MerchantProvider) error {
+		t.Fatal("operation should not be called")
+		return nil
+	})
+	if !errors.Is(err, ErrNoHealthyProvider) {
+		t.Errorf("ExecuteWithFallback() error = %v, want ErrNoHealthyProvider", err)
+	}
+}
+
+func TestProviderManagerCredentials(t *testing.T) {
+	mp := newMerchantProvider("bank", 1, domain.ProviderHealthHealthy)
+	mp.ClientID = "enc:client"
+	mp.ClientSecret = "enc:secret"
+	mp.PixKey = "pix@example.com"
+
+	manager := NewProviderManager(NewProviderRegistry(), nil, prefixDecrypter{}, nil)
+	credentials, err := manager.Credentials(&mp)
+	if err != nil {
+		t.Fatalf("Credentials() error = %v", err)
+	}
+	if credentials.ClientID != "client" || credentials.ClientSecret != "secret" || credentials.PixKey != "pix@example.com" {
+		t.Errorf("Credentials() = %+v", credentials)
+	}
+
+	mp.ClientSecret = "plain"
+	_, err = manager.Credentials(&mp)
+	assertProviderErrorCode(t, err, "CREDENTIALS_INVALID")
+}
+
+// prefixDecrypter remove o prefixo "enc:" e rejeita valores sem ele
+type prefixDecrypter struct{}
+
+func (prefixDecrypter) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, "enc:") {
+		return "", errors.New("invalid ciphertext")
+	}
+	return strings.TrimPrefix(ciphertext, "enc:"), nil
+}
+
+type mockMerchantProviderLister struct {
+	mps []domain.MerchantProvider
+}
+
+func (m *mockMerchantProviderLister) ListByMerchant(ctx context.Context, merchantID uuid.UUID, activeOnly bool) ([]domain.MerchantProvider, error) {
+	return m.mps, nil
+}
+
+func newMerchantProvider(code string, priority int, health string) domain.MerchantProvider {
+	providerID := uuid.New()
+	return domain.MerchantProvider{
+		ID:         uuid.New(),
+		ProviderID: providerID,
+		Active:     true,
+		Provider: domain.Provider{
+			ID:           providerID,
+			Code:         code,
+			Active:       true,
+			Priority:     priority,
+			HealthStatus: health,
+		},
+	}
+}
+
 // MockProvider for testing
 type MockProvider struct {
-	code string
-	name string
+	code      string
+	name      string
+	config    ProviderConfig
+	healthErr error
+}
+
+func mockFactory(code, name string) ProviderFactory {
+	return func() PixProvider {
+		return &MockProvider{code: code, name: name}
+	}
 }
 
 func (m *MockProvider) GetCode() string {
@@ -127,6 +392,7 @@ func (m *MockProvider) GetName() string {
 }
 
 func (m *MockProvider) Initialize(config ProviderConfig) error {
+	m.config = config
 	return nil
 }
 
@@ -180,6 +446,21 @@ func (m *MockProvider) GetQRCode(ctx context.Context, req *GetQRCodeRequest) (*Q
 	}, nil
 }
 
+func (m *MockProvider) RequestRefund(ctx context.Context, req *RefundRequest) (*RefundResponse, error) {
+	return &RefundResponse{
+		RefundID: req.RefundID,
+		Amount:   req.Amount,
+		Status:   domain.RefundStatusProcessing,
+	}, nil
+}
+
+func (m *MockProvider) GetRefund(ctx context.Context, req *GetRefundRequest) (*RefundResponse, error) {
+	return &RefundResponse{
+		RefundID: req.RefundID,
+		Status:   domain.RefundStatusCompleted,
+	}, nil
+}
+
 func (m *MockProvider) ValidatePixKey(ctx context.Context, req *ValidatePixKeyRequest) (*ValidatePixKeyResponse, error) {
 	return &ValidatePixKeyResponse{
 		Valid:  true,
@@ -189,7 +470,11 @@ func (m *MockProvider) ValidatePixKey(ctx context.Context, req *ValidatePixKeyRe
 }
 
 func (m *MockProvider) HealthCheck(ctx context.Context) error {
-	return nil
+	return m.healthErr
+}
+
+func (m *MockProvider) ParseCallback(ctx context.Context, req *CallbackRequest) ([]CallbackNotification, error) {
+	return ParsePixCallback(req, DefaultCallbackSignatureHeader)
 }
 
 func (m *MockProvider) GetSupportedMethods() []string {
@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pixsaas/backend/internal/brcode"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
+)
+
+// NewLocalStaticQRCode gera o BR Code de um QR Code estático sem chamada ao banco.
+// O pagamento é liquidado pela chave PIX, então o código independe do provider.
+func NewLocalStaticQRCode(req *QRCodeRequest) (*QRCodeResponse, error) {
+	pixKey := req.PixKey
+	if pixKey == "" {
+		pixKey = req.PayeePixKey
+	}
+	key, err := pixkey.Parse(pixKey)
+	if err != nil {
+		return nil, NewProviderError(ErrCodeInvalidPixKey, "Chave PIX inválida para o BR Code", err)
+	}
+
+	code, err := brcode.Encode(&brcode.Payload{
+		PixKey:       key.Value,
+		Description:  req.Description,
+		Amount:       req.Amount,
+		MerchantName: req.PayeeName,
+		MerchantCity: req.PayeeCity,
+		TxID:         req.TxID,
+	})
+	if err != nil {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "Falha ao gerar BR Code", err)
+	}
+
+	return &QRCodeResponse{
+		QRCodeID:    req.TxID,
+		QRCode:      code,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Status:      "ATIVA",
+	}, nil
+}
+
+// Modalidades de multa, juros, abatimento e desconto no padrão da API PIX do BACEN
+var (
+	cobvFineModalities = map[domain.ChargeModality]int{
+		domain.ModalityFixed:   1,
+		domain.ModalityPercent: 2,
+	}
+	cobvInterestModalities = map[domain.ChargeModality]int{
+		domain.ModalityFixedPerDay:     1,
+		domain.ModalityPercentPerDay:   2,
+		domain.ModalityPercentPerMonth: 3,
+		domain.ModalityPercentPerYear:  4,
+	}
+	cobvDiscountModalities = map[domain.ChargeModality]int{
+		domain.ModalityFixedUntilDate:   1,
+		domain.ModalityPercentUntilDate: 2,
+		domain.ModalityFixedPerDay:      3,
+		domain.ModalityPercentPerDay:    5,
+	}
+)
+
+// NewCobVPayload monta o corpo da cobrança com vencimento (cobv) no padrão da API PIX do BACEN,
+// usado pelos bancos que seguem o padrão sem alterações
+func NewCobVPayload(req *QRCodeRequest) (map[string]interface{}, error) {
+	terms := req.DueDate
+	if terms == nil {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "Condições de vencimento não informadas", nil)
+	}
+	if err := terms.Validate(req.Amount, time.Now()); err != nil {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "Cobrança com vencimento inválida", err)
+	}
+	if req.PayerName == "" || req.PayerDocument == "" {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "Cobrança com vencimento exige nome e documento do devedor", nil)
+	}
+
+	devedor := map[string]interface{}{"nome": req.PayerName}
+	if len(req.PayerDocument) == 11 {
+		devedor["cpf"] = req.PayerDocument
+	} else {
+		devedor["cnpj"] = req.PayerDocument
+	}
+
+	valor := map[string]interface{}{"original": formatAmount(req.Amount)}
+	if terms.Fine != nil {
+		valor["multa"] = cobvModifier(cobvFineModalities, terms.Fine)
+	}
+	if terms.Interest != nil {
+		valor["juros"] = cobvModifier(cobvInterestModalities, terms.Interest)
+	}
+	if terms.Rebate != nil {
+		valor["abatimento"] = cobvModifier(cobvFineModalities, terms.Rebate)
+	}
+	if d := terms.Discount; d != nil {
+		desconto := map[string]interface{}{"modalidade": cobvDiscountModalities[d.Modality]}
+		if len(d.Dates) > 0 {
+			dates := make([]map[string]interface{}, 0, len(d.Dates))
+			for _, date := range d.Dates {
+				dates = append(dates, map[string]interface{}{"data": date.Date, "valorPerc": formatAmount(date.Value)})
+			}
+			desconto["descontoDataFixa"] = dates
+		} else {
+			desconto["valorPerc"] = formatAmount(d.Value)
+		}
+		valor["desconto"] = desconto
+	}
+
+	payload := map[string]interface{}{
+		"calendario": map[string]interface{}{
+			"dataDeVencimento":       terms.DueDate,
+			"validadeAposVencimento": terms.ValidAfterDue,
+		},
+		"devedor": devedor,
+		"valor":   valor,
+		"chave":   req.PixKey,
+	}
+	if req.Description != "" {
+		payload["solicitacaoPagador"] = req.Description
+	}
+	return payload, nil
+}
+
+// RejectDueDate recusa cobranças com vencimento nos providers que não as suportam.
+// Nada é enviado ao banco, então o erro permite fallback para o próximo provider.
+func RejectDueDate(req *QRCodeRequest) error {
+	if req.DueDate == nil {
+		return nil
+	}
+	return &ProviderError{
+		Code:      "NOT_SUPPORTED",
+		Message:   "Cobrança com vencimento não suportada pelo provider",
+		Retryable: true,
+	}
+}
+
+func cobvModifier(modalities map[domain.ChargeModality]int, m *domain.ChargeModifier) map[string]interface{} {
+	return map[string]interface{}{"modalidade": modalities[m.Modality], "valorPerc": formatAmount(m.Value)}
+}
+
+// formatAmount formata centavos ou centésimos de ponto percentual com duas casas decimais
+func formatAmount(value int64) string {
+	return fmt.Sprintf("%d.%02d", value/100, value%100)
+}
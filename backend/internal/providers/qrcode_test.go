@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pixsaas/backend/internal/brcode"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestNewLocalStaticQRCode(t *testing.T) {
+	resp, err := NewLocalStaticQRCode(&QRCodeRequest{
+		Amount:      2500,
+		Description: "Pedido 42",
+		PayeeName:   "Loja Exemplo",
+		PayeeCity:   "Florianópolis",
+		PayeePixKey: "pagamentos@loja.com.br",
+		TxID:        "abc123",
+	})
+	if err != nil {
+		t.Fatalf("NewLocalStaticQRCode() error = %v", err)
+	}
+	if resp.QRCodeID != "abc123" {
+		t.Errorf("QRCodeID = %q, want abc123", resp.QRCodeID)
+	}
+
+	payload, err := brcode.Decode(resp.QRCode)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if payload.PixKey != "pagamentos@loja.com.br" || payload.Amount != 2500 || payload.MerchantCity != "Florianopolis" || payload.TxID != "abc123" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestNewLocalStaticQRCodeRequiresCity(t *testing.T) {
+	_, err := NewLocalStaticQRCode(&QRCodeRequest{PixKey: "pagamentos@loja.com.br", PayeeName: "Loja"})
+	assertProviderErrorCode(t, err, ErrCodeInvalidRequest)
+}
+
+func TestNewLocalStaticQRCodeRejectsInvalidKey(t *testing.T) {
+	_, err := NewLocalStaticQRCode(&QRCodeRequest{PixKey: "chave", PayeeName: "Loja", PayeeCity: "Florianópolis"})
+	assertProviderErrorCode(t, err, ErrCodeInvalidPixKey)
+}
+
+func TestNewCobVPayload(t *testing.T) {
+	payload, err := NewCobVPayload(&QRCodeRequest{
+		Amount:        12345,
+		PixKey:        "cobranca@loja.com.br",
+		Description:   "Fatura 10/2024",
+		PayerName:     "Fulano de Tal",
+		PayerDocument: "12345678909",
+		DueDate: &domain.DueDateTerms{
+			DueDate:       "2024-10-31",
+			ValidAfterDue: 30,
+			Fine:          &domain.ChargeModifier{Modality: domain.ModalityPercent, Value: 200},
+			Interest:      &domain.ChargeModifier{Modality: domain.ModalityPercentPerMonth, Value: 100},
+			Discount: &domain.ChargeDiscount{Modality: domain.ModalityFixedUntilDate, Dates: []domain.DiscountDate{
+				{Date: "2024-10-20", Value: 500},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCobVPayload() error = %v", err)
+	}
+
+	body, _ := json.Marshal(payload)
+	for _, want := range []string{
+		`"dataDeVencimento":"2024-10-31"`,
+		`"validadeAposVencimento":30`,
+		`"cpf":"12345678909"`,
+		`"original":"123.45"`,
+		`"multa":{"modalidade":2,"valorPerc":"2.00"}`,
+		`"juros":{"modalidade":3,"valorPerc":"1.00"}`,
+		`"descontoDataFixa":[{"data":"2024-10-20","valorPerc":"5.00"}]`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("payload %s missing %s", body, want)
+		}
+	}
+}
+
+func TestNewCobVPayloadRejectsInvalidRequest(t *testing.T) {
+	terms := &domain.DueDateTerms{DueDate: "2024-10-31"}
+
+	_, err := NewCobVPayload(&QRCodeRequest{Amount: 1000, DueDate: terms})
+	assertProviderErrorCode(t, err, ErrCodeInvalidRequest)
+
+	_, err = NewCobVPayload(&QRCodeRequest{Amount: 1000, PayerName: "Fulano", PayerDocument: "12345678909", DueDate: &domain.DueDateTerms{DueDate: "31/10/2024"}})
+	assertProviderErrorCode(t, err, ErrCodeInvalidRequest)
+}
+
+func TestRejectDueDate(t *testing.T) {
+	if err := RejectDueDate(&QRCodeRequest{}); err != nil {
+		t.Errorf("RejectDueDate() without due date = %v", err)
+	}
+
+	err := RejectDueDate(&QRCodeRequest{DueDate: &domain.DueDateTerms{DueDate: "2024-10-31"}})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || !providerErr.Retryable {
+		t.Errorf("RejectDueDate() = %v, want retryable provider error", err)
+	}
+}
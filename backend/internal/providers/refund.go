@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// Natureza da devolução na API PIX do BACEN para cada motivo
+var refundNatures = map[domain.RefundReason]string{
+	domain.RefundReasonRequested:  "ORIGINAL",
+	domain.RefundReasonWithdrawal: "RETIRADA",
+	domain.RefundReasonBankError:  "MED_OPERACIONAL",
+	domain.RefundReasonFraud:      "MED_FRAUDE",
+}
+
+// Status da devolução na API PIX do BACEN
+var refundStatuses = map[string]domain.RefundStatus{
+	"EM_PROCESSAMENTO": domain.RefundStatusProcessing,
+	"DEVOLVIDO":        domain.RefundStatusCompleted,
+	"NAO_REALIZADO":    domain.RefundStatusFailed,
+}
+
+var refundIDPattern = regexp.MustCompile(`^[a-zA-Z0-9]{1,35}$`)
+
+// RefundPath retorna o caminho da devolução no padrão BACEN: /pix/{e2eid}/devolucao/{id}
+func RefundPath(e2eID, refundID string) string {
+	return fmt.Sprintf("/pix/%s/devolucao/%s", url.PathEscape(e2eID), url.PathEscape(refundID))
+}
+
+// NewRefundPayload monta o corpo da devolução no padrão da API PIX do BACEN
+func NewRefundPayload(req *RefundRequest) (map[string]interface{}, error) {
+	if req.E2EID == "" {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "Devolução exige o E2EID do PIX original", nil)
+	}
+	if !refundIDPattern.MatchString(req.RefundID) {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "ID da devolução deve ter até 35 caracteres alfanuméricos", nil)
+	}
+	if req.Amount <= 0 {
+		return nil, NewProviderError(ErrCodeInvalidRequest, "Valor da devolução deve ser positivo", nil)
+	}
+	nature, ok := refundNatures[req.Reason]
+	if !ok {
+		return nil, NewProviderError(ErrCodeInvalidRequest, fmt.Sprintf("Motivo de devolução inválido: %s", req.Reason), nil)
+	}
+
+	payload := map[string]interface{}{
+		"valor":    formatAmount(req.Amount),
+		"natureza": nature,
+	}
+	if req.Description != "" {
+		payload["descricao"] = req.Description
+	}
+	return payload, nil
+}
+
+// ParseRefundResponse interpreta a devolução retornada no padrão da API PIX do BACEN
+func ParseRefundResponse(body []byte) (*RefundResponse, error) {
+	var devolucao struct {
+		ID      string `json:"id"`
+		RtrID   string `json:"rtrId"`
+		Valor   string `json:"valor"`
+		Status  string `json:"status"`
+		Motivo  string `json:"motivo"`
+		Horario struct {
+			Solicitacao *time.Time `json:"solicitacao"`
+			Liquidacao  *time.Time `json:"liquidacao"`
+		} `json:"horario"`
+	}
+	if err := json.Unmarshal(body, &devolucao); err != nil {
+		return nil, NewProviderError("PARSE_ERROR", "Erro ao processar resposta", err)
+	}
+
+	amount, err := parseAmount(devolucao.Valor)
+	if err != nil {
+		return nil, NewProviderError("PARSE_ERROR", "Valor da devolução inválido", err)
+	}
+
+	status, ok := refundStatuses[devolucao.Status]
+	if !ok {
+		status = domain.RefundStatusProcessing
+	}
+
+	return &RefundResponse{
+		RefundID:     devolucao.ID,
+		RtrID:        devolucao.RtrID,
+		Amount:       amount,
+		Status:       status,
+		ErrorMessage: devolucao.Motivo,
+		RequestedAt:  devolucao.Horario.Solicitacao,
+		CompletedAt:  devolucao.Horario.Liquidacao,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestNewRefundPayload(t *testing.T) {
+	tests := []struct {
+		reason domain.RefundReason
+		nature string
+	}{
+		{domain.RefundReasonRequested, "ORIGINAL"},
+		{domain.RefundReasonWithdrawal, "RETIRADA"},
+		{domain.RefundReasonBankError, "MED_OPERACIONAL"},
+		{domain.RefundReasonFraud, "MED_FRAUDE"},
+	}
+
+	for _, tt := range tests {
+		payload, err := NewRefundPayload(&RefundRequest{E2EID: "E1", RefundID: "D123", Amount: 1050, Reason: tt.reason, Description: "Produto devolvido"})
+		if err != nil {
+			t.Fatalf("NewRefundPayload(%s) error = %v", tt.reason, err)
+		}
+		if payload["valor"] != "10.50" || payload["natureza"] != tt.nature || payload["descricao"] != "Produto devolvido" {
+			t.Errorf("NewRefundPayload(%s) = %v", tt.reason, payload)
+		}
+	}
+}
+
+func TestNewRefundPayloadRejectsInvalidRequest(t *testing.T) {
+	valid := RefundRequest{E2EID: "E1", RefundID: "D123", Amount: 100, Reason: domain.RefundReasonRequested}
+
+	for name, modify := range map[string]func(r *RefundRequest){
+		"missing e2eid":  func(r *RefundRequest) { r.E2EID = "" },
+		"invalid id":     func(r *RefundRequest) { r.RefundID = "devolução-1" },
+		"zero amount":    func(r *RefundRequest) { r.Amount = 0 },
+		"unknown reason": func(r *RefundRequest) { r.Reason = "AM09" },
+		"id too long":    func(r *RefundRequest) { r.RefundID = "D1234567890123456789012345678901234567" },
+	} {
+		req := valid
+		modify(&req)
+		_, err := NewRefundPayload(&req)
+		if err == nil {
+			t.Errorf("%s: NewRefundPayload() error = nil", name)
+			continue
+		}
+		assertProviderErrorCode(t, err, ErrCodeInvalidRequest)
+	}
+}
+
+func TestParseRefundResponse(t *testing.T) {
+	body := []byte(`{
+		"id": "D123",
+		"rtrId": "D12345678202009091000abcde123456",
+		"valor": "7.89",
+		"natureza": "ORIGINAL",
+		"horario": {"solicitacao": "2024-01-10T10:00:00Z", "liquidacao": "2024-01-10T10:00:02Z"},
+		"status": "DEVOLVIDO"
+	}`)
+
+	resp, err := ParseRefundResponse(body)
+	if err != nil {
+		t.Fatalf("ParseRefundResponse() error = %v", err)
+	}
+	if resp.RefundID != "D123" || resp.RtrID != "D12345678202009091000abcde123456" || resp.Amount != 789 || resp.Status != domain.RefundStatusCompleted {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.CompletedAt == nil || resp.CompletedAt.Second() != 2 {
+		t.Errorf("CompletedAt = %v", resp.CompletedAt)
+	}
+
+	failed, err := ParseRefundResponse([]byte(`{"id":"D124","valor":"1.00","status":"NAO_REALIZADO","motivo":"Saldo insuficiente"}`))
+	if err != nil || failed.Status != domain.RefundStatusFailed || failed.ErrorMessage != "Saldo insuficiente" {
+		t.Errorf("ParseRefundResponse() = %+v, %v", failed, err)
+	}
+}
+
+func TestRefundPath(t *testing.T) {
+	if got := RefundPath("E1234", "D1"); got != "/pix/E1234/devolucao/D1" {
+		t.Errorf("RefundPath() = %q", got)
+	}
+}
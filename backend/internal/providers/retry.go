@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseDelay é o intervalo inicial entre tentativas
+	DefaultRetryBaseDelay = 200 * time.Millisecond
+	// DefaultRetryMaxDelay limita o intervalo calculado entre tentativas
+	DefaultRetryMaxDelay = 5 * time.Second
+	// maxRetryAfter limita o tempo de espera solicitado pelo provider via Retry-After
+	maxRetryAfter = 30 * time.Second
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey marca as requisições do contexto como seguras para retry.
+//
+// A chave é o ID da transação (UUID sem hífens), enviado ao banco no header de
+// idempotência de cada adapter (ver WithIdempotencyHeader). Ela só permite que o
+// MESMO banco descarte repetições da requisição: não protege contra pagamento em
+// duplicidade quando a mesma transação é enviada a outro provider.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// WithIdempotencyHeader envia a chave de idempotência no header esperado pelo banco
+// e marca o contexto como seguro para retry. Sem chave, nada é alterado.
+func WithIdempotencyHeader(ctx context.Context, headers map[string]string, name, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	headers[name] = key
+	return WithIdempotencyKey(ctx, key)
+}
+
+// IdempotencyKeyFromContext retorna a chave de idempotência do contexto, se houver
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// isRetryableRequest indica se a requisição pode ser repetida sem risco de duplicidade
+func isRetryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return IdempotencyKeyFromContext(req.Context()) != ""
+}
+
+// isRetryableStatus indica se o status HTTP representa uma falha transitória
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// shouldRetry indica se a falha é transitória: erros de rede, 429 ou 5xx.
+// Falhas de certificado (ProviderError) não são repetidas.
+func shouldRetry(resp *http.Response, err error) bool {
+	if resp != nil {
+		// Status de sucesso com erro indica falha na leitura do corpo (conexão interrompida)
+		return isRetryableStatus(resp.StatusCode) || resp.StatusCode < 400
+	}
+
+	var providerErr *ProviderError
+	return !errors.As(err, &providerErr)
+}
+
+// backoffDelay calcula o intervalo antes da próxima tentativa (backoff exponencial com jitter)
+func (c *HTTPClient) backoffDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return delay
+		}
+	}
+
+	delay := c.retryBaseDelay << attempt
+	if delay <= 0 || delay > c.retryMaxDelay {
+		delay = c.retryMaxDelay
+	}
+
+	// Jitter: intervalo aleatório entre metade e o total do backoff
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter interpreta o header Retry-After (segundos ou data HTTP)
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = date.Sub(now)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay, true
+}
+
+// sleepContext aguarda o intervalo ou o cancelamento do contexto
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestRetryClient(3)
+	body, err := client.Get(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(body) != "ok" || calls.Load() != 3 {
+		t.Errorf("Get() body = %q after %d calls, want ok after 3", body, calls.Load())
+	}
+}
+
+func TestHTTPClientDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := newTestRetryClient(3)
+	if _, err := client.Get(context.Background(), server.URL, nil); err == nil {
+		t.Fatal("Get() expected error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestHTTPClientRetriesPostOnlyWithIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if calls.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := newTestRetryClient(3)
+	payload := map[string]string{"valor": "10.00"}
+
+	if _, err := client.Post(context.Background(), server.URL, payload, nil); err == nil {
+		t.Fatal("Post() without idempotency key should not be retried")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+
+	calls.Store(0)
+	bodies = nil
+	headers := map[string]string{}
+	ctx := WithIdempotencyHeader(context.Background(), headers, "X-Idempotency-Key", "3f2b9c1e8a7d4e6f9b0c1d2e3f4a5b6c")
+	if headers["X-Idempotency-Key"] == "" {
+		t.Fatal("WithIdempotencyHeader() did not set the header")
+	}
+	if _, err := client.Post(ctx, server.URL, payload, headers); err != nil {
+		t.Fatalf("Post() with idempotency key error = %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("retried bodies = %v, want identical payloads", bodies)
+	}
+}
+
+func TestWithIdempotencyHeaderWithoutKey(t *testing.T) {
+	headers := map[string]string{}
+	ctx := WithIdempotencyHeader(context.Background(), headers, "X-Idempotency-Key", "")
+	if len(headers) != 0 || IdempotencyKeyFromContext(ctx) != "" {
+		t.Errorf("headers = %v, key = %q; want request left unmarked", headers, IdempotencyKeyFromContext(ctx))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"2", 2 * time.Second, true},
+		{"3600", maxRetryAfter, true},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, true},
+		{"invalid", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func newTestRetryClient(maxRetries int) HTTPClient {
+	client := NewHTTPClient(5, false).WithRetries(maxRetries)
+	client.retryBaseDelay = time.Millisecond
+	client.retryMaxDelay = 5 * time.Millisecond
+	return client
+}
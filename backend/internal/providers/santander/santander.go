@@ -9,6 +9,8 @@ import (
 	"github.com/pixsaas/backend/internal/providers"
 )
 
+var _ providers.PixProvider = (*Provider)(nil)
+
 // Provider implementa o provider do Santander
 type Provider struct {
 	config     providers.ProviderConfig
@@ -32,8 +34,13 @@ func (p *Provider) GetName() string {
 
 // Initialize inicializa o provider
 func (p *Provider) Initialize(config providers.ProviderConfig) error {
+	httpClient, err := providers.NewHTTPClientFromConfig(config)
+	if err != nil {
+		return err
+	}
+
 	p.config = config
-	p.httpClient = providers.NewHTTPClient(config.Timeout, config.RequiresMTLS)
+	p.httpClient = httpClient
 	return nil
 }
 
@@ -103,6 +110,8 @@ func (p *Provider) CreateTransfer(ctx context.Context, req *providers.TransferRe
 		"X-Application-Key": req.ClientID,
 	}
 
+	ctx = providers.WithIdempotencyHeader(ctx, headers, "X-Idempotency-Key", req.IdempotencyKey)
+
 	resp, err := p.httpClient.Post(ctx, url, payload, headers)
 	if err != nil {
 		return nil, providers.NewProviderError("TRANSFER_FAILED", "Falha ao criar transferência", err)
@@ -201,6 +210,9 @@ func (p *Provider) CreateQRCodeStatic(ctx context.Context, req *providers.QRCode
 
 // CreateQRCodeDynamic cria um QR Code dinâmico
 func (p *Provider) CreateQRCodeDynamic(ctx context.Context, req *providers.QRCodeRequest) (*providers.QRCodeResponse, error) {
+	if err := providers.RejectDueDate(req); err != nil {
+		return nil, err
+	}
 	return p.CreateQRCodeStatic(ctx, req)
 }
 
@@ -236,9 +248,22 @@ func (p *Provider) GetQRCode(ctx context.Context, req *providers.GetQRCodeReques
 	}, nil
 }
 
-// ValidatePixKey valida uma chave PIX
+// RequestRefund não é suportado pela API do Santander integrada
+func (p *Provider) RequestRefund(ctx context.Context, req *providers.RefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Devolução não suportada pelo Santander", nil)
+}
+
+// GetRefund não é suportado pela API do Santander integrada
+func (p *Provider) GetRefund(ctx context.Context, req *providers.GetRefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.NewProviderError("NOT_SUPPORTED", "Devolução não suportada pelo Santander", nil)
+}
+
+// ValidatePixKey consulta no DICT o vínculo da chave PIX (titular e conta)
 func (p *Provider) ValidatePixKey(ctx context.Context, req *providers.ValidatePixKeyRequest) (*providers.ValidatePixKeyResponse, error) {
-	return nil, providers.NewProviderError("NOT_IMPLEMENTED", "Validação de chave não implementada", nil)
+	headers := map[string]string{
+		"X-Application-Key": req.ClientID,
+	}
+	return providers.LookupDictEntry(ctx, &p.httpClient, p.config.BaseURL+"/pix/v1", req, headers)
 }
 
 // HealthCheck verifica a saúde do provider
@@ -253,6 +278,11 @@ func (p *Provider) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ParseCallback autentica e interpreta um callback PIX enviado pelo banco
+func (p *Provider) ParseCallback(ctx context.Context, req *providers.CallbackRequest) ([]providers.CallbackNotification, error) {
+	return providers.ParsePixCallback(req, providers.DefaultCallbackSignatureHeader)
+}
+
 // GetSupportedMethods retorna os métodos suportados
 func (p *Provider) GetSupportedMethods() []string {
 	return []string{
@@ -261,6 +291,7 @@ func (p *Provider) GetSupportedMethods() []string {
 		"qrcode_dynamic",
 		"get_transfer",
 		"get_qrcode",
+		"validate_pix_key",
 	}
 }
 
@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TLSOptions contém o material TLS usado na comunicação mTLS com um provider
+type TLSOptions struct {
+	Certificate        []byte   // Certificado do cliente (PEM)
+	PrivateKey         []byte   // Chave privada do cliente (PEM)
+	CACertificates     []byte   // Bundle de CAs confiáveis (PEM, opcional)
+	PinnedCertificates []string // Hashes SHA-256 da chave pública (SPKI) em base64, formato "sha256/..." (opcional)
+}
+
+// errCertificatePinMismatch indica que o certificado do servidor não corresponde a nenhum pin configurado
+var errCertificatePinMismatch = errors.New("server certificate does not match any pinned key")
+
+// NewHTTPClientFromConfig cria o cliente HTTP adequado à configuração do provider
+func NewHTTPClientFromConfig(config ProviderConfig) (HTTPClient, error) {
+	if !config.RequiresMTLS {
+		return NewHTTPClient(config.Timeout, false).WithRetries(config.MaxRetries), nil
+	}
+
+	client, err := NewMTLSHTTPClient(config.Timeout, config.TLS)
+	if err != nil {
+		return HTTPClient{}, err
+	}
+	return client.WithRetries(config.MaxRetries), nil
+}
+
+// NewMTLSHTTPClient cria um cliente HTTP que se autentica com certificado de cliente (TLS 1.2+)
+func NewMTLSHTTPClient(timeout int, options TLSOptions) (HTTPClient, error) {
+	tlsConfig, err := NewTLSConfig(options)
+	if err != nil {
+		return HTTPClient{}, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	client := NewHTTPClient(timeout, true)
+	client.client.Transport = transport
+	client.mtlsConfigured = true
+	return client, nil
+}
+
+// NewTLSConfig monta a configuração TLS de cliente para providers com transporte próprio
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+	return buildTLSConfig(options, time.Now())
+}
+
+// buildTLSConfig valida o certificado do cliente e monta a configuração TLS
+func buildTLSConfig(options TLSOptions, now time.Time) (*tls.Config, error) {
+	if len(options.Certificate) == 0 || len(options.PrivateKey) == 0 {
+		return nil, NewProviderError("MTLS_NOT_CONFIGURED", "Certificado e chave privada são obrigatórios para mTLS", nil)
+	}
+
+	leaf, err := parseCertificatePEM(options.Certificate)
+	if err != nil {
+		return nil, NewProviderError("CERTIFICATE_INVALID", "Certificado do cliente inválido", err)
+	}
+
+	if now.After(leaf.NotAfter) {
+		return nil, NewProviderError("CERTIFICATE_EXPIRED", "Certificado do cliente expirado em "+leaf.NotAfter.Format(time.RFC3339), nil)
+	}
+	if now.Before(leaf.NotBefore) {
+		return nil, NewProviderError("CERTIFICATE_NOT_YET_VALID", "Certificado do cliente válido apenas a partir de "+leaf.NotBefore.Format(time.RFC3339), nil)
+	}
+
+	clientCert, err := tls.X509KeyPair(options.Certificate, options.PrivateKey)
+	if err != nil {
+		if strings.Contains(err.Error(), "does not match") {
+			return nil, NewProviderError("CERTIFICATE_KEY_MISMATCH", "Chave privada não corresponde ao certificado", err)
+		}
+		return nil, NewProviderError("PRIVATE_KEY_INVALID", "Chave privada do cliente inválida", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{clientCert},
+	}
+
+	if len(options.CACertificates) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(options.CACertificates) {
+			return nil, NewProviderError("CA_BUNDLE_INVALID", "Bundle de CAs inválido", nil)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(options.PinnedCertificates) > 0 {
+		pins := make(map[string]bool, len(options.PinnedCertificates))
+		for _, pin := range options.PinnedCertificates {
+			pins[strings.TrimPrefix(pin, "sha256/")] = true
+		}
+
+		// VerifyConnection roda após a validação padrão da cadeia
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			for _, cert := range state.PeerCertificates {
+				if pins[spkiFingerprint(cert)] {
+					return nil
+				}
+			}
+			return errCertificatePinMismatch
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// classifyTransportError converte falhas de TLS em erros de provider com códigos específicos
+func classifyTransportError(err error) error {
+	var invalidErr x509.CertificateInvalidError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+
+	switch {
+	case errors.Is(err, errCertificatePinMismatch):
+		return NewProviderError("CERTIFICATE_PIN_MISMATCH", "Certificado do servidor não corresponde ao pin configurado", err)
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		return NewProviderError("SERVER_CERTIFICATE_EXPIRED", "Certificado do servidor expirado", err)
+	case errors.As(err, &authorityErr):
+		return NewProviderError("SERVER_CERTIFICATE_UNTRUSTED", "Certificado do servidor não confiável", err)
+	case errors.As(err, &hostnameErr):
+		return NewProviderError("SERVER_CERTIFICATE_MISMATCH", "Certificado do servidor não corresponde ao host", err)
+	case strings.Contains(err.Error(), "remote error: tls:"):
+		// O servidor recusou o handshake (ex: certificado do cliente não aceito)
+		return NewProviderError("MTLS_HANDSHAKE_FAILED", "Servidor recusou o certificado do cliente", err)
+	}
+	return err
+}
+
+// SPKIFingerprint retorna o pin ("sha256/...") da chave pública de um certificado PEM
+func SPKIFingerprint(certPEM []byte) (string, error) {
+	cert, err := parseCertificatePEM(certPEM)
+	if err != nil {
+		return "", err
+	}
+	return "sha256/" + spkiFingerprint(cert), nil
+}
+
+func spkiFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func parseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,252 @@
+package providers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMTLSHTTPClientWithClientCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	server := newMTLSTestServer(t, ca)
+
+	certPEM, keyPEM := ca.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	client, err := NewMTLSHTTPClient(5, TLSOptions{
+		Certificate:    certPEM,
+		PrivateKey:     keyPEM,
+		CACertificates: serverCertPEM(server),
+	})
+	if err != nil {
+		t.Fatalf("NewMTLSHTTPClient() error = %v", err)
+	}
+
+	body, err := client.Get(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(body) != "client-cn" {
+		t.Errorf("Get() body = %q, want client-cn", body)
+	}
+}
+
+func TestMTLSHTTPClientRejectedByServer(t *testing.T) {
+	server := newMTLSTestServer(t, newTestCA(t))
+
+	// Certificado emitido por uma CA que o servidor não conhece
+	certPEM, keyPEM := newTestCA(t).issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	client, err := NewMTLSHTTPClient(5, TLSOptions{
+		Certificate:    certPEM,
+		PrivateKey:     keyPEM,
+		CACertificates: serverCertPEM(server),
+	})
+	if err != nil {
+		t.Fatalf("NewMTLSHTTPClient() error = %v", err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL, nil)
+	assertProviderErrorCode(t, err, "MTLS_HANDSHAKE_FAILED")
+}
+
+func TestMTLSHTTPClientCertificateErrors(t *testing.T) {
+	ca := newTestCA(t)
+	validCert, validKey := ca.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	expiredCert, expiredKey := ca.issueClientCert(t, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	_, otherKey := ca.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		options TLSOptions
+		code    string
+	}{
+		{"missing certificate", TLSOptions{}, "MTLS_NOT_CONFIGURED"},
+		{"expired certificate", TLSOptions{Certificate: expiredCert, PrivateKey: expiredKey}, "CERTIFICATE_EXPIRED"},
+		{"mismatched key", TLSOptions{Certificate: validCert, PrivateKey: otherKey}, "CERTIFICATE_KEY_MISMATCH"},
+		{"invalid certificate", TLSOptions{Certificate: []byte("invalid"), PrivateKey: validKey}, "CERTIFICATE_INVALID"},
+		{"invalid CA bundle", TLSOptions{Certificate: validCert, PrivateKey: validKey, CACertificates: []byte("invalid")}, "CA_BUNDLE_INVALID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMTLSHTTPClient(5, tt.options)
+			assertProviderErrorCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestMTLSHTTPClientCertificatePinning(t *testing.T) {
+	ca := newTestCA(t)
+	server := newMTLSTestServer(t, ca)
+	certPEM, keyPEM := ca.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+
+	serverPin, err := SPKIFingerprint(serverCertPEM(server))
+	if err != nil {
+		t.Fatalf("SPKIFingerprint() error = %v", err)
+	}
+	otherPin, err := SPKIFingerprint(certPEM)
+	if err != nil {
+		t.Fatalf("SPKIFingerprint() error = %v", err)
+	}
+
+	pinned, err := NewMTLSHTTPClient(5, TLSOptions{
+		Certificate:        certPEM,
+		PrivateKey:         keyPEM,
+		CACertificates:     serverCertPEM(server),
+		PinnedCertificates: []string{serverPin},
+	})
+	if err != nil {
+		t.Fatalf("NewMTLSHTTPClient() error = %v", err)
+	}
+	if _, err := pinned.Get(context.Background(), server.URL, nil); err != nil {
+		t.Errorf("Get() with matching pin error = %v", err)
+	}
+
+	mismatched, err := NewMTLSHTTPClient(5, TLSOptions{
+		Certificate:        certPEM,
+		PrivateKey:         keyPEM,
+		CACertificates:     serverCertPEM(server),
+		PinnedCertificates: []string{otherPin},
+	})
+	if err != nil {
+		t.Fatalf("NewMTLSHTTPClient() error = %v", err)
+	}
+	_, err = mismatched.Get(context.Background(), server.URL, nil)
+	assertProviderErrorCode(t, err, "CERTIFICATE_PIN_MISMATCH")
+}
+
+func TestMTLSHTTPClientUntrustedServer(t *testing.T) {
+	ca := newTestCA(t)
+	server := newMTLSTestServer(t, ca)
+	certPEM, keyPEM := ca.issueClientCert(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+
+	client, err := NewMTLSHTTPClient(5, TLSOptions{Certificate: certPEM, PrivateKey: keyPEM})
+	if err != nil {
+		t.Fatalf("NewMTLSHTTPClient() error = %v", err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL, nil)
+	assertProviderErrorCode(t, err, "SERVER_CERTIFICATE_UNTRUSTED")
+}
+
+func TestHTTPClientRequiresMTLSWithoutCertificate(t *testing.T) {
+	client := NewHTTPClient(5, true)
+
+	_, err := client.Get(context.Background(), "https://example.invalid", nil)
+	assertProviderErrorCode(t, err, "MTLS_NOT_CONFIGURED")
+}
+
+func assertProviderErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("error = %v, want ProviderError %s", err, code)
+	}
+	if providerErr.Code != code {
+		t.Errorf("error code = %s, want %s (%v)", providerErr.Code, code, err)
+	}
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-72 * time.Hour),
+		NotAfter:              time.Now().Add(72 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issueClientCert(t *testing.T, notBefore, notAfter time.Time) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "client-cn"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+// newMTLSTestServer inicia um servidor TLS que exige certificado de cliente emitido pela CA
+func newMTLSTestServer(t *testing.T, ca *testCA) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	server.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Handshakes recusados são esperados
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func serverCertPEM(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
@@ -0,0 +1,151 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// DefaultTokenRefreshMargin é a antecedência com que um token é renovado antes de expirar
+const DefaultTokenRefreshMargin = 60 * time.Second
+
+// TokenInfoUpdater persiste informações de token de uma configuração merchant-provider
+type TokenInfoUpdater interface {
+	UpdateTokenInfo(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+}
+
+// TokenCache mantém tokens de acesso por merchant e provider.
+// Renovações concorrentes para a mesma chave são agrupadas em uma única chamada ao provider.
+type TokenCache struct {
+	mu            sync.Mutex
+	tokens        map[tokenCacheKey]*AuthToken
+	inflight      map[tokenCacheKey]*tokenCall
+	store         TokenInfoUpdater
+	refreshMargin time.Duration
+}
+
+type tokenCacheKey struct {
+	merchantID uuid.UUID
+	providerID uuid.UUID
+}
+
+type tokenCall struct {
+	done  chan struct{}
+	token *AuthToken
+	err   error
+}
+
+// NewTokenCache cria um novo cache de tokens
+func NewTokenCache(store TokenInfoUpdater, refreshMargin time.Duration) *TokenCache {
+	return &TokenCache{
+		tokens:        make(map[tokenCacheKey]*AuthToken),
+		inflight:      make(map[tokenCacheKey]*tokenCall),
+		store:         store,
+		refreshMargin: refreshMargin,
+	}
+}
+
+// GetToken retorna um token válido para o merchant-provider, autenticando ou renovando se necessário
+func (c *TokenCache) GetToken(
+	ctx context.Context,
+	provider PixProvider,
+	mp *domain.MerchantProvider,
+	credentials ProviderCredentials,
+) (*AuthToken, error) {
+	key := tokenCacheKey{merchantID: mp.MerchantID, providerID: mp.ProviderID}
+
+	c.mu.Lock()
+	current := c.tokens[key]
+	if c.isFresh(current) {
+		c.mu.Unlock()
+		return current, nil
+	}
+
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		return call.wait(ctx)
+	}
+
+	call := &tokenCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.token, call.err = c.fetch(ctx, provider, current, credentials)
+
+	c.mu.Lock()
+	if call.err == nil {
+		c.tokens[key] = call.token
+	}
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(call.done)
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	if c.store != nil {
+		if err := c.store.UpdateTokenInfo(ctx, mp.ID, call.token.ExpiresAt); err != nil {
+			log.Printf("Aviso: falha ao registrar renovação de token do merchant-provider %s: %v", mp.ID, err)
+		}
+	}
+
+	return call.token, nil
+}
+
+// Invalidate remove o token em cache do merchant-provider (ex: após resposta 401)
+func (c *TokenCache) Invalidate(merchantID, providerID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tokens, tokenCacheKey{merchantID: merchantID, providerID: providerID})
+}
+
+// InvalidateRevoked descarta o token do merchant-provider quando o banco rejeitou a chamada
+// com 401 (token revogado), para a próxima chamada autenticar de novo
+func (c *TokenCache) InvalidateRevoked(err error, mp *domain.MerchantProvider) {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusUnauthorized {
+		c.Invalidate(mp.MerchantID, mp.ProviderID)
+	}
+}
+
+// fetch renova o token via refresh token (quando suportado) ou realiza nova autenticação
+func (c *TokenCache) fetch(ctx context.Context, provider PixProvider, current *AuthToken, credentials ProviderCredentials) (*AuthToken, error) {
+	if current != nil && current.RefreshToken != "" {
+		token, err := provider.RefreshToken(ctx, current.RefreshToken)
+		if err == nil && token != nil {
+			if token.RefreshToken == "" {
+				token.RefreshToken = current.RefreshToken
+			}
+			return token, nil
+		}
+	}
+
+	token, err := provider.Authenticate(ctx, credentials)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, errors.New("provider returned empty token")
+	}
+	return token, nil
+}
+
+func (c *TokenCache) isFresh(token *AuthToken) bool {
+	return token != nil && time.Now().Add(c.refreshMargin).Before(token.ExpiresAt)
+}
+
+func (call *tokenCall) wait(ctx context.Context) (*AuthToken, error) {
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestTokenCacheReusesValidToken(t *testing.T) {
+	provider := &authCountingProvider{expiresIn: time.Hour}
+	store := &mockTokenInfoUpdater{}
+	cache := NewTokenCache(store, DefaultTokenRefreshMargin)
+	mp := &domain.MerchantProvider{ID: uuid.New(), MerchantID: uuid.New(), ProviderID: uuid.New()}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+			t.Fatalf("GetToken() error = %v", err)
+		}
+	}
+
+	if got := provider.authCalls.Load(); got != 1 {
+		t.Errorf("Authenticate() calls = %d, want 1", got)
+	}
+
+	if got := store.calls.Load(); got != 1 {
+		t.Errorf("UpdateTokenInfo() calls = %d, want 1", got)
+	}
+}
+
+func TestTokenCacheRefreshesBeforeExpiry(t *testing.T) {
+	provider := &authCountingProvider{expiresIn: 30 * time.Second, refreshToken: "refresh"}
+	cache := NewTokenCache(nil, DefaultTokenRefreshMargin)
+	mp := &domain.MerchantProvider{ID: uuid.New(), MerchantID: uuid.New(), ProviderID: uuid.New()}
+
+	if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	// Token expira dentro da margem de renovação: deve usar RefreshToken
+	if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	if got := provider.authCalls.Load(); got != 1 {
+		t.Errorf("Authenticate() calls = %d, want 1", got)
+	}
+
+	if got := provider.refreshCalls.Load(); got != 1 {
+		t.Errorf("RefreshToken() calls = %d, want 1", got)
+	}
+}
+
+func TestTokenCacheCollapsesConcurrentRefreshes(t *testing.T) {
+	provider := &authCountingProvider{expiresIn: time.Hour, delay: 50 * time.Millisecond}
+	cache := NewTokenCache(nil, DefaultTokenRefreshMargin)
+	mp := &domain.MerchantProvider{ID: uuid.New(), MerchantID: uuid.New(), ProviderID: uuid.New()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+				t.Errorf("GetToken() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := provider.authCalls.Load(); got != 1 {
+		t.Errorf("Authenticate() calls = %d, want 1", got)
+	}
+}
+
+func TestTokenCacheInvalidate(t *testing.T) {
+	provider := &authCountingProvider{expiresIn: time.Hour}
+	cache := NewTokenCache(nil, DefaultTokenRefreshMargin)
+	mp := &domain.MerchantProvider{ID: uuid.New(), MerchantID: uuid.New(), ProviderID: uuid.New()}
+
+	if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	cache.Invalidate(mp.MerchantID, mp.ProviderID)
+
+	if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	if got := provider.authCalls.Load(); got != 2 {
+		t.Errorf("Authenticate() calls = %d, want 2", got)
+	}
+}
+
+func TestTokenCacheInvalidateRevoked(t *testing.T) {
+	provider := &authCountingProvider{expiresIn: time.Hour}
+	cache := NewTokenCache(nil, DefaultTokenRefreshMargin)
+	mp := &domain.MerchantProvider{ID: uuid.New(), MerchantID: uuid.New(), ProviderID: uuid.New()}
+
+	if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+
+	// Outros erros mantêm o token; 401 o descarta
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		cache.InvalidateRevoked(NewProviderError("TRANSFER_FAILED", "falha", NewHTTPError(status, nil, nil)), mp)
+		if _, err := cache.GetToken(context.Background(), provider, mp, ProviderCredentials{}); err != nil {
+			t.Fatalf("GetToken() error = %v", err)
+		}
+	}
+
+	if got := provider.authCalls.Load(); got != 2 {
+		t.Errorf("Authenticate() calls = %d, want 2", got)
+	}
+}
+
+type authCountingProvider struct {
+	MockProvider
+	expiresIn    time.Duration
+	refreshToken string
+	delay        time.Duration
+	authCalls    atomic.Int32
+	refreshCalls atomic.Int32
+}
+
+func (p *authCountingProvider) Authenticate(ctx context.Context, credentials ProviderCredentials) (*AuthToken, error) {
+	p.authCalls.Add(1)
+	time.Sleep(p.delay)
+	return &AuthToken{
+		AccessToken:  "token",
+		RefreshToken: p.refreshToken,
+		ExpiresAt:    time.Now().Add(p.expiresIn),
+	}, nil
+}
+
+func (p *authCountingProvider) RefreshToken(ctx context.Context, refreshToken string) (*AuthToken, error) {
+	p.refreshCalls.Add(1)
+	return &AuthToken{
+		AccessToken: "refreshed",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}, nil
+}
+
+type mockTokenInfoUpdater struct {
+	calls atomic.Int32
+}
+
+func (m *mockTokenInfoUpdater) UpdateTokenInfo(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	m.calls.Add(1)
+	return nil
+}
@@ -0,0 +1,88 @@
+package providers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+// AttemptStore persiste as tentativas de execução de uma transação
+type AttemptStore interface {
+	CreateAttempts(ctx context.Context, attempts []domain.TransactionAttempt) error
+}
+
+// AttemptAuditor registra na auditoria cada chamada feita a um provider
+type AttemptAuditor interface {
+	LogProviderOperation(ctx context.Context, merchantID, transactionID uuid.UUID, provider, operation string, success bool, errorMsg string, duration int64) error
+}
+
+// NewTransferRequest monta a requisição de transferência da transação no provider do merchant.
+// Dados do recebedor que não ficam na transação (ISPB, tipo de conta, txid do BR Code) são
+// completados por quem chama.
+func NewTransferRequest(tx *domain.Transaction, mp *domain.MerchantProvider, authToken, clientID string) *TransferRequest {
+	return &TransferRequest{
+		ExternalID:  tx.ExternalID,
+		Amount:      tx.Amount,
+		Description: tx.Description,
+
+		// Pagador (merchant)
+		PayerAccountAgency: mp.AccountAgency,
+		PayerAccountNumber: mp.AccountNumber,
+		PayerAccountType:   mp.AccountType,
+		PayerPixKey:        mp.PixKey,
+		PayerPixKeyType:    mp.PixKeyType,
+
+		// Recebedor
+		PayeeName:          tx.PayeeName,
+		PayeeDocument:      tx.PayeeDocument,
+		PayeePixKey:        tx.PayeePixKey,
+		PayeePixKeyType:    tx.PayeePixKeyType,
+		PayeeBank:          tx.PayeeBank,
+		PayeeAccountAgency: tx.PayeeAccountAgency,
+		PayeeAccountNumber: tx.PayeeAccountNumber,
+
+		QRCode: tx.QRCode,
+
+		Metadata: tx.Metadata,
+
+		// Permite ao mesmo banco descartar repetições; entre bancos diferentes não há proteção,
+		// por isso o fallback só ocorre quando a requisição comprovadamente não foi processada
+		IdempotencyKey: TransferIdempotencyKey(tx.ID),
+
+		AuthToken: authToken,
+		ClientID:  clientID,
+	}
+}
+
+// TransferIdempotencyKey retorna a chave de idempotência enviada ao banco: o ID da transação sem hífens
+func TransferIdempotencyKey(txID uuid.UUID) string {
+	return strings.ReplaceAll(txID.String(), "-", "")
+}
+
+// RecordAttempts persiste e audita as tentativas de execução nos providers
+func RecordAttempts(ctx context.Context, store AttemptStore, auditor AttemptAuditor, tx *domain.Transaction, attempts []ProviderAttempt, operation string) {
+	records := make([]domain.TransactionAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		records = append(records, domain.TransactionAttempt{
+			ID:            uuid.New(),
+			TransactionID: tx.ID,
+			ProviderID:    attempt.ProviderID,
+			Attempt:       attempt.Attempt,
+			Success:       attempt.Success,
+			ErrorCode:     attempt.ErrorCode,
+			ErrorMessage:  attempt.ErrorMessage,
+			Retryable:     attempt.Retryable,
+			Duration:      attempt.Duration,
+			CreatedAt:     attempt.StartedAt,
+		})
+
+		_ = auditor.LogProviderOperation(ctx, tx.MerchantID, tx.ID, attempt.ProviderCode, operation, attempt.Success, attempt.ErrorMessage, attempt.Duration)
+	}
+
+	if err := store.CreateAttempts(ctx, records); err != nil {
+		log.Printf("Aviso: falha ao registrar tentativas da transação %s: %v", tx.ID, err)
+	}
+}
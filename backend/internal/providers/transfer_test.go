@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestNewTransferRequest(t *testing.T) {
+	tx := &domain.Transaction{
+		ID:                 uuid.MustParse("6f1c2d3e-4b5a-4c6d-8e7f-901234567890"),
+		ExternalID:         "ORDER-1",
+		Amount:             1500,
+		PayeeName:          "Fulano",
+		PayeeDocument:      "52998224725",
+		PayeeAccountAgency: "0001",
+		PayeeAccountNumber: "12345-6",
+		QRCode:             "000201",
+	}
+	mp := &domain.MerchantProvider{AccountAgency: "1234", PixKey: "loja@example.com", PixKeyType: domain.PixKeyTypeEmail}
+
+	req := NewTransferRequest(tx, mp, "token", "client")
+
+	if req.ExternalID != "ORDER-1" || req.Amount != 1500 || req.PayeeAccountNumber != "12345-6" || req.QRCode != "000201" {
+		t.Errorf("request = %+v", req)
+	}
+	if req.PayerAccountAgency != "1234" || req.PayerPixKey != "loja@example.com" || req.AuthToken != "token" || req.ClientID != "client" {
+		t.Errorf("payer/auth = %+v", req)
+	}
+	if req.IdempotencyKey != "6f1c2d3e4b5a4c6d8e7f901234567890" {
+		t.Errorf("IdempotencyKey = %q", req.IdempotencyKey)
+	}
+}
+
+func TestRecordAttempts(t *testing.T) {
+	store := &attemptRecorder{}
+	tx := &domain.Transaction{ID: uuid.New(), MerchantID: uuid.New()}
+	attempts := []ProviderAttempt{
+		{ProviderID: uuid.New(), ProviderCode: "bb", Attempt: 1, ErrorCode: ErrCodeBankUnavailable, Retryable: true},
+		{ProviderID: uuid.New(), ProviderCode: "inter", Attempt: 2, Success: true},
+	}
+
+	RecordAttempts(context.Background(), store, store, tx, attempts, "create_transfer")
+
+	if len(store.records) != 2 || store.records[0].TransactionID != tx.ID || !store.records[0].Retryable || !store.records[1].Success {
+		t.Errorf("records = %+v", store.records)
+	}
+	if len(store.operations) != 2 || store.operations[1] != "inter:create_transfer" {
+		t.Errorf("audited operations = %v", store.operations)
+	}
+}
+
+type attemptRecorder struct {
+	records    []domain.TransactionAttempt
+	operations []string
+}
+
+func (r *attemptRecorder) CreateAttempts(ctx context.Context, attempts []domain.TransactionAttempt) error {
+	r.records = append(r.records, attempts...)
+	return nil
+}
+
+func (r *attemptRecorder) LogProviderOperation(ctx context.Context, merchantID, transactionID uuid.UUID, provider, operation string, success bool, errorMsg string, duration int64) error {
+	r.operations = append(r.operations, provider+":"+operation)
+	return nil
+}
@@ -0,0 +1,216 @@
+// Package qrimage renderiza códigos PIX (BR Code) como imagem de QR Code em PNG ou SVG.
+package qrimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Format é o formato da imagem gerada
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// Level é o nível de correção de erros do QR Code
+type Level string
+
+const (
+	LevelLow     Level = "L" // ~7% de recuperação
+	LevelMedium  Level = "M" // ~15% de recuperação
+	LevelQuarter Level = "Q" // ~25% de recuperação
+	LevelHigh    Level = "H" // ~30% de recuperação
+)
+
+// Limites de renderização
+const (
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 2048
+	MaxLogoMargin = 25 // Percentual da largura do código
+)
+
+// Options configura a renderização do QR Code
+type Options struct {
+	Format Format // Vazio = PNG
+	Size   int    // Largura e altura em pixels (0 = DefaultSize)
+	Level  Level  // Vazio = M, ou H quando há margem para logo
+	// LogoMargin reserva um quadrado em branco no centro, com o percentual indicado
+	// da largura do código, para sobrepor um logo. Exige correção de erros Q ou H.
+	LogoMargin int
+}
+
+// ContentType retorna o MIME type do formato
+func (f Format) ContentType() string {
+	if f == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Validate verifica se as opções são válidas
+func (o Options) Validate() error {
+	_, err := o.withDefaults()
+	return err
+}
+
+// withDefaults valida as opções e preenche os valores padrão
+func (o Options) withDefaults() (Options, error) {
+	if o.Format == "" {
+		o.Format = FormatPNG
+	}
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return o, fmt.Errorf("formato de imagem inválido: %s", o.Format)
+	}
+
+	if o.Size == 0 {
+		o.Size = DefaultSize
+	}
+	if o.Size < MinSize || o.Size > MaxSize {
+		return o, fmt.Errorf("tamanho deve estar entre %d e %d pixels", MinSize, MaxSize)
+	}
+
+	if o.LogoMargin < 0 || o.LogoMargin > MaxLogoMargin {
+		return o, fmt.Errorf("margem do logo deve estar entre 0 e %d%%", MaxLogoMargin)
+	}
+
+	if o.Level == "" {
+		o.Level = LevelMedium
+		if o.LogoMargin > 0 {
+			o.Level = LevelHigh
+		}
+	}
+	o.Level = Level(strings.ToUpper(string(o.Level)))
+	if _, ok := recoveryLevels[o.Level]; !ok {
+		return o, fmt.Errorf("nível de correção inválido: %s", o.Level)
+	}
+	if o.LogoMargin > 0 && (o.Level == LevelLow || o.Level == LevelMedium) {
+		return o, errors.New("margem para logo exige correção de erros Q ou H")
+	}
+
+	return o, nil
+}
+
+var recoveryLevels = map[Level]qrcode.RecoveryLevel{
+	LevelLow:     qrcode.Low,
+	LevelMedium:  qrcode.Medium,
+	LevelQuarter: qrcode.High,
+	LevelHigh:    qrcode.Highest,
+}
+
+// Render gera a imagem do QR Code para o conteúdo informado
+func Render(content string, opts Options) ([]byte, error) {
+	if content == "" {
+		return nil, errors.New("conteúdo do QR Code é obrigatório")
+	}
+
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, recoveryLevels[opts.Level])
+	if err != nil {
+		return nil, fmt.Errorf("falha ao gerar QR Code: %w", err)
+	}
+
+	modules := code.Bitmap()
+	clearLogoArea(modules, opts.LogoMargin)
+
+	if opts.Format == FormatSVG {
+		return renderSVG(modules, opts.Size), nil
+	}
+	return renderPNG(modules, opts.Size)
+}
+
+// RenderBase64 gera a imagem codificada em Base64, no formato de QRCodeResponse.QRCodeImage
+func RenderBase64(content string, opts Options) (string, error) {
+	data, err := Render(content, opts)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// clearLogoArea apaga os módulos do quadrado central reservado ao logo
+func clearLogoArea(modules [][]bool, margin int) {
+	if margin == 0 {
+		return
+	}
+
+	n := len(modules)
+	side := n * margin / 100
+	if side == 0 {
+		return
+	}
+	start := (n - side) / 2
+	for y := start; y < start+side; y++ {
+		for x := start; x < start+side; x++ {
+			modules[y][x] = false
+		}
+	}
+}
+
+// renderPNG desenha os módulos em escala inteira, centralizados na imagem
+func renderPNG(modules [][]bool, size int) ([]byte, error) {
+	n := len(modules)
+	scale := size / n
+	if scale == 0 {
+		scale = 1
+		size = n
+	}
+	offset := (size - n*scale) / 2
+
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("falha ao codificar PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG gera um SVG com um único path para os módulos escuros
+func renderSVG(modules [][]bool, size int) []byte {
+	n := len(modules)
+
+	var path strings.Builder
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, n, n)
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000"/>`, path.String())
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,119 @@
+package qrimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const testBRCode = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestRenderPNG(t *testing.T) {
+	data, err := Render(testBRCode, Options{Size: 300})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 300 || bounds.Dy() != 300 {
+		t.Errorf("image size = %dx%d, want 300x300", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	data, err := Render(testBRCode, Options{Format: FormatSVG, Size: 512})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	svg := string(data)
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `width="512"`) || !strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("unexpected SVG: %.120s", svg)
+	}
+}
+
+func TestRenderBase64(t *testing.T) {
+	encoded, err := RenderBase64(testBRCode, Options{})
+	if err != nil {
+		t.Fatalf("RenderBase64() error = %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("png.Decode() error = %v", err)
+	}
+}
+
+func TestClearLogoArea(t *testing.T) {
+	code, err := qrcode.New(testBRCode, qrcode.Highest)
+	if err != nil {
+		t.Fatalf("qrcode.New() error = %v", err)
+	}
+	modules := code.Bitmap()
+	clearLogoArea(modules, 20)
+
+	n := len(modules)
+	side := n * 20 / 100
+	start := (n - side) / 2
+	for y := start; y < start+side; y++ {
+		for x := start; x < start+side; x++ {
+			if modules[y][x] {
+				t.Fatalf("module (%d,%d) inside logo area is dark", x, y)
+			}
+		}
+	}
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	opts, err := Options{}.withDefaults()
+	if err != nil {
+		t.Fatalf("withDefaults() error = %v", err)
+	}
+	if opts.Format != FormatPNG || opts.Size != DefaultSize || opts.Level != LevelMedium {
+		t.Errorf("defaults = %+v", opts)
+	}
+
+	opts, err = Options{LogoMargin: 20}.withDefaults()
+	if err != nil {
+		t.Fatalf("withDefaults() error = %v", err)
+	}
+	if opts.Level != LevelHigh {
+		t.Errorf("Level = %s, want H with logo margin", opts.Level)
+	}
+}
+
+func TestRenderRejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown format", Options{Format: "gif"}},
+		{"too small", Options{Size: MinSize - 1}},
+		{"too large", Options{Size: MaxSize + 1}},
+		{"unknown level", Options{Level: "X"}},
+		{"logo margin too large", Options{LogoMargin: MaxLogoMargin + 1}},
+		{"logo with low correction", Options{Level: LevelMedium, LogoMargin: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Render(testBRCode, tt.opts); err == nil {
+				t.Error("Render() error = nil, want error")
+			}
+		})
+	}
+
+	if _, err := Render("", Options{}); err == nil {
+		t.Error("Render() with empty content error = nil, want error")
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pixsaas/backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository gerencia as chaves de idempotência das requisições
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository cria um novo repositório de chaves de idempotência
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Acquire registra a chave para a requisição atual. Se a chave já existir e ainda for válida,
+// retorna o registro existente sem adquiri-la. Chaves expiradas ou abandonadas (requisição
+// interrompida antes de concluir) são reaproveitadas.
+func (r *IdempotencyRepository) Acquire(ctx context.Context, record *domain.IdempotencyKey, now time.Time) (bool, *domain.IdempotencyKey, error) {
+	db := r.db.WithContext(ctx)
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil, nil
+	}
+
+	var existing domain.IdempotencyKey
+	if err := db.Where("merchant_id = ? AND key = ?", record.MerchantID, record.Key).First(&existing).Error; err != nil {
+		return false, nil, err
+	}
+
+	abandoned := existing.CompletedAt == nil && existing.LockedUntil.Before(now)
+	if !existing.ExpiresAt.Before(now) && !abandoned {
+		return false, &existing, nil
+	}
+
+	// Reaproveita a chave apenas se ninguém a assumiu desde a leitura
+	takeover := db.Model(&domain.IdempotencyKey{}).
+		Where("id = ? AND updated_at = ?", existing.ID, existing.UpdatedAt).
+		Updates(map[string]interface{}{
+			"method":        record.Method,
+			"path":          record.Path,
+			"request_hash":  record.RequestHash,
+			"status_code":   0,
+			"content_type":  "",
+			"response_body": nil,
+			"locked_until":  record.LockedUntil,
+			"completed_at":  nil,
+			"expires_at":    record.ExpiresAt,
+			"created_at":    now,
+			"updated_at":    now,
+		})
+	if takeover.Error != nil {
+		return false, nil, takeover.Error
+	}
+	if takeover.RowsAffected == 0 {
+		return false, &existing, nil
+	}
+
+	record.ID = existing.ID
+	return true, nil, nil
+}
+
+// Complete armazena a resposta da requisição e libera a chave para reprodução
+func (r *IdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Model(&domain.IdempotencyKey{}).
+		Where("id = ?", record.ID).
+		Updates(map[string]interface{}{
+			"status_code":   record.StatusCode,
+			"content_type":  record.ContentType,
+			"response_body": record.ResponseBody,
+			"completed_at":  record.CompletedAt,
+			"updated_at":    time.Now(),
+		}).Error
+}
+
+// Release remove a chave para que a requisição possa ser repetida
+func (r *IdempotencyRepository) Release(ctx context.Context, record *domain.IdempotencyKey) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? AND completed_at IS NULL", record.ID).
+		Delete(&domain.IdempotencyKey{}).Error
+}
+
+// DeleteExpired remove as chaves expiradas
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("expires_at < ? AND (completed_at IS NOT NULL OR locked_until < ?)", now, now).
+		Delete(&domain.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -90,6 +90,27 @@ func (r *ProviderRepository) UpdateHealthStatus(ctx context.Context, id uuid.UUI
 		}).Error
 }
 
+// RecordHealthCheck registra o resultado de uma verificação de saúde
+func (r *ProviderRepository) RecordHealthCheck(ctx context.Context, check *domain.ProviderHealthCheck) error {
+	return r.db.WithContext(ctx).Create(check).Error
+}
+
+// ListHealthChecks lista o histórico de verificações de saúde de um provider (mais recentes primeiro)
+func (r *ProviderRepository) ListHealthChecks(ctx context.Context, providerID uuid.UUID, since time.Time, limit int) ([]domain.ProviderHealthCheck, error) {
+	var checks []domain.ProviderHealthCheck
+	err := r.db.WithContext(ctx).
+		Where("provider_id = ? AND checked_at >= ?", providerID, since).
+		Order("checked_at DESC").
+		Limit(limit).
+		Find(&checks).Error
+	return checks, err
+}
+
+// DeleteHealthChecksBefore remove verificações de saúde anteriores à data informada
+func (r *ProviderRepository) DeleteHealthChecksBefore(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("checked_at < ?", before).Delete(&domain.ProviderHealthCheck{}).Error
+}
+
 // GetHealthyProviders retorna providers saudáveis ordenados por prioridade
 func (r *ProviderRepository) GetHealthyProviders(ctx context.Context) ([]domain.Provider, error) {
 	var providers []domain.Provider
@@ -158,6 +179,21 @@ func (r *MerchantProviderRepository) ListByMerchant(ctx context.Context, merchan
 	return mps, err
 }
 
+// ListByProvider lista as configurações de merchants que usam um provider
+func (r *MerchantProviderRepository) ListByProvider(ctx context.Context, providerID uuid.UUID, activeOnly bool) ([]domain.MerchantProvider, error) {
+	var mps []domain.MerchantProvider
+	query := r.db.WithContext(ctx).
+		Preload("Provider").
+		Where("provider_id = ? AND deleted_at IS NULL", providerID)
+
+	if activeOnly {
+		query = query.Where("active = true")
+	}
+
+	err := query.Find(&mps).Error
+	return mps, err
+}
+
 // Update atualiza uma configuração
 func (r *MerchantProviderRepository) Update(ctx context.Context, mp *domain.MerchantProvider) error {
 	return r.db.WithContext(ctx).Save(mp).Error
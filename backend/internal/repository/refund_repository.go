@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefundRepository gerencia as devoluções de PIX recebidos
+type RefundRepository struct {
+	db *gorm.DB
+}
+
+// NewRefundRepository cria um novo repositório de devoluções
+func NewRefundRepository(db *gorm.DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+// Reserve registra a devolução se, somada às devoluções não recusadas, ela não ultrapassar
+// limit. Com Amount zero, reserva todo o saldo restante. A transação original fica bloqueada
+// durante a verificação para que devoluções simultâneas não excedam o valor.
+func (r *RefundRepository) Reserve(ctx context.Context, refund *domain.Refund, limit int64) error {
+	return r.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var original domain.Transaction
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", refund.TransactionID).
+			First(&original).Error; err != nil {
+			return err
+		}
+
+		var reserved int64
+		if err := db.Model(&domain.Refund{}).
+			Where("transaction_id = ? AND status <> ?", refund.TransactionID, domain.RefundStatusFailed).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&reserved).Error; err != nil {
+			return err
+		}
+
+		if refund.Amount == 0 {
+			refund.Amount = limit - reserved
+		}
+		if refund.Amount <= 0 || reserved+refund.Amount > limit {
+			return domain.ErrRefundExceedsAmount
+		}
+
+		return db.Omit(clause.Associations).Create(refund).Error
+	})
+}
+
+// Update atualiza uma devolução
+func (r *RefundRepository) Update(ctx context.Context, refund *domain.Refund) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(refund).Error
+}
+
+// GetByID busca uma devolução de uma transação
+func (r *RefundRepository) GetByID(ctx context.Context, transactionID, id uuid.UUID) (*domain.Refund, error) {
+	var refund domain.Refund
+	err := r.db.WithContext(ctx).
+		Where("transaction_id = ? AND id = ?", transactionID, id).
+		First(&refund).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ListByTransaction lista as devoluções de uma transação
+func (r *RefundRepository) ListByTransaction(ctx context.Context, transactionID uuid.UUID) ([]domain.Refund, error) {
+	var refunds []domain.Refund
+	err := r.db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+// CompletedAmount soma as devoluções já liquidadas de uma transação
+func (r *RefundRepository) CompletedAmount(ctx context.Context, transactionID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&domain.Refund{}).
+		Where("transaction_id = ? AND status = ?", transactionID, domain.RefundStatusCompleted).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+// ScheduledTransferRepository gerencia as transferências agendadas
+type ScheduledTransferRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduledTransferRepository cria um novo repositório de transferências agendadas
+func NewScheduledTransferRepository(db *gorm.DB) *ScheduledTransferRepository {
+	return &ScheduledTransferRepository{db: db}
+}
+
+// Create cria uma transferência agendada. external_id repetido no merchant retorna gorm.ErrDuplicatedKey.
+func (r *ScheduledTransferRepository) Create(ctx context.Context, schedule *domain.ScheduledTransfer) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+// GetByID busca uma transferência agendada do merchant
+func (r *ScheduledTransferRepository) GetByID(ctx context.Context, merchantID, id uuid.UUID) (*domain.ScheduledTransfer, error) {
+	var schedule domain.ScheduledTransfer
+	err := r.db.WithContext(ctx).
+		Where("merchant_id = ? AND id = ?", merchantID, id).
+		First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListByMerchant lista as transferências agendadas do merchant, opcionalmente por status
+func (r *ScheduledTransferRepository) ListByMerchant(ctx context.Context, merchantID uuid.UUID, status domain.ScheduledTransferStatus, limit, offset int) ([]domain.ScheduledTransfer, int64, error) {
+	var schedules []domain.ScheduledTransfer
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.ScheduledTransfer{}).Where("merchant_id = ?", merchantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&schedules).Error
+
+	return schedules, total, err
+}
+
+// GetDue busca agendamentos ativos cuja próxima execução já chegou
+func (r *ScheduledTransferRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	var schedules []domain.ScheduledTransfer
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_run_at <= ?", domain.ScheduledTransferActive, now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// Claim grava o agendamento já avançado para a próxima execução apenas se ele continuar ativo
+// e com o número de execuções lido (expectedOccurrences). Retorna false quando outro worker
+// reservou a execução ou o agendamento foi cancelado.
+func (r *ScheduledTransferRepository) Claim(ctx context.Context, schedule *domain.ScheduledTransfer, expectedOccurrences int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(schedule).
+		Where("status = ? AND occurrences = ?", domain.ScheduledTransferActive, expectedOccurrences).
+		Select("next_run_at", "occurrences", "status", "last_transaction_id", "last_run_at", "updated_at").
+		Updates(schedule)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Cancel cancela o agendamento se ele ainda estiver ativo. Execuções já iniciadas não são afetadas.
+func (r *ScheduledTransferRepository) Cancel(ctx context.Context, schedule *domain.ScheduledTransfer, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(schedule).
+		Where("status = ?", domain.ScheduledTransferActive).
+		Select("status", "next_run_at", "cancelled_at", "updated_at").
+		Updates(&domain.ScheduledTransfer{
+			Status:      domain.ScheduledTransferCancelled,
+			NextRunAt:   nil,
+			CancelledAt: &now,
+			UpdatedAt:   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	schedule.Status = domain.ScheduledTransferCancelled
+	schedule.NextRunAt = nil
+	schedule.CancelledAt = &now
+	schedule.UpdatedAt = now
+	return true, nil
+}
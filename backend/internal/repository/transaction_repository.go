@@ -85,6 +85,20 @@ func (r *TransactionRepository) Update(ctx context.Context, tx *domain.Transacti
 	return r.db.WithContext(ctx).Save(tx).Error
 }
 
+// UpdateIfStatus atualiza a transação apenas se o status persistido ainda for o esperado.
+// Retorna false quando outra atualização (ex: callback do banco) alterou o status antes.
+func (r *TransactionRepository) UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(tx).
+		Where("status = ?", expected).
+		Select("*").
+		Omit("Merchant", "Provider", "created_at").
+		Updates(tx)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // UpdateStatus atualiza apenas o status de uma transação
 func (r *TransactionRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.TransactionStatus) error {
 	updates := map[string]interface{}{
@@ -104,6 +118,25 @@ func (r *TransactionRepository) UpdateStatus(ctx context.Context, id uuid.UUID,
 	return r.db.WithContext(ctx).Model(&domain.Transaction{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// CreateAttempts registra as tentativas de execução de uma transação
+func (r *TransactionRepository) CreateAttempts(ctx context.Context, attempts []domain.TransactionAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&attempts).Error
+}
+
+// ListAttempts lista as tentativas de execução de uma transação
+func (r *TransactionRepository) ListAttempts(ctx context.Context, transactionID uuid.UUID) ([]domain.TransactionAttempt, error) {
+	var attempts []domain.TransactionAttempt
+	err := r.db.WithContext(ctx).
+		Preload("Provider").
+		Where("transaction_id = ?", transactionID).
+		Order("attempt ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
 // ListByMerchant lista transações de um merchant com filtros
 func (r *TransactionRepository) ListByMerchant(ctx context.Context, merchantID uuid.UUID, filters map[string]interface{}, limit, offset int) ([]domain.Transaction, int64, error) {
 	var transactions []domain.Transaction
@@ -120,6 +153,14 @@ func (r *TransactionRepository) ListByMerchant(ctx context.Context, merchantID u
 		query = query.Where("type = ?", txType)
 	}
 
+	if txTypes, ok := filters["types"].([]domain.TransactionType); ok {
+		query = query.Where("type IN ?", txTypes)
+	}
+
+	if scheduledTransferID, ok := filters["scheduled_transfer_id"].(uuid.UUID); ok {
+		query = query.Where("scheduled_transfer_id = ?", scheduledTransferID)
+	}
+
 	if startDate, ok := filters["start_date"].(time.Time); ok {
 		query = query.Where("created_at >= ?", startDate)
 	}
@@ -205,17 +246,23 @@ func (r *TransactionRepository) GetStatistics(ctx context.Context, merchantID uu
 	return stats, nil
 }
 
-// GetPendingTransactions busca transações pendentes ou em processamento
-func (r *TransactionRepository) GetPendingTransactions(ctx context.Context, limit int) ([]domain.Transaction, error) {
+// GetPendingTransactions busca transferências (inclusive pagamentos de BR Code) pendentes ou em
+// processamento cuja consulta de status venceu. Transações ainda não consultadas são elegíveis
+// após ficarem sem atualização até staleBefore.
+func (r *TransactionRepository) GetPendingTransactions(ctx context.Context, staleBefore, now time.Time, limit int) ([]domain.Transaction, error) {
 	var transactions []domain.Transaction
 	err := r.db.WithContext(ctx).
 		Preload("Merchant").
 		Preload("Provider").
-		Where("status IN ?", []domain.TransactionStatus{
+		Where("type IN ? AND status IN ?", []domain.TransactionType{
+			domain.TransactionTypeTransfer,
+			domain.TransactionTypePixCopyPaste,
+		}, []domain.TransactionStatus{
 			domain.TransactionStatusPending,
 			domain.TransactionStatusProcessing,
 		}).
-		Order("created_at ASC").
+		Where("(next_status_check_at IS NULL AND updated_at <= ?) OR next_status_check_at <= ?", staleBefore, now).
+		Order("COALESCE(next_status_check_at, updated_at) ASC").
 		Limit(limit).
 		Find(&transactions).Error
 
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"gorm.io/gorm"
+)
+
+// WebhookRepository gerencia webhooks e suas entregas
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository cria um novo repositório de webhooks
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// ListActiveByMerchant lista os webhooks ativos de um merchant
+func (r *WebhookRepository) ListActiveByMerchant(ctx context.Context, merchantID uuid.UUID) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	err := r.db.WithContext(ctx).
+		Where("merchant_id = ? AND active = true AND deleted_at IS NULL", merchantID).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// CreateDeliveries enfileira entregas de webhook
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Webhook", "Transaction").Create(&deliveries).Error
+}
+
+// GetDueDeliveries busca entregas pendentes cujo horário de envio já passou
+func (r *WebhookRepository) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Preload("Webhook").
+		Where("status = ? AND next_retry_at <= ?", domain.WebhookDeliveryPending, now).
+		Order("next_retry_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDelivery reserva uma entrega até "until" para que apenas um worker a envie.
+// Se o processo cair durante o envio, a entrega volta a ficar disponível após a reserva expirar.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_retry_at <= ?", id, domain.WebhookDeliveryPending, now).
+		Update("next_retry_at", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateDelivery atualiza o resultado de uma entrega
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).
+		Select("attempt", "status", "response_code", "response_body", "error_message", "next_retry_at", "delivered_at").
+		Updates(delivery).Error
+}
+
+// Create cria um novo webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	return r.db.WithContext(ctx).Omit("Merchant").Create(webhook).Error
+}
+
+// GetByMerchantAndID busca um webhook do merchant
+func (r *WebhookRepository) GetByMerchantAndID(ctx context.Context, merchantID, id uuid.UUID) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND merchant_id = ? AND deleted_at IS NULL", id, merchantID).
+		First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListByMerchant lista os webhooks de um merchant
+func (r *WebhookRepository) ListByMerchant(ctx context.Context, merchantID uuid.UUID) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	err := r.db.WithContext(ctx).
+		Where("merchant_id = ? AND deleted_at IS NULL", merchantID).
+		Order("created_at ASC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Update atualiza um webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *domain.Webhook) error {
+	return r.db.WithContext(ctx).Omit("Merchant", "created_at").Save(webhook).Error
+}
+
+// Delete remove um webhook (soft delete)
+func (r *WebhookRepository) Delete(ctx context.Context, webhook *domain.Webhook) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(webhook).
+		Updates(map[string]interface{}{"deleted_at": now, "active": false}).Error
+}
+
+// ListDeliveries lista as entregas de um webhook, das mais recentes para as mais antigas
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit, offset int) ([]domain.WebhookDelivery, int64, error) {
+	var deliveries []domain.WebhookDelivery
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// GetDelivery busca uma entrega de um webhook
+func (r *WebhookRepository) GetDelivery(ctx context.Context, webhookID, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND webhook_id = ?", id, webhookID).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ResetDelivery volta uma entrega falha para a fila, com o contador de tentativas reiniciado
+func (r *WebhookRepository) ResetDelivery(ctx context.Context, id uuid.UUID, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.WebhookDelivery{}).
+		Where("id = ? AND status = ?", id, domain.WebhookDeliveryFailed).
+		Updates(map[string]interface{}{
+			"status":        domain.WebhookDeliveryPending,
+			"attempt":       1,
+			"next_retry_at": now,
+			"error_message": "",
+		}).Error
+}
@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"regexp"
+
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/pixkey"
+)
+
+var ispbPattern = regexp.MustCompile(`^[0-9]{8}$`)
+
+// maxEmailLength é o tamanho máximo de um endereço de email (RFC 5321)
+const maxEmailLength = 254
+
+// ValidEmail verifica o formato de um endereço de email (mesma regra das chaves PIX)
+func ValidEmail(value string) bool {
+	return len(value) <= maxEmailLength && pixkey.ValidEmail(value)
+}
+
+// ValidISPB verifica o formato do código ISPB da instituição
+func ValidISPB(value string) bool {
+	return ispbPattern.MatchString(value)
+}
+
+// ValidatePixKey verifica se a chave pode ser normalizada para o tipo informado (ou para
+// algum tipo, quando vazio). A normalização em si fica a cargo do handler.
+func ValidatePixKey(keyType, key string) error {
+	_, err := pixkey.Normalize(domain.PixKeyType(keyType), key)
+	return err
+}
@@ -0,0 +1,49 @@
+package validation
+
+import "testing"
+
+func TestValidatePixKey(t *testing.T) {
+	tests := []struct {
+		keyType string
+		key     string
+		valid   bool
+	}{
+		{"cpf", "529.982.247-25", true},
+		{"cpf", "52998224724", false},
+		{"cnpj", "52998224725", false},
+		{"email", "Cliente@Example.com", true},
+		{"email", "cliente@", false},
+		{"phone", "(11) 99999-8888", true},
+		{"phone", "1133334444", false},
+		{"random", "123e4567-e12b-12d1-a456-426655440000", true},
+		{"random", "123e4567e12b12d1a456426655440000", false},
+		{"", "cliente@example.com", true},
+		{"", "abc", false},
+		{"iban", "BR00", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePixKey(tt.keyType, tt.key)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidatePixKey(%q, %q) error = %v, want valid %v", tt.keyType, tt.key, err, tt.valid)
+		}
+	}
+}
+
+func TestValidEmail(t *testing.T) {
+	if !ValidEmail("Admin@PixSaaS.com.br") {
+		t.Error("ValidEmail() rejected a valid email")
+	}
+	if ValidEmail("admin@") || ValidEmail("admin") || ValidEmail("admin@localhost") {
+		t.Error("ValidEmail() accepted an invalid email")
+	}
+}
+
+func TestValidISPB(t *testing.T) {
+	if !ValidISPB("00000000") || !ValidISPB("60746948") {
+		t.Error("ValidISPB() rejected a valid ISPB")
+	}
+	if ValidISPB("6074694") || ValidISPB("6074694A") {
+		t.Error("ValidISPB() accepted an invalid ISPB")
+	}
+}
@@ -0,0 +1,243 @@
+// Package validation aplica as regras declaradas nas tags validate dos DTOs da API.
+//
+// Regras suportadas (separadas por vírgula):
+//
+//	required                 campo obrigatório
+//	required_without=F       obrigatório quando o campo F estiver vazio
+//	required_without_all=F G obrigatório quando todos os campos listados estiverem vazios
+//	min=N, max=N             valor de números ou quantidade de caracteres de textos
+//	oneof=a b c              um dos valores listados
+//	email                    endereço de email
+//	document, cpf, cnpj      CPF e/ou CNPJ com dígitos verificadores
+//	ispb                     código ISPB (8 dígitos)
+//	pixkey=F                 chave PIX no formato do tipo informado no campo F
+//
+// Campos vazios e não obrigatórios não passam pelas demais regras.
+// Structs aninhadas (ou ponteiros para struct) são validadas recursivamente.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pixsaas/backend/internal/pixkey"
+)
+
+// FieldError descreve a regra violada por um campo da requisição
+type FieldError struct {
+	Field   string `json:"field"` // Nome do campo no JSON (ex: payee_account.ispb)
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors reúne as falhas de validação de uma requisição
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Field+": "+fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Struct valida a struct (ou ponteiro para struct) conforme as tags validate.
+// Retorna Errors com todos os campos inválidos ou nil.
+func Struct(v interface{}) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: Struct recebeu %s", value.Kind()))
+	}
+
+	var errs Errors
+	validateStruct(value, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(value reflect.Value, prefix string, errs *Errors) {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + jsonName(field)
+		fieldValue := value.Field(i)
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if fieldErr := validateField(value, fieldValue, name, tag); fieldErr != nil {
+				*errs = append(*errs, *fieldErr)
+				continue
+			}
+		}
+
+		nested := fieldValue
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type().PkgPath() != "time" {
+			validateStruct(nested, name+".", errs)
+		}
+	}
+}
+
+// validateField aplica as regras da tag e retorna a primeira violada
+func validateField(parent, value reflect.Value, name, tag string) *FieldError {
+	rules := strings.Split(tag, ",")
+
+	if isEmpty(value) {
+		for _, rule := range rules {
+			key, param := splitRule(rule)
+			if requiredRule(parent, key, param) {
+				return &FieldError{Field: name, Rule: key, Message: "is required"}
+			}
+		}
+		return nil
+	}
+
+	for _, rule := range rules {
+		key, param := splitRule(rule)
+		if message := checkRule(parent, value, key, param); message != "" {
+			return &FieldError{Field: name, Rule: key, Message: message}
+		}
+	}
+	return nil
+}
+
+// requiredRule indica se a regra torna o campo vazio inválido
+func requiredRule(parent reflect.Value, key, param string) bool {
+	switch key {
+	case "required":
+		return true
+	case "required_without":
+		return isEmpty(parent.FieldByName(param))
+	case "required_without_all":
+		for _, other := range strings.Fields(param) {
+			if !isEmpty(parent.FieldByName(other)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// checkRule retorna a mensagem de erro da regra ou vazio se o valor for válido
+func checkRule(parent, value reflect.Value, key, param string) string {
+	switch key {
+	case "required", "required_without", "required_without_all", "":
+		return ""
+	case "min", "max":
+		return checkLimit(value, key, param)
+	case "oneof":
+		options := strings.Fields(param)
+		for _, option := range options {
+			if stringValue(value) == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "email":
+		if !ValidEmail(stringValue(value)) {
+			return "must be a valid email"
+		}
+	case "document":
+		if !pixkey.ValidDocument(stringValue(value)) {
+			return "must be a valid CPF or CNPJ"
+		}
+	case "cpf":
+		if !pixkey.ValidCPF(stringValue(value)) {
+			return "must be a valid CPF"
+		}
+	case "cnpj":
+		if !pixkey.ValidCNPJ(stringValue(value)) {
+			return "must be a valid CNPJ"
+		}
+	case "ispb":
+		if !ValidISPB(stringValue(value)) {
+			return "must be an ISPB code with 8 digits"
+		}
+	case "pixkey":
+		keyType := stringValue(parent.FieldByName(param))
+		if err := ValidatePixKey(keyType, stringValue(value)); err != nil {
+			if keyType == "" {
+				return "must be a valid pix key"
+			}
+			return "must be a valid " + keyType + " pix key"
+		}
+	default:
+		panic(fmt.Sprintf("validation: regra desconhecida %q", key))
+	}
+	return ""
+}
+
+func checkLimit(value reflect.Value, key, param string) string {
+	limit, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: limite inválido em %s=%s", key, param))
+	}
+
+	var size int64
+	unit := ""
+	switch value.Kind() {
+	case reflect.String:
+		size = int64(utf8.RuneCountInString(value.String()))
+		unit = " characters"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = value.Int()
+	default:
+		return ""
+	}
+
+	switch {
+	case key == "min" && size < limit:
+		return fmt.Sprintf("must be at least %d%s", limit, unit)
+	case key == "max" && size > limit:
+		return fmt.Sprintf("must be at most %d%s", limit, unit)
+	}
+	return ""
+}
+
+func splitRule(rule string) (string, string) {
+	key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+	return key, param
+}
+
+func isEmpty(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	return value.IsZero()
+}
+
+func stringValue(value reflect.Value) string {
+	if !value.IsValid() {
+		return ""
+	}
+	if value.Kind() == reflect.String {
+		return value.String()
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// jsonName retorna o nome do campo no JSON
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testAccount struct {
+	ISPB string `json:"ispb" validate:"required,ispb"`
+}
+
+type testRequest struct {
+	ExternalID  string       `json:"external_id" validate:"required,max=5"`
+	Amount      int64        `json:"amount" validate:"required_without=Code,min=1"`
+	Description string       `json:"description" validate:"max=3"`
+	Code        string       `json:"code,omitempty"`
+	Document    string       `json:"document" validate:"document"`
+	PixKey      string       `json:"pix_key" validate:"required_without_all=Code Account,pixkey=PixKeyType"`
+	PixKeyType  string       `json:"pix_key_type" validate:"oneof=cpf email"`
+	Account     *testAccount `json:"account,omitempty"`
+}
+
+func TestStructValid(t *testing.T) {
+	req := testRequest{ExternalID: "p1", Amount: 100, Document: "52998224725", PixKey: "52998224725", PixKeyType: "cpf"}
+	if err := Struct(&req); err != nil {
+		t.Errorf("Struct() error = %v", err)
+	}
+
+	// Com o código, valor e chave deixam de ser obrigatórios
+	if err := Struct(testRequest{ExternalID: "p1", Code: "000201"}); err != nil {
+		t.Errorf("Struct() with code error = %v", err)
+	}
+}
+
+func TestStructReportsEachField(t *testing.T) {
+	req := testRequest{
+		ExternalID:  "pedido-123",
+		Amount:      -1,
+		Description: "ações",
+		Document:    "12345678900",
+		PixKey:      "cliente@example.com",
+		PixKeyType:  "cpf",
+		Account:     &testAccount{ISPB: "123"},
+	}
+
+	err := Struct(&req)
+	var fieldErrs Errors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("Struct() error = %v, want Errors", err)
+	}
+
+	got := map[string]string{}
+	for _, fieldErr := range fieldErrs {
+		got[fieldErr.Field] = fieldErr.Rule
+	}
+	want := map[string]string{
+		"external_id":  "max",
+		"amount":       "min",
+		"description":  "max",
+		"document":     "document",
+		"pix_key":      "pixkey",
+		"account.ispb": "ispb",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestStructRequired(t *testing.T) {
+	err := Struct(&testRequest{})
+	var fieldErrs Errors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("Struct() error = %v, want Errors", err)
+	}
+
+	required := map[string]bool{}
+	for _, fieldErr := range fieldErrs {
+		if fieldErr.Rule != "required" && fieldErr.Rule != "required_without" && fieldErr.Rule != "required_without_all" {
+			t.Errorf("%s: rule = %s, want a required rule", fieldErr.Field, fieldErr.Rule)
+		}
+		required[fieldErr.Field] = true
+	}
+	for _, field := range []string{"external_id", "amount", "pix_key"} {
+		if !required[field] {
+			t.Errorf("%s not reported as required: %v", field, fieldErrs)
+		}
+	}
+	if len(fieldErrs) != 3 {
+		t.Errorf("errors = %v, want only the required fields", fieldErrs)
+	}
+}
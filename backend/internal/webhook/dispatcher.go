@@ -0,0 +1,449 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/netguard"
+)
+
+const (
+	// defaultTimeout é usado quando o webhook não define timeout
+	defaultTimeout = 30 * time.Second
+	// claimMargin é somado ao timeout do webhook na reserva de uma entrega
+	claimMargin = 30 * time.Second
+	// maxResponseBody limita o corpo de resposta armazenado por entrega
+	maxResponseBody = 1024
+)
+
+// Store persiste webhooks e entregas
+type Store interface {
+	ListActiveByMerchant(ctx context.Context, merchantID uuid.UUID) ([]domain.Webhook, error)
+	CreateDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	ClaimDelivery(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error)
+	UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	ResetDelivery(ctx context.Context, id uuid.UUID, now time.Time) error
+}
+
+// SecretDecrypter descriptografa o segredo de assinatura dos webhooks
+type SecretDecrypter interface {
+	Decrypt(ciphertext string) (string, error)
+}
+
+// Auditor registra as tentativas de entrega
+type Auditor interface {
+	LogWebhookDelivery(ctx context.Context, merchantID, webhookID, transactionID uuid.UUID, event string, attempt int, success bool, statusCode int, errorMsg string) error
+}
+
+// Config define a periodicidade e os limites do dispatcher
+type Config struct {
+	PollInterval   time.Duration // Intervalo entre buscas de entregas pendentes
+	BatchSize      int           // Entregas buscadas por ciclo
+	Workers        int           // Entregas enviadas em paralelo
+	RetryBaseDelay time.Duration // Intervalo antes da primeira nova tentativa
+	RetryMaxDelay  time.Duration // Intervalo máximo entre tentativas
+}
+
+// Dispatcher enfileira e entrega webhooks de mudança de status de transações.
+// As entregas ficam no banco, então entregas pendentes são retomadas após reinício.
+type Dispatcher struct {
+	store     Store
+	decrypter SecretDecrypter
+	auditor   Auditor
+	client    *http.Client
+	config    Config
+	wake      chan struct{}
+	now       func() time.Time
+}
+
+// NewDispatcher cria o dispatcher de webhooks
+func NewDispatcher(store Store, decrypter SecretDecrypter, auditor Auditor, config Config) *Dispatcher {
+	return &Dispatcher{
+		store:     store,
+		decrypter: decrypter,
+		auditor:   auditor,
+		client: &http.Client{
+			// Apenas endereços públicos: a URL é definida pelo merchant e a resposta do teste é exibida
+			Transport: netguard.NewTransport(),
+			// Redirecionamentos não são seguidos: o payload assinado vai apenas para a URL cadastrada
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// TestEvent é o evento enviado pelo endpoint de teste de webhook
+const TestEvent = "webhook.test"
+
+// SupportedEvents lista os eventos aceitos na inscrição de um webhook
+var SupportedEvents = []string{
+	"*",
+	"transaction.*",
+	EventName(domain.TransactionStatusPending),
+	EventName(domain.TransactionStatusProcessing),
+	EventName(domain.TransactionStatusCompleted),
+	EventName(domain.TransactionStatusFailed),
+	EventName(domain.TransactionStatusCancelled),
+	EventName(domain.TransactionStatusRefunded),
+	EventName(domain.TransactionStatusManualReview),
+}
+
+// IsSupportedEvent indica se o evento pode ser usado na inscrição de um webhook
+func IsSupportedEvent(event string) bool {
+	for _, supported := range SupportedEvents {
+		if event == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// TestResult representa o resultado do envio de um evento de teste
+type TestResult struct {
+	Success      bool   `json:"success"`
+	ResponseCode int    `json:"response_code,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	Duration     int64  `json:"duration"` // Milissegundos
+}
+
+// EventName retorna o evento correspondente ao status da transação (ex: transaction.completed)
+func EventName(status domain.TransactionStatus) string {
+	return "transaction." + string(status)
+}
+
+// Enqueue registra uma entrega para cada webhook do merchant inscrito no evento do status atual da transação
+func (d *Dispatcher) Enqueue(ctx context.Context, tx *domain.Transaction) error {
+	webhooks, err := d.store.ListActiveByMerchant(ctx, tx.MerchantID)
+	if err != nil {
+		return err
+	}
+
+	event := EventName(tx.Status)
+	now := d.now()
+
+	var deliveries []domain.WebhookDelivery
+	for i := range webhooks {
+		if !subscribes(&webhooks[i], event) {
+			continue
+		}
+
+		id := uuid.New()
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			ID:            id,
+			WebhookID:     webhooks[i].ID,
+			TransactionID: tx.ID,
+			Event:         event,
+			Payload:       buildPayload(id, event, tx, now),
+			Attempt:       1,
+			Status:        domain.WebhookDeliveryPending,
+			NextRetryAt:   &now,
+			CreatedAt:     now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := d.store.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+
+	d.notify()
+	return nil
+}
+
+// Redeliver reagenda uma entrega para envio imediato, com o limite de tentativas renovado
+func (d *Dispatcher) Redeliver(ctx context.Context, id uuid.UUID) error {
+	if err := d.store.ResetDelivery(ctx, id, d.now()); err != nil {
+		return err
+	}
+	d.notify()
+	return nil
+}
+
+// SendTest envia um evento de teste para o webhook de forma síncrona, sem registrar entrega
+func (d *Dispatcher) SendTest(ctx context.Context, webhook *domain.Webhook) *TestResult {
+	id := uuid.New()
+	now := d.now()
+	payload := map[string]interface{}{
+		"id":         id.String(),
+		"event":      TestEvent,
+		"created_at": now.Format(time.RFC3339),
+		"data": map[string]interface{}{
+			"webhook_id": webhook.ID.String(),
+			"message":    "Evento de teste",
+		},
+	}
+
+	statusCode, responseBody, err := d.post(ctx, webhook, id, TestEvent, payload)
+
+	result := &TestResult{
+		Success:      err == nil,
+		ResponseCode: statusCode,
+		ResponseBody: responseBody,
+		Duration:     d.now().Sub(now).Milliseconds(),
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+	}
+	return result
+}
+
+// Run envia as entregas pendentes até o contexto ser cancelado
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.DispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DispatchDue envia um lote de entregas pendentes cujo horário já passou
+func (d *Dispatcher) DispatchDue(ctx context.Context) {
+	deliveries, err := d.store.GetDueDeliveries(ctx, d.now(), d.config.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Aviso: falha ao buscar entregas de webhook pendentes: %v", err)
+		}
+		return
+	}
+
+	workers := d.config.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(delivery *domain.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			d.deliver(ctx, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+// deliver envia uma entrega e registra o resultado
+func (d *Dispatcher) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	webhook := &delivery.Webhook
+
+	now := d.now()
+	claimed, err := d.store.ClaimDelivery(ctx, delivery.ID, now, now.Add(webhookTimeout(webhook)+claimMargin))
+	if err != nil || !claimed {
+		return
+	}
+
+	var statusCode int
+	var responseBody string
+	var sendErr error
+
+	if !webhook.Active || webhook.DeletedAt != nil {
+		// Webhook removido ou desativado depois do enfileiramento: não há para onde entregar
+		sendErr = errors.New("webhook inativo")
+		delivery.Attempt = webhook.MaxRetries + 1
+	} else {
+		statusCode, responseBody, sendErr = d.post(ctx, webhook, delivery.ID, delivery.Event, delivery.Payload)
+		if ctx.Err() != nil {
+			// Desligamento: a entrega volta a ficar disponível quando a reserva expirar
+			return
+		}
+	}
+
+	d.record(ctx, delivery, statusCode, responseBody, sendErr)
+}
+
+// post assina e envia o payload para a URL do webhook
+func (d *Dispatcher) post(ctx context.Context, webhook *domain.Webhook, id uuid.UUID, event string, payload map[string]interface{}) (int, string, error) {
+	secrets, err := d.signingSecrets(webhook)
+	if err != nil {
+		return 0, "", err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, "", err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, webhookTimeout(webhook))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(sendCtx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PIX-SaaS-Webhook/1.0")
+	req.Header.Set("X-Webhook-ID", id.String())
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set(SignatureHeader, BuildSignatureHeader(timestamp, body, secrets...))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = resp.Body.Close() }() //nolint:errcheck
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// signingSecrets retorna os segredos de assinatura; durante uma rotação o segredo anterior também assina
+func (d *Dispatcher) signingSecrets(webhook *domain.Webhook) ([]string, error) {
+	secret, err := d.decrypter.Decrypt(webhook.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao descriptografar segredo do webhook: %w", err)
+	}
+	secrets := []string{secret}
+
+	if webhook.PreviousSecret != "" && webhook.PreviousSecretExpiresAt != nil && d.now().Before(*webhook.PreviousSecretExpiresAt) {
+		previous, err := d.decrypter.Decrypt(webhook.PreviousSecret)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao descriptografar segredo anterior do webhook: %w", err)
+		}
+		secrets = append(secrets, previous)
+	}
+	return secrets, nil
+}
+
+// record atualiza a entrega com o resultado da tentativa e agenda a próxima, se houver
+func (d *Dispatcher) record(ctx context.Context, delivery *domain.WebhookDelivery, statusCode int, responseBody string, sendErr error) {
+	now := d.now()
+	attempt := delivery.Attempt
+
+	delivery.ResponseCode = statusCode
+	delivery.ResponseBody = responseBody
+	delivery.ErrorMessage = ""
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.WebhookDeliverySuccess
+		delivery.DeliveredAt = &now
+		delivery.NextRetryAt = nil
+	case attempt > delivery.Webhook.MaxRetries:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.ErrorMessage = sendErr.Error()
+		delivery.NextRetryAt = nil
+	default:
+		next := now.Add(d.backoff(attempt))
+		delivery.Attempt++
+		delivery.ErrorMessage = sendErr.Error()
+		delivery.NextRetryAt = &next
+	}
+
+	if err := d.store.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("Aviso: falha ao atualizar entrega de webhook %s: %v", delivery.ID, err)
+	}
+
+	_ = d.auditor.LogWebhookDelivery(ctx, delivery.Webhook.MerchantID, delivery.WebhookID, delivery.TransactionID, delivery.Event, attempt, sendErr == nil, statusCode, delivery.ErrorMessage)
+}
+
+// backoff calcula o intervalo até a próxima tentativa (exponencial, limitado a RetryMaxDelay)
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.RetryBaseDelay
+	for i := 1; i < attempt && delay < d.config.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.config.RetryMaxDelay {
+		delay = d.config.RetryMaxDelay
+	}
+	return delay
+}
+
+// webhookTimeout retorna o timeout de envio do webhook
+func webhookTimeout(webhook *domain.Webhook) time.Duration {
+	if webhook.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(webhook.Timeout) * time.Second
+}
+
+// notify acorda o loop de envio sem bloquear
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// subscribes indica se o webhook está inscrito no evento ("*" e "transaction.*" cobrem todos)
+func subscribes(webhook *domain.Webhook, event string) bool {
+	for _, subscribed := range webhook.Events {
+		if subscribed == event || subscribed == "*" || subscribed == "transaction.*" {
+			return true
+		}
+	}
+	return false
+}
+
+// buildPayload monta o corpo enviado ao merchant
+func buildPayload(id uuid.UUID, event string, tx *domain.Transaction, now time.Time) map[string]interface{} {
+	data := map[string]interface{}{
+		"id":          tx.ID.String(),
+		"external_id": tx.ExternalID,
+		"e2e_id":      tx.E2EID,
+		"type":        tx.Type,
+		"status":      tx.Status,
+		"amount":      tx.Amount,
+		"description": tx.Description,
+		"provider":    tx.Provider.Code,
+		"created_at":  tx.CreatedAt.Format(time.RFC3339),
+		"updated_at":  tx.UpdatedAt.Format(time.RFC3339),
+	}
+	if tx.ErrorCode != "" {
+		data["error_code"] = tx.ErrorCode
+		data["error_message"] = tx.ErrorMessage
+	}
+	if tx.CompletedAt != nil {
+		data["completed_at"] = tx.CompletedAt.Format(time.RFC3339)
+	}
+	if tx.ScheduledTransferID != nil {
+		data["scheduled_transfer_id"] = tx.ScheduledTransferID.String()
+	}
+
+	return map[string]interface{}{
+		"id":         id.String(),
+		"event":      event,
+		"created_at": now.Format(time.RFC3339),
+		"data":       data,
+	}
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/netguard"
+)
+
+func TestDispatcherRefusesNonPublicDestination(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, _ = w.Write([]byte("internal data"))
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(newFakeStore(), plainDecrypter{}, &fakeAuditor{}, testConfig())
+	result := dispatcher.SendTest(context.Background(), &domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec", Active: true})
+
+	if called || result.Success || result.ResponseBody != "" {
+		t.Fatalf("result = %+v, want connection to loopback refused", result)
+	}
+
+	_, err := dispatcher.client.Get(server.URL)
+	if !errors.Is(err, netguard.ErrNonPublicAddress) {
+		t.Errorf("Get() error = %v, want ErrNonPublicAddress", err)
+	}
+}
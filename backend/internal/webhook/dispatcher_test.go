@@ -0,0 +1,436 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+)
+
+func TestDispatcherEnqueueMatchesSubscribedWebhooks(t *testing.T) {
+	merchantID := uuid.New()
+	store := newFakeStore(
+		domain.Webhook{ID: uuid.New(), MerchantID: merchantID, Events: []string{"transaction.completed"}, Active: true},
+		domain.Webhook{ID: uuid.New(), MerchantID: merchantID, Events: []string{"transaction.failed"}, Active: true},
+		domain.Webhook{ID: uuid.New(), MerchantID: merchantID, Events: []string{"*"}, Active: true},
+	)
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+
+	tx := &domain.Transaction{ID: uuid.New(), MerchantID: merchantID, Status: domain.TransactionStatusCompleted, Amount: 1500}
+	if err := dispatcher.Enqueue(context.Background(), tx); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if len(store.deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(store.deliveries))
+	}
+	for _, delivery := range store.deliveries {
+		if delivery.Event != "transaction.completed" || delivery.Status != domain.WebhookDeliveryPending || delivery.NextRetryAt == nil {
+			t.Errorf("delivery = %+v", delivery)
+		}
+		data := delivery.Payload["data"].(map[string]interface{})
+		if data["id"] != tx.ID.String() || data["amount"] != int64(1500) {
+			t.Errorf("payload data = %v", data)
+		}
+	}
+}
+
+func TestBuildPayloadIncludesScheduledTransfer(t *testing.T) {
+	scheduleID := uuid.New()
+	tx := &domain.Transaction{ID: uuid.New(), Status: domain.TransactionStatusFailed, ErrorCode: "INSUFFICIENT_BALANCE", ScheduledTransferID: &scheduleID}
+
+	data := buildPayload(uuid.New(), "transaction.failed", tx, time.Now())["data"].(map[string]interface{})
+	if data["scheduled_transfer_id"] != scheduleID.String() || data["error_code"] != "INSUFFICIENT_BALANCE" {
+		t.Errorf("payload data = %v", data)
+	}
+
+	tx.ScheduledTransferID = nil
+	if _, ok := buildPayload(uuid.New(), "transaction.failed", tx, time.Now())["data"].(map[string]interface{})["scheduled_transfer_id"]; ok {
+		t.Error("scheduled_transfer_id sent for immediate transfer")
+	}
+}
+
+func TestDispatcherDeliversSignedPayload(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := newFakeStore()
+	auditor := &fakeAuditor{}
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, auditor, testConfig())
+	delivery := store.addDelivery(domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec", Active: true, MaxRetries: 3})
+
+	dispatcher.DispatchDue(context.Background())
+
+	got := store.delivery(delivery.ID)
+	if got.Status != domain.WebhookDeliverySuccess || got.ResponseCode != http.StatusNoContent || got.DeliveredAt == nil {
+		t.Fatalf("delivery = %+v, want success", got)
+	}
+
+	if err := VerifySignature(gotHeader.Get(SignatureHeader), gotBody, "whsec", time.Minute, time.Now()); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+	if gotHeader.Get("X-Webhook-Event") != "transaction.completed" || gotHeader.Get("X-Webhook-ID") != delivery.ID.String() {
+		t.Errorf("headers = %v", gotHeader)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(gotBody, &payload); err != nil || payload["event"] != "transaction.completed" {
+		t.Errorf("payload = %s (%v)", gotBody, err)
+	}
+
+	if len(auditor.attempts) != 1 || !auditor.attempts[0] {
+		t.Errorf("audited attempts = %v, want [true]", auditor.attempts)
+	}
+}
+
+func TestDispatcherRetriesWithBackoffUntilMaxRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	store := newFakeStore()
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+	now := time.Now()
+	dispatcher.now = func() time.Time { return now }
+	delivery := store.addDelivery(domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec", Active: true, MaxRetries: 2})
+
+	// Primeira falha: agenda nova tentativa com o atraso base
+	dispatcher.DispatchDue(context.Background())
+	got := store.delivery(delivery.ID)
+	if got.Status != domain.WebhookDeliveryPending || got.Attempt != 2 || got.ResponseCode != 500 || got.ResponseBody != "boom" {
+		t.Fatalf("delivery after first attempt = %+v", got)
+	}
+	if !got.NextRetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("NextRetryAt = %v, want %v", got.NextRetryAt, now.Add(time.Minute))
+	}
+
+	// Ainda não venceu: nada é enviado
+	dispatcher.DispatchDue(context.Background())
+	if store.delivery(delivery.ID).Attempt != 2 {
+		t.Fatal("delivery sent before NextRetryAt")
+	}
+
+	now = now.Add(time.Minute)
+	dispatcher.DispatchDue(context.Background())
+	got = store.delivery(delivery.ID)
+	if got.Attempt != 3 || !got.NextRetryAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("delivery after second attempt = %+v", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	dispatcher.DispatchDue(context.Background())
+	got = store.delivery(delivery.ID)
+	if got.Status != domain.WebhookDeliveryFailed || got.NextRetryAt != nil || got.ErrorMessage == "" {
+		t.Errorf("delivery after last attempt = %+v, want failed", got)
+	}
+}
+
+func TestDispatcherResumesPendingDeliveries(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	// Entregas enfileiradas antes do reinício ficam apenas no banco
+	store := newFakeStore()
+	webhook := domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec", Active: true, MaxRetries: 3}
+	first := store.addDelivery(webhook)
+	second := store.addDelivery(webhook)
+
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for store.delivery(first.ID).Status != domain.WebhookDeliverySuccess || store.delivery(second.ID).Status != domain.WebhookDeliverySuccess {
+		select {
+		case <-deadline:
+			t.Fatal("pending deliveries were not resumed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestDispatcherInactiveWebhookFails(t *testing.T) {
+	store := newFakeStore()
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+	delivery := store.addDelivery(domain.Webhook{ID: uuid.New(), URL: "http://example.invalid", Active: false, MaxRetries: 3})
+
+	dispatcher.DispatchDue(context.Background())
+
+	if got := store.delivery(delivery.ID); got.Status != domain.WebhookDeliveryFailed {
+		t.Errorf("Status = %s, want failed", got.Status)
+	}
+}
+
+func TestDispatcherSignsWithPreviousSecretDuringRotation(t *testing.T) {
+	var gotBody []byte
+	var gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(SignatureHeader)
+	}))
+	defer server.Close()
+
+	store := newFakeStore()
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+	expiresAt := time.Now().Add(time.Hour)
+	webhook := domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "new", PreviousSecret: "old", PreviousSecretExpiresAt: &expiresAt, Active: true, MaxRetries: 3}
+	store.addDelivery(webhook)
+
+	dispatcher.DispatchDue(context.Background())
+
+	for _, secret := range []string{"new", "old"} {
+		if err := VerifySignature(gotSignature, gotBody, secret, time.Minute, time.Now()); err != nil {
+			t.Errorf("VerifySignature(%s) error = %v", secret, err)
+		}
+	}
+
+	// Após a janela de transição apenas o segredo novo assina
+	expired := time.Now().Add(-time.Minute)
+	webhook.PreviousSecretExpiresAt = &expired
+	store.addDelivery(webhook)
+
+	dispatcher.DispatchDue(context.Background())
+
+	if err := VerifySignature(gotSignature, gotBody, "old", time.Minute, time.Now()); err == nil {
+		t.Error("expired previous secret still signs")
+	}
+	if err := VerifySignature(gotSignature, gotBody, "new", time.Minute, time.Now()); err != nil {
+		t.Errorf("VerifySignature(new) error = %v", err)
+	}
+}
+
+func TestDispatcherSendTest(t *testing.T) {
+	var gotEvent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEvent = r.Header.Get("X-Webhook-Event")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid signature"))
+	}))
+	defer server.Close()
+
+	store := newFakeStore()
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+
+	result := dispatcher.SendTest(context.Background(), &domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec", Active: true})
+
+	if result.Success || result.ResponseCode != http.StatusBadRequest || result.ResponseBody != "invalid signature" || result.ErrorMessage == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if gotEvent != TestEvent {
+		t.Errorf("event = %q, want %q", gotEvent, TestEvent)
+	}
+	if len(store.deliveries) != 0 {
+		t.Errorf("deliveries = %d, want none", len(store.deliveries))
+	}
+}
+
+func TestDispatcherRedeliverFailedDelivery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	store := newFakeStore()
+	dispatcher := newTestDispatcher(store, plainDecrypter{}, &fakeAuditor{}, testConfig())
+	delivery := store.addDelivery(domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "whsec", Active: true, MaxRetries: 3})
+	delivery.Status = domain.WebhookDeliveryFailed
+	delivery.Attempt = 4
+	delivery.NextRetryAt = nil
+
+	if err := dispatcher.Redeliver(context.Background(), delivery.ID); err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	dispatcher.DispatchDue(context.Background())
+
+	if got := store.delivery(delivery.ID); got.Status != domain.WebhookDeliverySuccess || got.Attempt != 1 {
+		t.Errorf("delivery = %+v, want success on first attempt", got)
+	}
+}
+
+func TestIsSupportedEvent(t *testing.T) {
+	for _, event := range []string{"*", "transaction.*", "transaction.completed", "transaction.manual_review"} {
+		if !IsSupportedEvent(event) {
+			t.Errorf("IsSupportedEvent(%q) = false", event)
+		}
+	}
+	for _, event := range []string{"", "transaction", "transaction.unknown", TestEvent} {
+		if IsSupportedEvent(event) {
+			t.Errorf("IsSupportedEvent(%q) = true", event)
+		}
+	}
+}
+
+// newTestDispatcher cria o dispatcher sem a proteção de endereços, pois os testes usam servidores locais
+func newTestDispatcher(store Store, decrypter SecretDecrypter, auditor Auditor, config Config) *Dispatcher {
+	dispatcher := NewDispatcher(store, decrypter, auditor, config)
+	dispatcher.client.Transport = http.DefaultTransport
+	return dispatcher
+}
+
+func testConfig() Config {
+	return Config{
+		PollInterval:   time.Hour,
+		BatchSize:      10,
+		Workers:        2,
+		RetryBaseDelay: time.Minute,
+		RetryMaxDelay:  time.Hour,
+	}
+}
+
+type fakeStore struct {
+	mu         sync.Mutex
+	webhooks   []domain.Webhook
+	deliveries []*domain.WebhookDelivery
+}
+
+func newFakeStore(webhooks ...domain.Webhook) *fakeStore {
+	return &fakeStore{webhooks: webhooks}
+}
+
+func (s *fakeStore) addDelivery(webhook domain.Webhook) *domain.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Add(-time.Second)
+	delivery := &domain.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     webhook.ID,
+		TransactionID: uuid.New(),
+		Event:         "transaction.completed",
+		Payload:       map[string]interface{}{"event": "transaction.completed"},
+		Attempt:       1,
+		Status:        domain.WebhookDeliveryPending,
+		NextRetryAt:   &now,
+		Webhook:       webhook,
+	}
+	s.deliveries = append(s.deliveries, delivery)
+	return delivery
+}
+
+func (s *fakeStore) delivery(id uuid.UUID) domain.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.deliveries {
+		if delivery.ID == id {
+			return *delivery
+		}
+	}
+	return domain.WebhookDelivery{}
+}
+
+func (s *fakeStore) ListActiveByMerchant(ctx context.Context, merchantID uuid.UUID) ([]domain.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *fakeStore) CreateDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range deliveries {
+		delivery := deliveries[i]
+		s.deliveries = append(s.deliveries, &delivery)
+	}
+	return nil
+}
+
+func (s *fakeStore) GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []domain.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextRetryAt.After(now) {
+			due = append(due, *delivery)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) ClaimDelivery(ctx context.Context, id uuid.UUID, now, until time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.deliveries {
+		if delivery.ID == id && delivery.Status == domain.WebhookDeliveryPending && !delivery.NextRetryAt.After(now) {
+			delivery.NextRetryAt = &until
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeStore) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, stored := range s.deliveries {
+		if stored.ID == delivery.ID {
+			updated := *delivery
+			s.deliveries[i] = &updated
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) ResetDelivery(ctx context.Context, id uuid.UUID, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range s.deliveries {
+		if delivery.ID == id && delivery.Status == domain.WebhookDeliveryFailed {
+			delivery.Status = domain.WebhookDeliveryPending
+			delivery.Attempt = 1
+			delivery.NextRetryAt = &now
+		}
+	}
+	return nil
+}
+
+type fakeAuditor struct {
+	mu       sync.Mutex
+	attempts []bool
+}
+
+func (f *fakeAuditor) LogWebhookDelivery(ctx context.Context, merchantID, webhookID, transactionID uuid.UUID, event string, attempt int, success bool, statusCode int, errorMsg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, success)
+	return nil
+}
+
+type plainDecrypter struct{}
+
+func (plainDecrypter) Decrypt(ciphertext string) (string, error) {
+	return ciphertext, nil
+}
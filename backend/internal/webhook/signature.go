@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader é o header com a assinatura HMAC-SHA256 do payload
+const SignatureHeader = "X-Webhook-Signature"
+
+// Erros de verificação de assinatura
+var (
+	ErrSignatureMalformed = errors.New("malformed webhook signature header")
+	ErrSignatureExpired   = errors.New("webhook signature timestamp outside tolerance")
+	ErrSignatureMismatch  = errors.New("webhook signature mismatch")
+)
+
+// Sign calcula a assinatura HMAC-SHA256 (hex) de "<timestamp>.<body>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// BuildSignatureHeader monta o valor do header no formato "t=<timestamp>,v1=<assinatura>".
+// Com mais de um segredo, uma assinatura v1 é incluída para cada um.
+func BuildSignatureHeader(timestamp int64, body []byte, secrets ...string) string {
+	parts := []string{fmt.Sprintf("t=%d", timestamp)}
+	for _, secret := range secrets {
+		parts = append(parts, "v1="+Sign(secret, timestamp, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// VerifySignature valida o header de assinatura recebido por um merchant.
+// O timestamp deve estar dentro da tolerância para evitar replay.
+func VerifySignature(header string, body []byte, secret string, tolerance time.Duration, now time.Time) error {
+	var timestamp int64
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return ErrSignatureMalformed
+		}
+		switch key {
+		case "t":
+			ts, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrSignatureMalformed
+			}
+			timestamp = ts
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrSignatureMalformed
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return ErrSignatureExpired
+	}
+
+	expected := Sign(secret, timestamp, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
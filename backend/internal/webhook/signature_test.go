@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignatureRoundTrip(t *testing.T) {
+	body := []byte(`{"event":"transaction.completed"}`)
+	now := time.Unix(1700000000, 0)
+
+	header := BuildSignatureHeader(now.Unix(), body, "secret")
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("header = %q", header)
+	}
+
+	if err := VerifySignature(header, body, "secret", 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+}
+
+func TestSignatureMultipleSecrets(t *testing.T) {
+	body := []byte(`{}`)
+	now := time.Now()
+	header := BuildSignatureHeader(now.Unix(), body, "old", "new")
+
+	for _, secret := range []string{"old", "new"} {
+		if err := VerifySignature(header, body, secret, time.Minute, now); err != nil {
+			t.Errorf("VerifySignature(%s) error = %v", secret, err)
+		}
+	}
+}
+
+func TestSignatureVerificationErrors(t *testing.T) {
+	body := []byte(`{"amount":100}`)
+	now := time.Now()
+	valid := BuildSignatureHeader(now.Unix(), body, "secret")
+
+	tests := []struct {
+		name   string
+		header string
+		body   []byte
+		secret string
+		now    time.Time
+		want   error
+	}{
+		{"wrong secret", valid, body, "other", now, ErrSignatureMismatch},
+		{"tampered body", valid, []byte(`{"amount":999}`), "secret", now, ErrSignatureMismatch},
+		{"expired", valid, body, "secret", now.Add(10 * time.Minute), ErrSignatureExpired},
+		{"missing signature", "t=123", body, "secret", now, ErrSignatureMalformed},
+		{"garbage", "invalid", body, "secret", now, ErrSignatureMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.header, tt.body, tt.secret, 5*time.Minute, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+// ErrCodeStatusCheckTimeout indica que o status final não foi confirmado dentro do prazo
+const ErrCodeStatusCheckTimeout = "STATUS_CHECK_TIMEOUT"
+
+// TransactionStore persiste as transações consultadas pelo worker
+type TransactionStore interface {
+	GetPendingTransactions(ctx context.Context, staleBefore, now time.Time, limit int) ([]domain.Transaction, error)
+	UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error)
+}
+
+// MerchantProviderGetter busca a configuração merchant-provider de uma transação
+type MerchantProviderGetter interface {
+	GetByMerchantAndProvider(ctx context.Context, merchantID, providerID uuid.UUID) (*domain.MerchantProvider, error)
+}
+
+// TransactionAuditor registra eventos de auditoria de transações
+type TransactionAuditor interface {
+	LogTransaction(ctx context.Context, merchantID, userID, transactionID uuid.UUID, action string, metadata map[string]interface{}) error
+}
+
+// TransactionNotifier notifica o merchant sobre mudanças de status (webhooks)
+type TransactionNotifier interface {
+	Enqueue(ctx context.Context, tx *domain.Transaction) error
+}
+
+// StatusPollerConfig define a periodicidade e os limites da consulta de status
+type StatusPollerConfig struct {
+	Interval       time.Duration // Intervalo entre ciclos
+	BatchSize      int           // Transações consultadas por ciclo
+	StaleAfter     time.Duration // Tempo sem atualização antes da primeira consulta
+	Timeout        time.Duration // Tempo máximo de cada consulta ao provider
+	InitialBackoff time.Duration // Intervalo após a primeira consulta sem status final
+	MaxBackoff     time.Duration // Intervalo máximo entre consultas
+	ReviewDeadline time.Duration // Prazo, a partir da criação, para enviar a transação para revisão manual
+}
+
+// StatusPoller consulta nos providers o status de transferências pendentes ou em processamento
+type StatusPoller struct {
+	transactions      TransactionStore
+	merchantProviders MerchantProviderGetter
+	providerManager   *providers.ProviderManager
+	tokenCache        *providers.TokenCache
+	auditor           TransactionAuditor
+	notifier          TransactionNotifier
+	config            StatusPollerConfig
+	now               func() time.Time
+}
+
+// NewStatusPoller cria o worker de consulta de status
+func NewStatusPoller(
+	transactions TransactionStore,
+	merchantProviders MerchantProviderGetter,
+	providerManager *providers.ProviderManager,
+	tokenCache *providers.TokenCache,
+	auditor TransactionAuditor,
+	notifier TransactionNotifier,
+	config StatusPollerConfig,
+) *StatusPoller {
+	return &StatusPoller{
+		transactions:      transactions,
+		merchantProviders: merchantProviders,
+		providerManager:   providerManager,
+		tokenCache:        tokenCache,
+		auditor:           auditor,
+		notifier:          notifier,
+		config:            config,
+		now:               time.Now,
+	}
+}
+
+// Run executa os ciclos de consulta até o contexto ser cancelado
+func (p *StatusPoller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.PollOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollOnce consulta um lote de transações com consulta de status vencida
+func (p *StatusPoller) PollOnce(ctx context.Context) {
+	now := p.now()
+	transactions, err := p.transactions.GetPendingTransactions(ctx, now.Add(-p.config.StaleAfter), now, p.config.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Aviso: falha ao buscar transações pendentes: %v", err)
+		}
+		return
+	}
+
+	for i := range transactions {
+		if ctx.Err() != nil {
+			return
+		}
+		p.poll(ctx, &transactions[i])
+	}
+}
+
+// poll consulta o status de uma transação e aplica o resultado
+func (p *StatusPoller) poll(ctx context.Context, tx *domain.Transaction) {
+	previous := tx.Status
+
+	resp, err := p.fetch(ctx, tx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := p.now()
+	tx.StatusChecks++
+
+	switch {
+	case err == nil && isFinalStatus(resp.Status):
+		p.apply(tx, resp, now)
+	case now.Sub(tx.CreatedAt) >= p.config.ReviewDeadline:
+		tx.Status = domain.TransactionStatusManualReview
+		tx.NextStatusCheckAt = nil
+		tx.ErrorCode = ErrCodeStatusCheckTimeout
+		tx.ErrorMessage = "status final não confirmado pelo provider dentro do prazo"
+		if err != nil {
+			tx.ErrorMessage += ": " + err.Error()
+		}
+	default:
+		if err == nil {
+			p.applyProgress(tx, resp)
+		} else {
+			log.Printf("Aviso: falha ao consultar status da transação %s: %v", tx.ID, err)
+		}
+		next := now.Add(p.backoff(tx.StatusChecks))
+		tx.NextStatusCheckAt = &next
+	}
+
+	tx.UpdatedAt = now
+	updated, err := p.transactions.UpdateIfStatus(ctx, tx, previous)
+	if err != nil {
+		log.Printf("Aviso: falha ao atualizar transação %s: %v", tx.ID, err)
+		return
+	}
+	if !updated || tx.Status == previous {
+		return
+	}
+
+	_ = p.auditor.LogTransaction(ctx, tx.MerchantID, uuid.Nil, tx.ID, "status_transition", map[string]interface{}{
+		"from":       previous,
+		"to":         tx.Status,
+		"provider":   tx.Provider.Code,
+		"source":     "status_poller",
+		"error_code": tx.ErrorCode,
+	})
+
+	if err := p.notifier.Enqueue(ctx, tx); err != nil {
+		log.Printf("Aviso: falha ao enfileirar webhooks da transação %s: %v", tx.ID, err)
+	}
+}
+
+// fetch autentica no provider da transação e consulta a transferência
+func (p *StatusPoller) fetch(ctx context.Context, tx *domain.Transaction) (*providers.TransferResponse, error) {
+	if tx.ProviderTxID == "" {
+		return nil, errors.New("transação sem identificador do provider")
+	}
+
+	mp, err := p.merchantProviders.GetByMerchantAndProvider(ctx, tx.MerchantID, tx.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := p.providerManager.Instance(mp)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := p.providerManager.Credentials(mp)
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	token, err := p.tokenCache.GetToken(callCtx, provider, mp, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := provider.GetTransfer(callCtx, &providers.GetTransferRequest{
+		ProviderTxID: tx.ProviderTxID,
+		AuthToken:    token.AccessToken,
+		ClientID:     credentials.ClientID,
+	})
+	if err != nil {
+		// Token revogado pelo banco: descartar do cache para a próxima consulta
+		p.tokenCache.InvalidateRevoked(err, mp)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// apply registra o status final retornado pelo provider
+func (p *StatusPoller) apply(tx *domain.Transaction, resp *providers.TransferResponse, now time.Time) {
+	p.applyProgress(tx, resp)
+
+	tx.Status = resp.Status
+	tx.NextStatusCheckAt = nil
+
+	switch resp.Status {
+	case domain.TransactionStatusCompleted:
+		tx.CompletedAt = resp.CompletedAt
+		if tx.CompletedAt == nil {
+			tx.CompletedAt = &now
+		}
+	case domain.TransactionStatusFailed:
+		tx.ErrorCode = resp.ErrorCode
+		tx.ErrorMessage = resp.ErrorMessage
+	case domain.TransactionStatusCancelled:
+		tx.CancelledAt = &now
+	}
+}
+
+// applyProgress registra dados retornados enquanto a transação ainda está em andamento
+func (p *StatusPoller) applyProgress(tx *domain.Transaction, resp *providers.TransferResponse) {
+	if resp.E2EID != "" {
+		tx.E2EID = resp.E2EID
+	}
+	if resp.ProcessedAt != nil && tx.ProcessedAt == nil {
+		tx.ProcessedAt = resp.ProcessedAt
+	}
+	if resp.Status == domain.TransactionStatusProcessing {
+		tx.Status = domain.TransactionStatusProcessing
+	}
+}
+
+// backoff calcula o intervalo até a próxima consulta (exponencial, limitado a MaxBackoff)
+func (p *StatusPoller) backoff(checks int) time.Duration {
+	delay := p.config.InitialBackoff
+	for i := 1; i < checks && delay < p.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.config.MaxBackoff {
+		delay = p.config.MaxBackoff
+	}
+	return delay
+}
+
+// isFinalStatus indica se o status encerra a consulta junto ao provider
+func isFinalStatus(status domain.TransactionStatus) bool {
+	switch status {
+	case domain.TransactionStatusCompleted,
+		domain.TransactionStatusFailed,
+		domain.TransactionStatusCancelled,
+		domain.TransactionStatusRefunded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+func TestStatusPollerCompletesTransaction(t *testing.T) {
+	completedAt := time.Now().Add(-time.Minute)
+	fake := &fakeProvider{resp: &providers.TransferResponse{
+		ProviderTxID: "tx-1",
+		E2EID:        "E12345678202401011200abcdefghijk",
+		Status:       domain.TransactionStatusCompleted,
+		CompletedAt:  &completedAt,
+	}}
+	env := newPollerTestEnv(t, fake)
+	tx := env.addTransaction(domain.TransactionStatusProcessing, time.Now().Add(-time.Hour))
+
+	env.poller.PollOnce(context.Background())
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusCompleted {
+		t.Fatalf("Status = %s, want completed", got.Status)
+	}
+	if got.E2EID != fake.resp.E2EID {
+		t.Errorf("E2EID = %q, want %q", got.E2EID, fake.resp.E2EID)
+	}
+	if got.CompletedAt == nil || !got.CompletedAt.Equal(completedAt) {
+		t.Errorf("CompletedAt = %v, want %v", got.CompletedAt, completedAt)
+	}
+	if got.NextStatusCheckAt != nil {
+		t.Errorf("NextStatusCheckAt = %v, want nil", got.NextStatusCheckAt)
+	}
+	if fake.gotReq == nil || fake.gotReq.ProviderTxID != "tx-1" || fake.gotReq.AuthToken != "token" {
+		t.Errorf("GetTransfer request = %+v", fake.gotReq)
+	}
+
+	if len(env.auditor.entries) != 1 {
+		t.Fatalf("audit entries = %d, want 1", len(env.auditor.entries))
+	}
+	entry := env.auditor.entries[0]
+	if entry["from"] != domain.TransactionStatusProcessing || entry["to"] != domain.TransactionStatusCompleted {
+		t.Errorf("audit entry = %v", entry)
+	}
+
+	if len(env.notifier.statuses) != 1 || env.notifier.statuses[0] != domain.TransactionStatusCompleted {
+		t.Errorf("notified statuses = %v, want [completed]", env.notifier.statuses)
+	}
+}
+
+func TestStatusPollerFailedTransaction(t *testing.T) {
+	fake := &fakeProvider{resp: &providers.TransferResponse{
+		Status:       domain.TransactionStatusFailed,
+		ErrorCode:    "AC03",
+		ErrorMessage: "Conta inexistente",
+	}}
+	env := newPollerTestEnv(t, fake)
+	tx := env.addTransaction(domain.TransactionStatusPending, time.Now().Add(-time.Hour))
+
+	env.poller.PollOnce(context.Background())
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusFailed || got.ErrorCode != "AC03" || got.ErrorMessage != "Conta inexistente" {
+		t.Errorf("transaction = %+v, want failed with provider error", got)
+	}
+}
+
+func TestStatusPollerBacksOffWhileProcessing(t *testing.T) {
+	fake := &fakeProvider{resp: &providers.TransferResponse{Status: domain.TransactionStatusProcessing}}
+	env := newPollerTestEnv(t, fake)
+	tx := env.addTransaction(domain.TransactionStatusPending, time.Now().Add(-time.Hour))
+	now := time.Now()
+	env.poller.now = func() time.Time { return now }
+
+	env.poller.PollOnce(context.Background())
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusProcessing {
+		t.Errorf("Status = %s, want processing", got.Status)
+	}
+	if got.StatusChecks != 1 {
+		t.Errorf("StatusChecks = %d, want 1", got.StatusChecks)
+	}
+	if got.NextStatusCheckAt == nil || !got.NextStatusCheckAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("NextStatusCheckAt = %v, want %v", got.NextStatusCheckAt, now.Add(time.Minute))
+	}
+
+	// Pending -> processing também é auditado
+	if len(env.auditor.entries) != 1 {
+		t.Errorf("audit entries = %d, want 1", len(env.auditor.entries))
+	}
+}
+
+func TestStatusPollerManualReviewAfterDeadline(t *testing.T) {
+	fake := &fakeProvider{err: providers.NewProviderError(providers.ErrCodeBankUnavailable, "Indisponível", nil)}
+	env := newPollerTestEnv(t, fake)
+	tx := env.addTransaction(domain.TransactionStatusProcessing, time.Now().Add(-48*time.Hour))
+
+	env.poller.PollOnce(context.Background())
+
+	got := env.store.txs[tx.ID]
+	if got.Status != domain.TransactionStatusManualReview {
+		t.Fatalf("Status = %s, want manual_review", got.Status)
+	}
+	if got.ErrorCode != ErrCodeStatusCheckTimeout {
+		t.Errorf("ErrorCode = %s, want %s", got.ErrorCode, ErrCodeStatusCheckTimeout)
+	}
+	if got.NextStatusCheckAt != nil {
+		t.Errorf("NextStatusCheckAt = %v, want nil", got.NextStatusCheckAt)
+	}
+	if len(env.auditor.entries) != 1 || env.auditor.entries[0]["to"] != domain.TransactionStatusManualReview {
+		t.Errorf("audit entries = %v", env.auditor.entries)
+	}
+}
+
+func TestStatusPollerSkipsConcurrentUpdate(t *testing.T) {
+	fake := &fakeProvider{resp: &providers.TransferResponse{Status: domain.TransactionStatusCompleted}}
+	env := newPollerTestEnv(t, fake)
+	tx := env.addTransaction(domain.TransactionStatusProcessing, time.Now().Add(-time.Hour))
+
+	// Outra atualização concluiu a transação depois da leitura do lote
+	env.store.overrideStatus = map[uuid.UUID]domain.TransactionStatus{tx.ID: domain.TransactionStatusCompleted}
+
+	env.poller.PollOnce(context.Background())
+
+	if len(env.auditor.entries) != 0 || len(env.notifier.statuses) != 0 {
+		t.Errorf("audit entries = %v, notified = %v, want none", env.auditor.entries, env.notifier.statuses)
+	}
+}
+
+func TestStatusPollerBackoff(t *testing.T) {
+	poller := &StatusPoller{config: StatusPollerConfig{InitialBackoff: time.Minute, MaxBackoff: 10 * time.Minute}}
+
+	tests := []struct {
+		checks int
+		want   time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := poller.backoff(tt.checks); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.checks, got, tt.want)
+		}
+	}
+}
+
+type pollerTestEnv struct {
+	poller   *StatusPoller
+	store    *fakeTransactionStore
+	auditor  *fakeAuditor
+	notifier *fakeNotifier
+	mp       *domain.MerchantProvider
+}
+
+func newPollerTestEnv(t *testing.T, fake *fakeProvider) *pollerTestEnv {
+	t.Helper()
+
+	registry := providers.NewProviderRegistry()
+	registry.Register(func() providers.PixProvider { return fake })
+
+	providerID := uuid.New()
+	mp := &domain.MerchantProvider{
+		ID:           uuid.New(),
+		MerchantID:   uuid.New(),
+		ProviderID:   providerID,
+		Active:       true,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Provider:     domain.Provider{ID: providerID, Code: "fake", Active: true},
+	}
+
+	store := &fakeTransactionStore{txs: make(map[uuid.UUID]*domain.Transaction)}
+	auditor := &fakeAuditor{}
+	notifier := &fakeNotifier{}
+	poller := NewStatusPoller(
+		store,
+		fakeMerchantProviders{mp: mp},
+		providers.NewProviderManager(registry, nil, plainDecrypter{}, nil),
+		providers.NewTokenCache(nil, providers.DefaultTokenRefreshMargin),
+		auditor,
+		notifier,
+		StatusPollerConfig{
+			Interval:       time.Second,
+			BatchSize:      10,
+			StaleAfter:     time.Minute,
+			Timeout:        time.Second,
+			InitialBackoff: time.Minute,
+			MaxBackoff:     time.Hour,
+			ReviewDeadline: 24 * time.Hour,
+		},
+	)
+
+	return &pollerTestEnv{poller: poller, store: store, auditor: auditor, notifier: notifier, mp: mp}
+}
+
+func (e *pollerTestEnv) addTransaction(status domain.TransactionStatus, createdAt time.Time) *domain.Transaction {
+	tx := &domain.Transaction{
+		ID:           uuid.New(),
+		MerchantID:   e.mp.MerchantID,
+		ProviderID:   e.mp.ProviderID,
+		ProviderTxID: "tx-1",
+		Type:         domain.TransactionTypeTransfer,
+		Status:       status,
+		Amount:       1000,
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+		Provider:     e.mp.Provider,
+	}
+	e.store.txs[tx.ID] = tx
+	return tx
+}
+
+type fakeProvider struct {
+	providers.PixProvider // Métodos não usados pelo worker não são implementados
+
+	resp   *providers.TransferResponse
+	err    error
+	gotReq *providers.GetTransferRequest
+}
+
+func (f *fakeProvider) GetCode() string { return "fake" }
+
+func (f *fakeProvider) Initialize(config providers.ProviderConfig) error { return nil }
+
+func (f *fakeProvider) Authenticate(ctx context.Context, credentials providers.ProviderCredentials) (*providers.AuthToken, error) {
+	return &providers.AuthToken{AccessToken: "token", ExpiresIn: 3600, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (f *fakeProvider) GetTransfer(ctx context.Context, req *providers.GetTransferRequest) (*providers.TransferResponse, error) {
+	f.gotReq = req
+	return f.resp, f.err
+}
+
+type fakeTransactionStore struct {
+	txs            map[uuid.UUID]*domain.Transaction
+	overrideStatus map[uuid.UUID]domain.TransactionStatus
+}
+
+func (s *fakeTransactionStore) GetPendingTransactions(ctx context.Context, staleBefore, now time.Time, limit int) ([]domain.Transaction, error) {
+	var result []domain.Transaction
+	for _, tx := range s.txs {
+		result = append(result, *tx)
+	}
+	for id, status := range s.overrideStatus {
+		s.txs[id].Status = status
+	}
+	return result, nil
+}
+
+func (s *fakeTransactionStore) UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error) {
+	if s.txs[tx.ID].Status != expected {
+		return false, nil
+	}
+	updated := *tx
+	s.txs[tx.ID] = &updated
+	return true, nil
+}
+
+type fakeMerchantProviders struct {
+	mp *domain.MerchantProvider
+}
+
+func (f fakeMerchantProviders) GetByMerchantAndProvider(ctx context.Context, merchantID, providerID uuid.UUID) (*domain.MerchantProvider, error) {
+	return f.mp, nil
+}
+
+type fakeAuditor struct {
+	entries []map[string]interface{}
+}
+
+func (f *fakeAuditor) LogTransaction(ctx context.Context, merchantID, userID, transactionID uuid.UUID, action string, metadata map[string]interface{}) error {
+	f.entries = append(f.entries, metadata)
+	return nil
+}
+
+type fakeNotifier struct {
+	statuses []domain.TransactionStatus
+}
+
+func (f *fakeNotifier) Enqueue(ctx context.Context, tx *domain.Transaction) error {
+	f.statuses = append(f.statuses, tx.Status)
+	return nil
+}
+
+type plainDecrypter struct{}
+
+func (plainDecrypter) Decrypt(ciphertext string) (string, error) {
+	return ciphertext, nil
+}
@@ -0,0 +1,350 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+// ScheduleStore persiste as transferências agendadas
+type ScheduleStore interface {
+	GetDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledTransfer, error)
+	Claim(ctx context.Context, schedule *domain.ScheduledTransfer, expectedOccurrences int) (bool, error)
+}
+
+// ScheduledTransactionStore persiste as transações criadas em cada execução
+type ScheduledTransactionStore interface {
+	Create(ctx context.Context, tx *domain.Transaction) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error)
+	UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error)
+	CreateAttempts(ctx context.Context, attempts []domain.TransactionAttempt) error
+}
+
+// errOccurrenceCancelled indica que a execução foi cancelada pelo merchant durante o envio
+var errOccurrenceCancelled = errors.New("execução cancelada durante o envio")
+
+// ScheduleAuditor registra as execuções e as tentativas nos providers
+type ScheduleAuditor interface {
+	TransactionAuditor
+	LogProviderOperation(ctx context.Context, merchantID, transactionID uuid.UUID, provider, operation string, success bool, errorMsg string, duration int64) error
+}
+
+// TransferSchedulerConfig define a periodicidade e os limites das execuções agendadas
+type TransferSchedulerConfig struct {
+	Interval  time.Duration // Intervalo entre ciclos
+	BatchSize int           // Agendamentos executados por ciclo
+	Timeout   time.Duration // Tempo máximo de cada transferência, incluindo o fallback
+}
+
+// TransferScheduler executa as transferências agendadas pelo mesmo caminho das transferências
+// imediatas: seleção de provider com fallback, tentativas registradas e webhooks de status.
+type TransferScheduler struct {
+	schedules       ScheduleStore
+	transactions    ScheduledTransactionStore
+	providerManager *providers.ProviderManager
+	tokenCache      *providers.TokenCache
+	auditor         ScheduleAuditor
+	notifier        TransactionNotifier
+	config          TransferSchedulerConfig
+	now             func() time.Time
+}
+
+// NewTransferScheduler cria o worker de transferências agendadas
+func NewTransferScheduler(
+	schedules ScheduleStore,
+	transactions ScheduledTransactionStore,
+	providerManager *providers.ProviderManager,
+	tokenCache *providers.TokenCache,
+	auditor ScheduleAuditor,
+	notifier TransactionNotifier,
+	config TransferSchedulerConfig,
+) *TransferScheduler {
+	return &TransferScheduler{
+		schedules:       schedules,
+		transactions:    transactions,
+		providerManager: providerManager,
+		tokenCache:      tokenCache,
+		auditor:         auditor,
+		notifier:        notifier,
+		config:          config,
+		now:             time.Now,
+	}
+}
+
+// Run executa os ciclos até o contexto ser cancelado
+func (s *TransferScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce executa um lote de agendamentos cuja próxima execução já chegou
+func (s *TransferScheduler) RunOnce(ctx context.Context) {
+	schedules, err := s.schedules.GetDue(ctx, s.now(), s.config.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Aviso: falha ao buscar transferências agendadas: %v", err)
+		}
+		return
+	}
+
+	for i := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		s.run(ctx, &schedules[i])
+	}
+}
+
+// run reserva a execução atual do agendamento e executa a transferência
+func (s *TransferScheduler) run(ctx context.Context, schedule *domain.ScheduledTransfer) {
+	// Sem provider disponível (ex: circuit breaker aberto) a execução fica para o próximo ciclo
+	candidates, err := s.providerManager.SelectProviders(ctx, schedule.MerchantID, schedule.ProviderCode)
+	if errors.Is(err, providers.ErrNoHealthyProvider) {
+		log.Printf("Aviso: transferência agendada %s adiada: nenhum provider disponível", schedule.ID)
+		return
+	}
+
+	// A execução é reservada antes da transferência: cada ocorrência é enviada ao banco no máximo uma vez
+	expected := schedule.Occurrences
+	txID := uuid.New()
+	schedule.Advance(s.now())
+	schedule.LastTransactionID = &txID
+
+	claimed, err := s.schedules.Claim(ctx, schedule, expected)
+	if err != nil {
+		log.Printf("Aviso: falha ao reservar transferência agendada %s: %v", schedule.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	tx := newScheduledTransaction(schedule, txID)
+	if err := s.execute(ctx, schedule, tx); err != nil {
+		log.Printf("Aviso: execução %d da transferência agendada %s não criou transação: %v", schedule.Occurrences, schedule.ID, err)
+		_ = s.auditor.LogTransaction(ctx, schedule.MerchantID, uuid.Nil, uuid.Nil, "scheduled_transfer_failed", map[string]interface{}{
+			"scheduled_transfer_id": schedule.ID,
+			"occurrence":            schedule.Occurrences,
+			"error":                 err.Error(),
+		})
+		s.recordFailure(ctx, tx, candidates)
+	}
+}
+
+// recordFailure grava como falha a execução que não chegou a criar a transação, para o merchant
+// receber o webhook transaction.failed da ocorrência
+func (s *TransferScheduler) recordFailure(ctx context.Context, tx *domain.Transaction, candidates []domain.MerchantProvider) {
+	for i := range candidates {
+		if tx.ProviderID == uuid.Nil || candidates[i].ProviderID == tx.ProviderID {
+			tx.ProviderID = candidates[i].ProviderID
+			tx.Provider = candidates[i].Provider
+			break
+		}
+	}
+	if tx.ProviderID == uuid.Nil {
+		log.Printf("Aviso: execução com falha da transferência agendada %s sem provider para registro", *tx.ScheduledTransferID)
+		return
+	}
+
+	tx.Status = domain.TransactionStatusFailed
+	tx.ErrorCode = "SCHEDULED_TRANSFER_FAILED"
+	tx.ErrorMessage = "execução agendada não pôde ser enviada ao banco"
+	if err := s.transactions.Create(ctx, tx); err != nil {
+		log.Printf("Aviso: falha ao registrar execução com falha da transferência agendada %s: %v", *tx.ScheduledTransferID, err)
+		return
+	}
+
+	if err := s.notifier.Enqueue(ctx, tx); err != nil {
+		log.Printf("Aviso: falha ao enfileirar webhooks da transação %s: %v", tx.ID, err)
+	}
+}
+
+// execute envia a transferência com fallback entre os providers do merchant. Retorna erro
+// apenas quando a transação não pôde ser criada; falhas do provider ficam na transação.
+func (s *TransferScheduler) execute(ctx context.Context, schedule *domain.ScheduledTransfer, tx *domain.Transaction) error {
+	callCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	var selectedProvider *domain.Provider
+	var transferResp *providers.TransferResponse
+	txCreated := false
+	submitted := false
+
+	attempts, transferErr := s.providerManager.ExecuteWithFallback(callCtx, schedule.MerchantID, schedule.ProviderCode, func(providerImpl providers.PixProvider, merchantProvider *domain.MerchantProvider) error {
+		selectedProvider = &merchantProvider.Provider
+		tx.ProviderID = merchantProvider.ProviderID
+		submitted = false
+
+		if !txCreated {
+			if err := s.transactions.Create(ctx, tx); err != nil {
+				return fmt.Errorf("falha ao criar transação: %w", err)
+			}
+			txCreated = true
+		} else if updated, err := s.transactions.UpdateIfStatus(ctx, tx, domain.TransactionStatusPending); err != nil {
+			return fmt.Errorf("falha ao atualizar transação: %w", err)
+		} else if !updated {
+			return errOccurrenceCancelled
+		}
+
+		credentials, err := s.providerManager.Credentials(merchantProvider)
+		if err != nil {
+			return err
+		}
+		token, err := s.tokenCache.GetToken(callCtx, providerImpl, merchantProvider, credentials)
+		if err != nil {
+			return err
+		}
+
+		submitted = true
+		transferReq := providers.NewTransferRequest(tx, merchantProvider, token.AccessToken, credentials.ClientID)
+		transferReq.PayeeISPB = schedule.PayeeISPB
+		transferReq.PayeeAccountType = schedule.PayeeAccountType
+		resp, err := providerImpl.CreateTransfer(callCtx, transferReq)
+		if err != nil {
+			// Token revogado pelo banco: descartar do cache para a próxima execução
+			s.tokenCache.InvalidateRevoked(err, merchantProvider)
+			return err
+		}
+		transferResp = resp
+		return nil
+	})
+
+	if !txCreated {
+		if transferErr == nil {
+			transferErr = errors.New("nenhum provider executou a transferência")
+		}
+		return transferErr
+	}
+
+	providers.RecordAttempts(ctx, s.transactions, s.auditor, tx, attempts, "create_transfer")
+
+	// O cancelamento feito pelo merchant já foi registrado e notificado
+	if errors.Is(transferErr, errOccurrenceCancelled) {
+		return nil
+	}
+
+	accepted := true
+	if transferErr != nil {
+		tx.Status = domain.TransactionStatusFailed
+		accepted = false
+		if submitted && providers.OutcomeUnknown(transferErr) {
+			// Não se sabe se o banco processou e, sem o ID do banco, não há como consultar: revisão manual
+			tx.Status = domain.TransactionStatusManualReview
+			accepted = true
+		}
+		var providerErr *providers.ProviderError
+		if errors.As(transferErr, &providerErr) {
+			tx.ErrorCode = providerErr.Code
+			tx.ErrorMessage = providerErr.Message
+		} else {
+			tx.ErrorMessage = transferErr.Error()
+		}
+	} else {
+		tx.ProviderTxID = transferResp.ProviderTxID
+		tx.E2EID = transferResp.E2EID
+		tx.Status = transferResp.Status
+		tx.ProcessedAt = transferResp.ProcessedAt
+		tx.CompletedAt = transferResp.CompletedAt
+		accepted = tx.Status != domain.TransactionStatusFailed
+	}
+
+	saved, err := s.saveSubmission(ctx, tx, accepted)
+	if err != nil {
+		log.Printf("Aviso: falha ao atualizar transação %s da transferência agendada %s: %v", tx.ID, schedule.ID, err)
+	}
+	if !saved {
+		return nil
+	}
+
+	_ = s.auditor.LogTransaction(ctx, tx.MerchantID, uuid.Nil, tx.ID, "scheduled_transfer_executed", map[string]interface{}{
+		"scheduled_transfer_id": schedule.ID,
+		"occurrence":            schedule.Occurrences,
+		"provider":              selectedProvider.Code,
+		"amount":                tx.Amount,
+		"status":                tx.Status,
+		"error_code":            tx.ErrorCode,
+	})
+
+	tx.Provider = *selectedProvider
+	if err := s.notifier.Enqueue(ctx, tx); err != nil {
+		log.Printf("Aviso: falha ao enfileirar webhooks da transação %s: %v", tx.ID, err)
+	}
+	return nil
+}
+
+// saveSubmission grava o resultado do envio se a transação continua pendente, como no envio
+// pela API. Se o merchant a cancelou durante o envio e o banco pode ter aceito a transferência
+// (accepted), o cancelamento local não vale e a transação vai para revisão manual.
+func (s *TransferScheduler) saveSubmission(ctx context.Context, tx *domain.Transaction, accepted bool) (bool, error) {
+	updated, err := s.transactions.UpdateIfStatus(ctx, tx, domain.TransactionStatusPending)
+	if err != nil || updated {
+		return updated, err
+	}
+
+	current, err := s.transactions.GetByID(ctx, tx.ID)
+	if err != nil {
+		return false, err
+	}
+	if !accepted || current.Status != domain.TransactionStatusCancelled {
+		return false, nil
+	}
+
+	previous := current.Status
+	current.MarkCancelConflict(tx.ProviderTxID, tx.E2EID, s.now())
+	moved, err := s.transactions.UpdateIfStatus(ctx, current, previous)
+	if err != nil || !moved {
+		return false, err
+	}
+
+	_ = s.auditor.LogTransaction(ctx, current.MerchantID, uuid.Nil, current.ID, "status_transition", map[string]interface{}{
+		"from":                  previous,
+		"to":                    current.Status,
+		"provider":              current.Provider.Code,
+		"source":                "scheduler",
+		"scheduled_transfer_id": *tx.ScheduledTransferID,
+		"reason":                current.ErrorMessage,
+	})
+	if err := s.notifier.Enqueue(ctx, current); err != nil {
+		log.Printf("Aviso: falha ao enfileirar webhooks da transação %s: %v", current.ID, err)
+	}
+	return false, nil
+}
+
+// newScheduledTransaction cria a transação da execução atual, com o external_id da ocorrência
+func newScheduledTransaction(schedule *domain.ScheduledTransfer, txID uuid.UUID) *domain.Transaction {
+	scheduleID := schedule.ID
+	return &domain.Transaction{
+		ID:                  txID,
+		MerchantID:          schedule.MerchantID,
+		ExternalID:          schedule.OccurrenceExternalID(),
+		Type:                domain.TransactionTypeTransfer,
+		Status:              domain.TransactionStatusPending,
+		Amount:              schedule.Amount,
+		Description:         schedule.Description,
+		PayeeName:           schedule.PayeeName,
+		PayeeDocument:       schedule.PayeeDocument,
+		PayeePixKey:         schedule.PayeePixKey,
+		PayeePixKeyType:     schedule.PayeePixKeyType,
+		PayeeBank:           schedule.PayeeBank,
+		PayeeAccountAgency:  schedule.PayeeAccountAgency,
+		PayeeAccountNumber:  schedule.PayeeAccountNumber,
+		Metadata:            schedule.Metadata,
+		ScheduledTransferID: &scheduleID,
+	}
+}
@@ -0,0 +1,350 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pixsaas/backend/internal/domain"
+	"github.com/pixsaas/backend/internal/providers"
+)
+
+func TestTransferSchedulerExecutesDueSchedule(t *testing.T) {
+	fake := &fakeTransferProvider{resp: &providers.TransferResponse{
+		ProviderTxID: "tx-1",
+		E2EID:        "E12345678202401011200abcdefghijk",
+		Status:       domain.TransactionStatusProcessing,
+	}}
+	env := newSchedulerTestEnv(t, fake)
+	schedule := env.addSchedule(domain.ScheduleMonthly)
+
+	env.scheduler.RunOnce(context.Background())
+
+	got := env.schedules.items[schedule.ID]
+	if got.Occurrences != 1 || got.Status != domain.ScheduledTransferActive || got.NextRunAt == nil || !got.NextRunAt.After(env.now) {
+		t.Fatalf("schedule after run = %+v", got)
+	}
+	if len(env.transactions.txs) != 1 {
+		t.Fatalf("transactions = %d, want 1", len(env.transactions.txs))
+	}
+
+	tx := env.transactions.txs[*got.LastTransactionID]
+	if tx == nil {
+		t.Fatalf("last transaction %v not stored", got.LastTransactionID)
+	}
+	if tx.ExternalID != "scheduled:"+schedule.ID.String()+":1" || tx.Type != domain.TransactionTypeTransfer || tx.Status != domain.TransactionStatusProcessing || tx.ProviderTxID != "tx-1" {
+		t.Errorf("transaction = %+v", tx)
+	}
+	if tx.ScheduledTransferID == nil || *tx.ScheduledTransferID != schedule.ID {
+		t.Errorf("ScheduledTransferID = %v, want %s", tx.ScheduledTransferID, schedule.ID)
+	}
+	if fake.gotReq == nil || fake.gotReq.PayeePixKey != "joao@example.com" || fake.gotReq.Amount != 150000 || fake.gotReq.AuthToken != "token" {
+		t.Errorf("CreateTransfer request = %+v", fake.gotReq)
+	}
+	if len(env.transactions.attempts) != 1 || !env.transactions.attempts[0].Success {
+		t.Errorf("attempts = %+v", env.transactions.attempts)
+	}
+	if len(env.notifier.statuses) != 1 || env.notifier.statuses[0] != domain.TransactionStatusProcessing {
+		t.Errorf("notified statuses = %v, want [processing]", env.notifier.statuses)
+	}
+
+	// A próxima execução só ocorre na data seguinte
+	env.scheduler.RunOnce(context.Background())
+	if len(env.transactions.txs) != 1 {
+		t.Errorf("transactions after second cycle = %d, want 1", len(env.transactions.txs))
+	}
+}
+
+func TestTransferSchedulerRecordsProviderFailure(t *testing.T) {
+	fake := &fakeTransferProvider{err: providers.NewProviderError(providers.ErrCodeInsufficientBalance, "Saldo insuficiente", nil)}
+	env := newSchedulerTestEnv(t, fake)
+	schedule := env.addSchedule(domain.ScheduleOnce)
+
+	env.scheduler.RunOnce(context.Background())
+
+	got := env.schedules.items[schedule.ID]
+	if got.Status != domain.ScheduledTransferCompleted || got.NextRunAt != nil {
+		t.Errorf("schedule after run = %+v", got)
+	}
+	tx := env.transactions.txs[*got.LastTransactionID]
+	if tx == nil || tx.Status != domain.TransactionStatusFailed || tx.ErrorCode != providers.ErrCodeInsufficientBalance {
+		t.Fatalf("transaction = %+v, want failed with provider error", tx)
+	}
+	if len(env.notifier.statuses) != 1 || env.notifier.statuses[0] != domain.TransactionStatusFailed {
+		t.Errorf("notified statuses = %v, want [failed]", env.notifier.statuses)
+	}
+}
+
+func TestTransferSchedulerSendsAmbiguousFailureToReview(t *testing.T) {
+	fake := &fakeTransferProvider{err: providers.NewProviderError("TRANSFER_FAILED", "Transferência falhou", providers.NewHTTPError(http.StatusBadGateway, nil, nil))}
+	env := newSchedulerTestEnv(t, fake)
+	schedule := env.addSchedule(domain.ScheduleOnce)
+
+	env.scheduler.RunOnce(context.Background())
+
+	got := env.schedules.items[schedule.ID]
+	tx := env.transactions.txs[*got.LastTransactionID]
+	if tx == nil || tx.Status != domain.TransactionStatusManualReview || tx.ErrorCode != providers.ErrCodeBankUnavailable {
+		t.Fatalf("transaction = %+v, want manual_review", tx)
+	}
+	if len(env.notifier.statuses) != 1 || env.notifier.statuses[0] != domain.TransactionStatusManualReview {
+		t.Errorf("notified statuses = %v, want [manual_review]", env.notifier.statuses)
+	}
+}
+
+func TestTransferSchedulerNotifiesWhenTransactionIsNotCreated(t *testing.T) {
+	fake := &fakeTransferProvider{resp: &providers.TransferResponse{Status: domain.TransactionStatusCompleted}}
+	env := newSchedulerTestEnv(t, fake)
+	env.transactions.createErrs = []error{errors.New("connection reset")}
+	schedule := env.addSchedule(domain.ScheduleOnce)
+
+	env.scheduler.RunOnce(context.Background())
+
+	got := env.schedules.items[schedule.ID]
+	tx := env.transactions.txs[*got.LastTransactionID]
+	if tx == nil || tx.Status != domain.TransactionStatusFailed || tx.ErrorCode != "SCHEDULED_TRANSFER_FAILED" || tx.ProviderID != env.mp.ProviderID {
+		t.Fatalf("transaction = %+v, want failed execution recorded", tx)
+	}
+	if fake.gotReq != nil {
+		t.Errorf("transfer sent without a stored transaction")
+	}
+	if len(env.notifier.statuses) != 1 || env.notifier.statuses[0] != domain.TransactionStatusFailed {
+		t.Errorf("notified statuses = %v, want [failed]", env.notifier.statuses)
+	}
+}
+
+func TestTransferSchedulerFlagsCancelDuringSubmission(t *testing.T) {
+	tests := map[string]struct {
+		provider   *fakeTransferProvider
+		wantStatus domain.TransactionStatus
+		notified   int
+	}{
+		"bank accepted": {
+			provider:   &fakeTransferProvider{resp: &providers.TransferResponse{ProviderTxID: "tx-1", Status: domain.TransactionStatusProcessing}},
+			wantStatus: domain.TransactionStatusManualReview,
+			notified:   1,
+		},
+		"bank rejected": {
+			provider:   &fakeTransferProvider{err: providers.NewProviderError(providers.ErrCodeInsufficientBalance, "Saldo insuficiente", nil)},
+			wantStatus: domain.TransactionStatusCancelled,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			env := newSchedulerTestEnv(t, tt.provider)
+			schedule := env.addSchedule(domain.ScheduleOnce)
+			// O merchant cancela a execução enquanto ela está no banco
+			tt.provider.onCreate = func() {
+				for _, tx := range env.transactions.txs {
+					tx.Status = domain.TransactionStatusCancelled
+				}
+			}
+
+			env.scheduler.RunOnce(context.Background())
+
+			got := env.schedules.items[schedule.ID]
+			tx := env.transactions.txs[*got.LastTransactionID]
+			if tx.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", tx.Status, tt.wantStatus)
+			}
+			if tt.wantStatus == domain.TransactionStatusManualReview && (tx.ErrorCode != "CANCEL_CONFLICT" || tx.ProviderTxID != "tx-1") {
+				t.Errorf("transaction = %+v, want CANCEL_CONFLICT with the bank id", tx)
+			}
+			if len(env.notifier.statuses) != tt.notified {
+				t.Errorf("notified statuses = %v, want %d", env.notifier.statuses, tt.notified)
+			}
+		})
+	}
+}
+
+func TestTransferSchedulerSkipsClaimedSchedule(t *testing.T) {
+	fake := &fakeTransferProvider{resp: &providers.TransferResponse{Status: domain.TransactionStatusCompleted}}
+	env := newSchedulerTestEnv(t, fake)
+	schedule := env.addSchedule(domain.ScheduleDaily)
+
+	// Outro worker reservou a execução depois da leitura
+	env.schedules.claimedElsewhere = map[uuid.UUID]bool{schedule.ID: true}
+	env.scheduler.RunOnce(context.Background())
+
+	if len(env.transactions.txs) != 0 || fake.gotReq != nil {
+		t.Errorf("transfer executed for schedule claimed by another worker")
+	}
+}
+
+func TestTransferSchedulerPostponesWithoutHealthyProvider(t *testing.T) {
+	fake := &fakeTransferProvider{resp: &providers.TransferResponse{Status: domain.TransactionStatusCompleted}}
+	env := newSchedulerTestEnv(t, fake)
+	env.mp.Provider.HealthStatus = domain.ProviderHealthUnhealthy
+	schedule := env.addSchedule(domain.ScheduleOnce)
+
+	env.scheduler.RunOnce(context.Background())
+
+	got := env.schedules.items[schedule.ID]
+	if got.Occurrences != 0 || got.Status != domain.ScheduledTransferActive || len(env.transactions.txs) != 0 {
+		t.Errorf("schedule = %+v, transactions = %d; want execution postponed", got, len(env.transactions.txs))
+	}
+}
+
+type schedulerTestEnv struct {
+	scheduler    *TransferScheduler
+	schedules    *fakeScheduleStore
+	transactions *fakeScheduledTransactions
+	notifier     *fakeNotifier
+	mp           *domain.MerchantProvider
+	now          time.Time
+}
+
+func newSchedulerTestEnv(t *testing.T, fake *fakeTransferProvider) *schedulerTestEnv {
+	t.Helper()
+
+	registry := providers.NewProviderRegistry()
+	registry.Register(func() providers.PixProvider { return fake })
+
+	providerID := uuid.New()
+	mp := &domain.MerchantProvider{
+		ID:           uuid.New(),
+		MerchantID:   uuid.New(),
+		ProviderID:   providerID,
+		Active:       true,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Provider:     domain.Provider{ID: providerID, Code: "fake", Active: true},
+	}
+
+	env := &schedulerTestEnv{
+		schedules:    &fakeScheduleStore{items: make(map[uuid.UUID]*domain.ScheduledTransfer)},
+		transactions: &fakeScheduledTransactions{txs: make(map[uuid.UUID]*domain.Transaction)},
+		notifier:     &fakeNotifier{},
+		mp:           mp,
+		now:          time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC),
+	}
+	env.scheduler = NewTransferScheduler(
+		env.schedules,
+		env.transactions,
+		providers.NewProviderManager(registry, fakeMerchantProviderLister{mp: mp}, plainDecrypter{}, nil),
+		providers.NewTokenCache(nil, providers.DefaultTokenRefreshMargin),
+		&fakeScheduleAuditor{},
+		env.notifier,
+		TransferSchedulerConfig{Interval: time.Second, BatchSize: 10, Timeout: time.Second},
+	)
+	env.scheduler.now = func() time.Time { return env.now }
+	return env
+}
+
+func (e *schedulerTestEnv) addSchedule(frequency domain.ScheduleFrequency) *domain.ScheduledTransfer {
+	start := e.now.Add(-time.Minute)
+	schedule := &domain.ScheduledTransfer{
+		ID:              uuid.New(),
+		MerchantID:      e.mp.MerchantID,
+		ExternalID:      "folha",
+		Amount:          150000,
+		PayeeName:       "João da Silva",
+		PayeeDocument:   "52998224725",
+		PayeePixKey:     "joao@example.com",
+		PayeePixKeyType: domain.PixKeyTypeEmail,
+		Frequency:       frequency,
+		StartAt:         start,
+		NextRunAt:       &start,
+		Status:          domain.ScheduledTransferActive,
+	}
+	e.schedules.items[schedule.ID] = schedule
+	return schedule
+}
+
+type fakeTransferProvider struct {
+	fakeProvider
+
+	resp     *providers.TransferResponse
+	err      error
+	gotReq   *providers.TransferRequest
+	onCreate func() // Executado durante o envio (ex: cancelamento concorrente)
+}
+
+func (f *fakeTransferProvider) CreateTransfer(ctx context.Context, req *providers.TransferRequest) (*providers.TransferResponse, error) {
+	f.gotReq = req
+	if f.onCreate != nil {
+		f.onCreate()
+	}
+	return f.resp, f.err
+}
+
+type fakeMerchantProviderLister struct {
+	mp *domain.MerchantProvider
+}
+
+func (f fakeMerchantProviderLister) ListByMerchant(ctx context.Context, merchantID uuid.UUID, activeOnly bool) ([]domain.MerchantProvider, error) {
+	return []domain.MerchantProvider{*f.mp}, nil
+}
+
+type fakeScheduleStore struct {
+	items            map[uuid.UUID]*domain.ScheduledTransfer
+	claimedElsewhere map[uuid.UUID]bool
+}
+
+func (s *fakeScheduleStore) GetDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	var result []domain.ScheduledTransfer
+	for _, schedule := range s.items {
+		if schedule.Status == domain.ScheduledTransferActive && schedule.NextRunAt != nil && !schedule.NextRunAt.After(now) {
+			result = append(result, *schedule)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeScheduleStore) Claim(ctx context.Context, schedule *domain.ScheduledTransfer, expectedOccurrences int) (bool, error) {
+	stored := s.items[schedule.ID]
+	if s.claimedElsewhere[schedule.ID] || stored.Status != domain.ScheduledTransferActive || stored.Occurrences != expectedOccurrences {
+		return false, nil
+	}
+	updated := *schedule
+	s.items[schedule.ID] = &updated
+	return true, nil
+}
+
+type fakeScheduledTransactions struct {
+	txs        map[uuid.UUID]*domain.Transaction
+	attempts   []domain.TransactionAttempt
+	createErrs []error // Erros retornados pelas próximas chamadas de Create
+}
+
+func (s *fakeScheduledTransactions) Create(ctx context.Context, tx *domain.Transaction) error {
+	if len(s.createErrs) > 0 {
+		err := s.createErrs[0]
+		s.createErrs = s.createErrs[1:]
+		return err
+	}
+	stored := *tx
+	s.txs[tx.ID] = &stored
+	return nil
+}
+
+func (s *fakeScheduledTransactions) GetByID(ctx context.Context, id uuid.UUID) (*domain.Transaction, error) {
+	stored := *s.txs[id]
+	return &stored, nil
+}
+
+func (s *fakeScheduledTransactions) UpdateIfStatus(ctx context.Context, tx *domain.Transaction, expected domain.TransactionStatus) (bool, error) {
+	if s.txs[tx.ID].Status != expected {
+		return false, nil
+	}
+	stored := *tx
+	s.txs[tx.ID] = &stored
+	return true, nil
+}
+
+func (s *fakeScheduledTransactions) CreateAttempts(ctx context.Context, attempts []domain.TransactionAttempt) error {
+	s.attempts = append(s.attempts, attempts...)
+	return nil
+}
+
+type fakeScheduleAuditor struct {
+	fakeAuditor
+}
+
+func (f *fakeScheduleAuditor) LogProviderOperation(ctx context.Context, merchantID, transactionID uuid.UUID, provider, operation string, success bool, errorMsg string, duration int64) error {
+	return nil
+}
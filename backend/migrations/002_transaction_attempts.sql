@@ -0,0 +1,19 @@
+-- Tabela de Tentativas de Transação (fallback entre providers)
+CREATE TABLE transaction_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    provider_id UUID NOT NULL REFERENCES providers(id),
+    attempt INTEGER NOT NULL,
+    success BOOLEAN NOT NULL DEFAULT false,
+    error_code VARCHAR(50),
+    error_message TEXT,
+    retryable BOOLEAN NOT NULL DEFAULT false,
+    duration BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_transaction_attempts_transaction_id ON transaction_attempts(transaction_id);
+CREATE INDEX idx_transaction_attempts_provider_id ON transaction_attempts(provider_id);
+CREATE INDEX idx_transaction_attempts_created_at ON transaction_attempts(created_at);
+
+COMMENT ON TABLE transaction_attempts IS 'Tentativas de execução de transações em cada provider';
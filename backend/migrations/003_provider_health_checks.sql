@@ -0,0 +1,15 @@
+-- Histórico de verificações de saúde dos providers
+CREATE TABLE provider_health_checks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider_id UUID NOT NULL REFERENCES providers(id),
+    status VARCHAR(20) NOT NULL,
+    latency BIGINT,
+    error_code VARCHAR(50),
+    error_message TEXT,
+    checked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_provider_health_checks_provider_id ON provider_health_checks(provider_id);
+CREATE INDEX idx_provider_health_checks_checked_at ON provider_health_checks(checked_at);
+
+COMMENT ON TABLE provider_health_checks IS 'Histórico de verificações periódicas de saúde dos providers';
@@ -0,0 +1,8 @@
+-- Controle da consulta periódica de status de transações pendentes
+ALTER TABLE transactions ADD COLUMN status_checks INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN next_status_check_at TIMESTAMP;
+
+CREATE INDEX idx_transactions_next_status_check_at ON transactions(next_status_check_at);
+
+COMMENT ON COLUMN transactions.status_checks IS 'Número de consultas de status realizadas junto ao provider';
+COMMENT ON COLUMN transactions.next_status_check_at IS 'Próxima consulta de status (backoff por transação)';
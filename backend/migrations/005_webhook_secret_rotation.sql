@@ -0,0 +1,5 @@
+-- Rotação de segredo de webhooks (assinatura com ambos os segredos durante a janela de transição)
+ALTER TABLE webhooks ADD COLUMN previous_secret TEXT;
+ALTER TABLE webhooks ADD COLUMN previous_secret_expires_at TIMESTAMP;
+
+COMMENT ON COLUMN webhooks.previous_secret IS 'Segredo anterior (criptografado), válido até previous_secret_expires_at';
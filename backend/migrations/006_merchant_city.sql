@@ -0,0 +1,4 @@
+-- Cidade do merchant, obrigatória no BR Code gerado localmente (QR Code estático)
+ALTER TABLE merchants ADD COLUMN city VARCHAR(100) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN merchants.city IS 'Cidade do recebedor no BR Code (truncada para 15 caracteres sem acento)';
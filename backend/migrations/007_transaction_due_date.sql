@@ -0,0 +1,4 @@
+-- Condições das cobranças com vencimento (cobv): vencimento, multa, juros, abatimento e desconto
+ALTER TABLE transactions ADD COLUMN due_date JSONB;
+
+COMMENT ON COLUMN transactions.due_date IS 'Condições da cobrança com vencimento; o valor a pagar é calculado na data do pagamento';
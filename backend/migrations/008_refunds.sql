@@ -0,0 +1,26 @@
+-- Tabela de Devoluções de PIX recebidos (totais ou parciais)
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    provider_id UUID NOT NULL REFERENCES providers(id),
+    refund_id VARCHAR(35) NOT NULL UNIQUE,
+    rtr_id VARCHAR(32),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(4) NOT NULL,
+    description VARCHAR(140),
+    status VARCHAR(20) NOT NULL,
+    error_code VARCHAR(50),
+    error_message TEXT,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refunds_transaction_id ON refunds(transaction_id);
+CREATE INDEX idx_refunds_merchant_id ON refunds(merchant_id);
+CREATE INDEX idx_refunds_rtr_id ON refunds(rtr_id);
+CREATE INDEX idx_refunds_status ON refunds(status);
+
+COMMENT ON TABLE refunds IS 'Devoluções de PIX recebidos; a soma das não recusadas não ultrapassa o valor da transação';
+COMMENT ON COLUMN refunds.reason IS 'Motivo no SPI: MD06 (recebedor), SL02 (saque/troco), BE08 e FR01 (MED)';
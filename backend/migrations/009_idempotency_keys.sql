@@ -0,0 +1,23 @@
+-- Tabela de chaves de idempotência (header Idempotency-Key)
+CREATE TABLE idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(255),
+    response_body BYTEA,
+    locked_until TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_idempotency_keys_merchant_key ON idempotency_keys(merchant_id, key);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Respostas reproduzidas para retentativas com o mesmo Idempotency-Key; removidas após expires_at';
+COMMENT ON COLUMN idempotency_keys.locked_until IS 'Requisição original em andamento; após esse instante sem conclusão a chave pode ser reutilizada';
@@ -0,0 +1,47 @@
+-- Tabela de Transferências agendadas (únicas ou recorrentes)
+CREATE TABLE scheduled_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    external_id VARCHAR(200) NOT NULL,
+    provider_code VARCHAR(50),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    description VARCHAR(140),
+
+    payee_name VARCHAR(140) NOT NULL,
+    payee_document VARCHAR(18) NOT NULL,
+    payee_pix_key VARCHAR(77),
+    payee_pix_key_type VARCHAR(20),
+    payee_bank VARCHAR(100),
+    payee_ispb VARCHAR(8),
+    payee_account_agency VARCHAR(10),
+    payee_account_number VARCHAR(20),
+    payee_account_type VARCHAR(20),
+
+    metadata JSONB,
+
+    frequency VARCHAR(10) NOT NULL,
+    start_at TIMESTAMP NOT NULL,
+    end_date TIMESTAMP,
+    next_run_at TIMESTAMP,
+    occurrences INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,
+
+    last_transaction_id UUID,
+    last_run_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_scheduled_transfers_merchant_external ON scheduled_transfers(merchant_id, external_id);
+CREATE INDEX idx_scheduled_transfers_next_run_at ON scheduled_transfers(next_run_at);
+CREATE INDEX idx_scheduled_transfers_status ON scheduled_transfers(status);
+
+COMMENT ON TABLE scheduled_transfers IS 'Transferências agendadas; cada execução cria uma transação do tipo transfer';
+COMMENT ON COLUMN scheduled_transfers.frequency IS 'once, daily, weekly ou monthly (no dia da primeira execução ou no último dia do mês)';
+COMMENT ON COLUMN scheduled_transfers.end_date IS 'Último dia com execução, no horário de Brasília';
+COMMENT ON COLUMN scheduled_transfers.occurrences IS 'Execuções iniciadas; também serve de versão para que cada execução seja reservada por um único worker';
+
+-- Execuções das transferências agendadas
+ALTER TABLE transactions ADD COLUMN scheduled_transfer_id UUID REFERENCES scheduled_transfers(id);
+CREATE INDEX idx_transactions_scheduled_transfer_id ON transactions(scheduled_transfer_id);
@@ -0,0 +1,4 @@
+-- Valor efetivamente recebido nas cobranças, informado pelo banco no callback
+ALTER TABLE transactions ADD COLUMN paid_amount BIGINT;
+
+COMMENT ON COLUMN transactions.paid_amount IS 'Valor pago em centavos; limita o total devolvido da cobrança';
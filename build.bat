@@ -247,7 +247,7 @@ if %errorlevel% equ 0 (
 )
 
 echo Executando migrations...
-psql -d %DB_NAME% -f backend\migrations\001_initial_schema.sql >nul 2>nul
+for %%f in (backend\migrations\*.sql) do psql -d %DB_NAME% -f %%f >nul 2>nul
 echo %GREEN%[OK] Migrations executadas%NC%
 
 echo.
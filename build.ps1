@@ -194,7 +194,9 @@ function Setup-Database {
     
     # Run migrations
     Print-Info "Executando migrations..."
-    psql -d $dbName -f backend\migrations\001_initial_schema.sql | Out-Null
+    Get-ChildItem backend\migrations\*.sql | Sort-Object Name | ForEach-Object {
+        psql -d $dbName -f $_.FullName | Out-Null
+    }
     Print-Success "Migrations executadas"
     
     Write-Host ""
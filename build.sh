@@ -177,7 +177,9 @@ setup_database() {
     
     # Run migrations
     print_info "Executando migrations..."
-    psql -d $DB_NAME -f backend/migrations/001_initial_schema.sql > /dev/null 2>&1
+    for migration in backend/migrations/*.sql; do
+        psql -d $DB_NAME -f "$migration" > /dev/null 2>&1
+    done
     print_success "Migrations executadas"
     
     echo ""